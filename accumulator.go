@@ -0,0 +1,179 @@
+package mongorepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AccumulatorConfig holds the configuration of an Accumulator.
+type AccumulatorConfig struct {
+	FlushInterval time.Duration   // The period between flushes of Run, default: 1 second
+	MaxPending    int             // The number of pending documents triggering an early flush, default: 1000
+	Upsert        bool            // Create the documents that do not exist yet, default: false
+	OnError       func(err error) // Optional callback invoked when a flush of Run fails, default: an error to the repository Logger
+}
+
+// Accumulator aggregates high-frequency $inc updates (page views, metrics) in memory and flushes them
+// as one combined update per document, reducing the write load by orders of magnitude. Pending increments
+// are lost if the process dies before a flush; failed updates are kept and retried on the next flush.
+type Accumulator[T any] struct {
+	repo    *Repository[T]
+	config  AccumulatorConfig
+	mu      sync.Mutex
+	pending map[string]*pendingIncrement
+	flushed chan struct{}
+}
+
+// pendingIncrement holds the accumulated increments of a document.
+type pendingIncrement struct {
+	id     any
+	fields map[string]int64
+}
+
+// NewAccumulator creates an Accumulator on top of a repository.
+//
+// Parameters:
+//   - repo: The repository holding the counters.
+//   - config: The accumulator configuration.
+//
+// Returns:
+//   - A pointer to an Accumulator.
+func NewAccumulator[T any](repo *Repository[T], config AccumulatorConfig) *Accumulator[T] {
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+
+	if config.MaxPending <= 0 {
+		config.MaxPending = 1000
+	}
+
+	return &Accumulator[T]{repo: repo, config: config, pending: map[string]*pendingIncrement{}, flushed: make(chan struct{}, 1)}
+}
+
+// Inc adds delta to a counter of a document. Nothing is written until the next flush.
+//
+// Parameters:
+//   - id: The _id of the document.
+//   - key: The BSON key of the counter, dotted paths allowed, e.g. "views.2024-05-01".
+//   - delta: The increment, may be negative.
+func (a *Accumulator[T]) Inc(id any, key string, delta int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.add(id, map[string]int64{key: delta})
+
+	if len(a.pending) >= a.config.MaxPending {
+		select {
+		case a.flushed <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// add merges increments into the pending ones, the caller holding the lock.
+func (a *Accumulator[T]) add(id any, fields map[string]int64) {
+	documentKey := fmt.Sprintf("%T/%v", id, id)
+	increment, ok := a.pending[documentKey]
+	if !ok {
+		increment = &pendingIncrement{id: id, fields: map[string]int64{}}
+		a.pending[documentKey] = increment
+	}
+
+	for key, delta := range fields {
+		increment.fields[key] += delta
+	}
+}
+
+// Flush writes the pending increments, one update per document in a single unordered bulk write.
+// Increments whose update failed are kept for the next flush.
+//
+// Returns:
+//   - An error if the bulk write fails.
+func (a *Accumulator[T]) Flush() error {
+	a.mu.Lock()
+	pending := a.pending
+	a.pending = map[string]*pendingIncrement{}
+	a.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	increments := make([]*pendingIncrement, 0, len(pending))
+	models := make([]mongo.WriteModel, 0, len(pending))
+	for _, increment := range pending {
+		inc := bson.M{}
+		for key, delta := range increment.fields {
+			inc[key] = delta
+		}
+		increments = append(increments, increment)
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": increment.id}).
+			SetUpdate(bson.M{"$inc": inc}).
+			SetUpsert(a.config.Upsert))
+	}
+
+	_, err := a.repo.Collection().BulkWrite(a.repo.config.Context, models, options.BulkWrite().SetOrdered(false))
+	if err == nil {
+		return nil
+	}
+
+	// keep the increments that were not applied
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil {
+		for _, writeErr := range bulkErr.WriteErrors {
+			a.add(increments[writeErr.Index].id, increments[writeErr.Index].fields)
+		}
+		return err
+	}
+
+	for _, increment := range increments {
+		a.add(increment.id, increment.fields)
+	}
+
+	return err
+}
+
+// Run flushes the pending increments every FlushInterval, or earlier once MaxPending documents are pending,
+// until the context is cancelled. A last flush is done before returning.
+//
+// Parameters:
+//   - ctx: The context controlling the lifetime of the loop.
+//
+// Returns:
+//   - The error of the last flush, if any.
+func (a *Accumulator[T]) Run(ctx context.Context) error {
+	ticker := time.NewTicker(a.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return a.Flush()
+		case <-ticker.C:
+		case <-a.flushed:
+		}
+
+		if err := a.Flush(); err != nil {
+			if a.config.OnError != nil {
+				a.config.OnError(err)
+			} else {
+				a.repo.logger().Error("mongorepo accumulator flush failed",
+					slog.String("collection", a.repo.config.CollectionName),
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+	}
+}
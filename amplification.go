@@ -0,0 +1,156 @@
+package mongorepo
+
+import (
+	"cmp"
+	"math/rand/v2"
+	"slices"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// WriteAmplificationReporter measures, per collection, how much of the full-entity $set written by Update actually
+// changed: it reads the stored document of a sample of the updates, diffs it with the new version and compares the
+// size of the full update with the size of the $set of the changed paths only. The report tells how much a
+// dirty-tracking update would save, e.g. before adopting UpdateFields or PATCH endpoints on a hot collection.
+// It is safe for concurrent use and can be shared by every repository.
+type WriteAmplificationReporter struct {
+	mu          sync.Mutex
+	sampleRate  float64
+	collections map[string]*WriteAmplificationStats
+}
+
+// WriteAmplificationStats are the measurements of the sampled updates of a collection.
+type WriteAmplificationStats struct {
+	Collection string
+	Updates    uint64 // The sampled updates.
+	Unchanged  uint64 // The sampled updates writing a document identical to the stored one (UpdatedAt aside).
+	FullBytes  uint64 // The total size of their full-entity updates.
+	DiffBytes  uint64 // The total size of the updates of the changed paths only.
+}
+
+// Savings returns the share of the bytes written by the full updates that the diffs would save, from 0 to 1.
+func (s WriteAmplificationStats) Savings() float64 {
+	if s.FullBytes == 0 {
+		return 0
+	}
+
+	return 1 - float64(s.DiffBytes)/float64(s.FullBytes)
+}
+
+// NewWriteAmplificationReporter creates an empty WriteAmplificationReporter.
+//
+// Parameters:
+//   - sampleRate: The share of the updates measured, from 0 to 1, each costing an additional read of the stored
+//     document; 1 when <= 0 or > 1.
+//
+// Returns:
+//   - A pointer to a WriteAmplificationReporter, to be set as Config.WriteAmplification.
+func NewWriteAmplificationReporter(sampleRate float64) *WriteAmplificationReporter {
+	if sampleRate <= 0 || sampleRate > 1 {
+		sampleRate = 1
+	}
+
+	return &WriteAmplificationReporter{sampleRate: sampleRate, collections: map[string]*WriteAmplificationStats{}}
+}
+
+// Report returns the measurements recorded so far.
+//
+// Returns:
+//   - The stats per collection, the most bytes the diffs would save first.
+func (w *WriteAmplificationReporter) Report() []WriteAmplificationStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	report := make([]WriteAmplificationStats, 0, len(w.collections))
+	for _, stats := range w.collections {
+		report = append(report, *stats)
+	}
+
+	slices.SortFunc(report, func(a, b WriteAmplificationStats) int {
+		if c := cmp.Compare(int64(b.FullBytes)-int64(b.DiffBytes), int64(a.FullBytes)-int64(a.DiffBytes)); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Collection, b.Collection)
+	})
+
+	return report
+}
+
+// Reset forgets every measurement.
+func (w *WriteAmplificationReporter) Reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.collections = map[string]*WriteAmplificationStats{}
+}
+
+// record adds a sampled update.
+func (w *WriteAmplificationReporter) record(collection string, fullBytes, diffBytes int, unchanged bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	stats := w.collections[collection]
+	if stats == nil {
+		stats = &WriteAmplificationStats{Collection: collection}
+		w.collections[collection] = stats
+	}
+
+	stats.Updates++
+	if unchanged {
+		stats.Unchanged++
+	}
+	stats.FullBytes += uint64(fullBytes)
+	stats.DiffBytes += uint64(diffBytes)
+}
+
+// measureAmplification compares the full update of a document with the diff against its stored version, when
+// Config.WriteAmplification is set and the update is sampled. Failures only skip the measurement, the update
+// going on regardless.
+func (r *Repository[T]) measureAmplification(id any, document any) {
+	reporter := r.config.WriteAmplification
+	if reporter == nil || rand.Float64() >= reporter.sampleRate {
+		return
+	}
+
+	stored, err := r.Collection().FindOne(r.config.Context, bson.M{"_id": id}).Raw()
+	if err != nil {
+		return
+	}
+
+	updated, err := bson.Marshal(document)
+	if err != nil {
+		return
+	}
+
+	changes, err := diffDocuments("", stored, updated)
+	if err != nil {
+		return
+	}
+
+	full, err := bson.Marshal(bson.M{"$set": bson.Raw(updated)})
+	if err != nil {
+		return
+	}
+
+	// a full $set never removes the stored fields missing from the entity, so neither does the diff
+	set := bson.D{}
+	unchanged := true
+	for _, change := range changes {
+		if change.Kind == FieldRemoved {
+			continue
+		}
+
+		set = append(set, bson.E{Key: change.Path, Value: change.New})
+		if r.config.UpdatedAtField == "" || change.Path != r.fieldKey(r.config.UpdatedAtField) {
+			unchanged = false
+		}
+	}
+
+	diff, err := bson.Marshal(bson.M{"$set": set})
+	if err != nil {
+		return
+	}
+
+	reporter.record(r.config.CollectionName, len(full), len(diff), unchanged)
+}
@@ -0,0 +1,179 @@
+package mongorepo
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrBackupNotFound is returned by RestoreBackup when the backup does not exist (anymore).
+var ErrBackupNotFound error = &kindError{kind: ErrNotFound, err: errors.New("mongorepo: backup not found")}
+
+// BackupInfo describes a backup taken before a destructive operation.
+type BackupInfo struct {
+	ID         primitive.ObjectID `bson:"_id"`          // The id of the backup, passed to RestoreBackup.
+	Operation  string             `bson:"op"`           // The operation that took the backup, e.g. "DeleteMany".
+	Collection string             `bson:"collection"`   // The collection of the backed up documents.
+	BackedUpAt time.Time          `bson:"backed_up_at"` // When the backup was taken.
+	Documents  int64              `bson:"documents"`    // The number of backed up documents.
+}
+
+// backup copies the documents matching the filter to the BackupCollection, server-side with $merge, before
+// they are changed or removed by a destructive operation. Each copy is stored as {_id: {backup, id}, op,
+// collection, backed_up_at, document} and expires after the BackupRetention. A no-op without BackupCollection.
+func (r *Repository[T]) backup(operation string, filter bson.M) error {
+	if r.config.BackupCollection == "" {
+		return nil
+	}
+
+	ctx := r.config.Context
+	target := r.backups()
+
+	ttl := mongo.IndexModel{
+		Keys:    bson.D{{Key: "backed_up_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(r.config.BackupRetention.Seconds())),
+	}
+	if _, err := target.Indexes().CreateOne(ctx, ttl); err != nil {
+		return err
+	}
+
+	backupID := primitive.NewObjectID()
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$replaceWith", Value: bson.M{
+			"_id":          bson.M{"backup": backupID, "id": "$_id"},
+			"op":           operation,
+			"collection":   r.config.CollectionName,
+			"backed_up_at": "$$NOW",
+			"document":     "$$ROOT",
+		}}},
+		{{Key: "$merge", Value: bson.M{"into": r.config.BackupCollection, "whenMatched": "replace"}}},
+	}
+
+	cursor, err := r.Collection().Aggregate(ctx, pipeline, r.aggregateComment(operation))
+	if err != nil {
+		return err
+	}
+
+	return cursor.Close(ctx)
+}
+
+// Backups lists the unexpired backups of the collection, most recent first.
+//
+// Returns:
+//   - The backups.
+//   - An error if the aggregation fails.
+//
+// Panics:
+//   - If the BackupCollection is not set.
+func (r *Repository[T]) Backups() ([]BackupInfo, error) {
+	ctx := r.config.Context
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"collection": r.config.CollectionName}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":          "$_id.backup",
+			"op":           bson.M{"$first": "$op"},
+			"collection":   bson.M{"$first": "$collection"},
+			"backed_up_at": bson.M{"$first": "$backed_up_at"},
+			"documents":    bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "backed_up_at", Value: -1}, {Key: "_id", Value: -1}}}},
+	}
+
+	cursor, err := r.backups().Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	backups := []BackupInfo{}
+	err = cursor.All(ctx, &backups)
+	return backups, err
+}
+
+// RestoreBackup writes the backed up documents back into the collection, replacing their current version
+// or re-inserting them when they were deleted. Documents written since the backup are left untouched.
+//
+// Parameters:
+//   - id: The id of the backup, see Backups.
+//
+// Returns:
+//   - The number of restored documents.
+//   - ErrBackupNotFound if the backup does not exist, or an error if a read or a write fails.
+//
+// Panics:
+//   - If the BackupCollection is not set.
+func (r *Repository[T]) RestoreBackup(id primitive.ObjectID) (int64, error) {
+	ctx := r.config.Context
+
+	cursor, err := r.backups().Find(ctx, bson.M{"_id.backup": id}, options.Find().SetBatchSize(defaultBatchSize))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var restored int64
+	models := []mongo.WriteModel{}
+	flush := func() error {
+		if len(models) == 0 {
+			return nil
+		}
+		result, err := r.Collection().BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		if result != nil {
+			restored += result.UpsertedCount + result.MatchedCount
+		}
+		models = models[:0]
+		return err
+	}
+
+	for cursor.Next(ctx) {
+		document := cursor.Current.Lookup("document").Document()
+		filter := bson.M{"_id": decodeRawValue(document.Lookup("_id"))}
+		models = append(models, mongo.NewReplaceOneModel().SetFilter(filter).SetReplacement(document).SetUpsert(true))
+
+		if len(models) == defaultBatchSize {
+			if err := flush(); err != nil {
+				return restored, err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return restored, err
+	}
+
+	if err := flush(); err != nil {
+		return restored, err
+	}
+
+	if restored == 0 {
+		return 0, ErrBackupNotFound
+	}
+
+	return restored, nil
+}
+
+// Drop removes the collection with its documents and indexes, backing the documents up first when the
+// BackupCollection is set.
+//
+// Returns:
+//   - An error if the backup or the drop fails.
+func (r *Repository[T]) Drop() error {
+	if err := r.backup("Drop", bson.M{}); err != nil {
+		return err
+	}
+
+	return r.Collection().Drop(r.config.Context)
+}
+
+// backups returns the collection storing the backups.
+func (r *Repository[T]) backups() *mongo.Collection {
+	if r.config.BackupCollection == "" {
+		panic("Configuration error: The BackupCollection is not set.")
+	}
+
+	return r.Database().Collection(r.config.BackupCollection)
+}
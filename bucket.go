@@ -0,0 +1,119 @@
+package mongorepo
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Bucket is a document of the bucket pattern: items sharing a key are grouped into documents holding up to
+// a maximum number of items, instead of one document per item. This keeps indexes small and reads sequential
+// for high-volume, time-ordered data (sensor readings, events) on servers without time-series collections.
+//
+// Use it as the entity of a repository: mongorepo.New[mongorepo.Bucket[Reading]](config).
+type Bucket[I any] struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	Key       string             `bson:"key"`
+	Count     int                `bson:"count"`
+	Items     []I                `bson:"items"`
+	CreatedAt time.Time          `bson:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at"`
+}
+
+// AppendToBucket pushes an item into the current bucket of a key, in a single atomic upsert:
+// a new bucket is created when the key has none or when all its buckets are full.
+//
+// Parameters:
+//   - r: The repository of the buckets.
+//   - bucketKey: The key grouping the items, e.g. a sensor id, or a sensor id and a day.
+//   - item: The item to append.
+//   - maxBucketSize: The maximum number of items per bucket.
+//
+// Returns:
+//   - An error if the update fails.
+//
+// Panics:
+//   - If maxBucketSize is not positive.
+func AppendToBucket[I any](r *Repository[Bucket[I]], bucketKey string, item I, maxBucketSize int) error {
+	if maxBucketSize <= 0 {
+		panic("Configuration error: The maxBucketSize must be positive.")
+	}
+
+	now := time.Now()
+	filter := bson.M{"key": bucketKey, "count": bson.M{"$lt": maxBucketSize}}
+	update := bson.M{
+		"$push":        bson.M{"items": item},
+		"$inc":         bson.M{"count": 1},
+		"$set":         bson.M{"updated_at": now},
+		"$setOnInsert": bson.M{"created_at": now},
+	}
+
+	_, err := r.Collection().UpdateOne(r.config.Context, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+// EnsureBucketIndex creates the index on key and count used to find the current bucket of a key.
+//
+// Parameters:
+//   - r: The repository of the buckets.
+//
+// Returns:
+//   - An error if the index cannot be created.
+func EnsureBucketIndex[I any](r *Repository[Bucket[I]]) error {
+	index := mongo.IndexModel{Keys: bson.D{{Key: "key", Value: 1}, {Key: "count", Value: 1}}}
+	_, err := r.Collection().Indexes().CreateOne(r.config.Context, index)
+	return err
+}
+
+// BucketItemStream streams the items of the buckets matching a filter, flattened in bucket creation
+// and insertion order.
+//
+// Parameters:
+//   - r: The repository of the buckets.
+//   - filter: A BSON map selecting the buckets, e.g. bson.M{"key": "sensor-1"}.
+//
+// Returns:
+//   - A Stream of the items.
+//   - An error if the aggregation fails.
+func BucketItemStream[I any](r *Repository[Bucket[I]], filter bson.M) (*Stream[I], error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+		{{Key: "$unwind", Value: "$items"}},
+		{{Key: "$project", Value: bson.M{"_id": 0, "item": "$items"}}},
+	}
+
+	cursor, err := r.Aggregate(&pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	type flattened struct {
+		Item I `bson:"item"`
+	}
+
+	return Map(NewStream[flattened](r.config.Context, cursor), func(f flattened) (I, error) {
+		return f.Item, nil
+	}), nil
+}
+
+// BucketItems returns every item stored under a bucket key, flattened in insertion order.
+//
+// Parameters:
+//   - r: The repository of the buckets.
+//   - bucketKey: The key grouping the items.
+//
+// Returns:
+//   - The items of the key.
+//   - An error if the aggregation fails.
+func BucketItems[I any](r *Repository[Bucket[I]], bucketKey string) ([]I, error) {
+	stream, err := BucketItemStream(r, bson.M{"key": bucketKey})
+	if err != nil {
+		return nil, err
+	}
+
+	return stream.Collect()
+}
@@ -0,0 +1,219 @@
+package mongorepo
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BulkResult holds the counts of an executed Bulk.
+type BulkResult struct {
+	Inserted    int64         // The number of inserted documents.
+	Matched     int64         // The number of documents matched by the updates and replacements.
+	Modified    int64         // The number of documents modified by the updates, replacements and soft deletes.
+	Deleted     int64         // The number of deleted documents.
+	Upserted    int64         // The number of documents inserted by upserts.
+	UpsertedIDs map[int64]any // The _id of the upserted documents, keyed by the index of their operation.
+}
+
+// Bulk accumulates typed write operations on the entities of a repository and executes them in a single BulkWrite.
+// The fields maintained by the repository are set as each operation is added, like Create, Update and Delete do.
+type Bulk[T any] struct {
+	repo    *Repository[T]
+	models  []mongo.WriteModel
+	ordered bool
+	err     error
+	written func() // Optional callback run after the bulk write, e.g. to invalidate a cache.
+}
+
+// Bulk starts a new ordered bulk write on the repository collection.
+//
+// Returns:
+//   - A pointer to an empty Bulk.
+func (r *Repository[T]) Bulk() *Bulk[T] {
+	return &Bulk[T]{repo: r, ordered: true}
+}
+
+// Ordered sets whether the operations run in order, stopping at the first error (the default),
+// or in any order, running every operation regardless of errors, which is faster.
+//
+// Parameters:
+//   - ordered: Whether the operations run in order.
+//
+// Returns:
+//   - The Bulk, for chaining.
+func (b *Bulk[T]) Ordered(ordered bool) *Bulk[T] {
+	b.ordered = ordered
+	return b
+}
+
+// InsertOne adds the insertion of an entity, setting its ID and CreatedAt field.
+//
+// Parameters:
+//   - entity: A pointer to the entity of type `T` to be inserted.
+//
+// Returns:
+//   - The Bulk, for chaining.
+func (b *Bulk[T]) InsertOne(entity *T) *Bulk[T] {
+	if err := b.repo.guardRegion(entity); err != nil {
+		b.fail(err)
+		return b
+	}
+
+	er := NewEntityReflection(b.repo.config, entity)
+	if err := b.repo.assignIDs(er); err != nil {
+		b.fail(err)
+		return b
+	}
+	b.repo.prepareInsert(er)
+
+	document, err := b.repo.guardSize(entity)
+	if err != nil {
+		b.fail(err)
+		return b
+	}
+
+	b.models = append(b.models, mongo.NewInsertOneModel().SetDocument(document))
+	return b
+}
+
+// UpdateOne adds an update of the first document matching the filter, setting the UpdatedAt field when configured.
+//
+// Parameters:
+//   - filter: A BSON map selecting the document to update.
+//   - update: A BSON map of update operators.
+//   - upsert: Whether a document is inserted when nothing matches.
+//
+// Returns:
+//   - The Bulk, for chaining.
+func (b *Bulk[T]) UpdateOne(filter bson.M, update bson.M, upsert bool) *Bulk[T] {
+	model := mongo.NewUpdateOneModel().
+		SetFilter(b.repo.regionScope(filter)).
+		SetUpdate(b.repo.stampUpdate(update)).
+		SetUpsert(upsert)
+
+	b.models = append(b.models, model)
+	return b
+}
+
+// ReplaceOne adds the replacement of the stored document of an entity, matched by its ID, setting the UpdatedAt field.
+// When VersionField is configured, only the document still holding the version of the entity is replaced,
+// and the version is incremented: stale entities show up as a Matched count lower than expected.
+//
+// Parameters:
+//   - entity: A pointer to the entity of type `T` with the new data.
+//
+// Returns:
+//   - The Bulk, for chaining.
+func (b *Bulk[T]) ReplaceOne(entity *T) *Bulk[T] {
+	if err := b.repo.guardRegion(entity); err != nil {
+		b.fail(err)
+		return b
+	}
+
+	er := NewEntityReflection(b.repo.config, entity)
+	if b.repo.config.UpdatedAtField != "" {
+		er.SetUpdateAt()
+	}
+	b.repo.setDedupeKey(er)
+
+	filter := b.repo.entityFilter(er)
+	if b.repo.config.VersionField != "" {
+		version := er.GetVersion()
+		filter[b.repo.fieldKey(b.repo.config.VersionField)] = versionFilter(version)
+		er.SetVersion(version + 1)
+	}
+
+	document, err := b.repo.guardSize(entity)
+	if err != nil {
+		b.fail(err)
+		return b
+	}
+
+	model := mongo.NewReplaceOneModel().
+		SetFilter(filter).
+		SetReplacement(document)
+
+	b.models = append(b.models, model)
+	return b
+}
+
+// DeleteOne adds the deletion of the first document matching the filter.
+// If the configuration supports soft deletes, its DeletedAt field is set instead.
+//
+// Parameters:
+//   - filter: A BSON map selecting the document to delete.
+//
+// Returns:
+//   - The Bulk, for chaining.
+func (b *Bulk[T]) DeleteOne(filter bson.M) *Bulk[T] {
+	if b.repo.config.DeletedAtField != "" {
+		key := b.repo.fieldKey(b.repo.config.DeletedAtField)
+		model := mongo.NewUpdateOneModel().
+			SetFilter(b.repo.regionScope(bson.M{"$and": bson.A{filter, bson.M{key: bson.M{"$exists": false}}}})).
+			SetUpdate(b.repo.stampUpdate(bson.M{"$set": bson.M{key: time.Now()}}))
+
+		b.models = append(b.models, model)
+		return b
+	}
+
+	b.models = append(b.models, mongo.NewDeleteOneModel().SetFilter(b.repo.regionScope(filter)))
+	return b
+}
+
+// Len returns the number of operations added so far.
+//
+// Returns:
+//   - The number of operations.
+func (b *Bulk[T]) Len() int {
+	return len(b.models)
+}
+
+// Execute runs the accumulated operations with a single BulkWrite and resets the Bulk.
+//
+// Returns:
+//   - The counts of the executed operations; with unordered bulks they are set even when some operations failed.
+//   - The first error met while adding an operation (in which case nothing is written), or the bulk write error.
+func (b *Bulk[T]) Execute() (*BulkResult, error) {
+	models, err := b.models, b.err
+	b.models, b.err = nil, nil
+
+	if err == nil {
+		err = b.repo.guardRegion()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BulkResult{UpsertedIDs: map[int64]any{}}
+	if len(models) == 0 {
+		return result, nil
+	}
+
+	if b.written != nil {
+		defer b.written()
+	}
+
+	bulk, err := b.repo.Collection().BulkWrite(b.repo.config.Context, models, b.repo.bulkWriteComment("Bulk"), options.BulkWrite().SetOrdered(b.ordered))
+	if bulk != nil {
+		result.Inserted = bulk.InsertedCount
+		result.Matched = bulk.MatchedCount
+		result.Modified = bulk.ModifiedCount
+		result.Deleted = bulk.DeletedCount
+		result.Upserted = bulk.UpsertedCount
+		for index, id := range bulk.UpsertedIDs {
+			result.UpsertedIDs[index] = id
+		}
+	}
+
+	return result, err
+}
+
+// fail records the first error met while adding an operation.
+func (b *Bulk[T]) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
@@ -0,0 +1,669 @@
+package mongorepo
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/sync/singleflight"
+)
+
+// CacheEntry is a cached query result: the matching documents encoded as BSON and the time they were loaded.
+// Documents are cached encoded so every read decodes its own copy of the entities.
+type CacheEntry struct {
+	Documents []bson.Raw
+	StoredAt  time.Time
+}
+
+// CacheStore is the storage backend of a CachedRepository. Implementations must be safe for concurrent use.
+type CacheStore interface {
+	Get(key string) (CacheEntry, bool)
+	Set(key string, entry CacheEntry)
+	Clear()
+}
+
+// CacheDeleter is implemented by the CacheStores able to remove a single entry, used by InvalidateAggregate;
+// with other stores, InvalidateAggregate clears the whole cache.
+type CacheDeleter interface {
+	Delete(key string)
+}
+
+// MemoryCache is an in-memory CacheStore.
+type MemoryCache struct {
+	mu      sync.RWMutex
+	entries map[string]CacheEntry
+}
+
+// NewMemoryCache creates an empty in-memory CacheStore.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: map[string]CacheEntry{}}
+}
+
+// Get returns the entry stored under key.
+func (m *MemoryCache) Get(key string) (CacheEntry, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entry, ok := m.entries[key]
+	return entry, ok
+}
+
+// Set stores an entry under key.
+func (m *MemoryCache) Set(key string, entry CacheEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries[key] = entry
+}
+
+// Delete removes the entry stored under key.
+func (m *MemoryCache) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+}
+
+// Clear removes every entry.
+func (m *MemoryCache) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries = map[string]CacheEntry{}
+}
+
+// CacheConfig holds the configuration of a CachedRepository.
+//
+// Entries younger than TTL are served from the cache. Entries between TTL and HardTTL are stale:
+// they are still served, and refreshed in the background (stale-while-revalidate). Entries older than
+// HardTTL are reloaded before answering.
+type CacheConfig struct {
+	Store          CacheStore                  // The cache backend, default: NewMemoryCache()
+	TTL            time.Duration               // How long an entry is fresh, default: 1 minute
+	HardTTL        time.Duration               // How long a stale entry may still be served, default: TTL (no stale serving)
+	OnRefreshError func(key string, err error) // Optional callback invoked when a background refresh fails, default: an error to the repository Logger
+}
+
+// CachedRepository decorates a Repository caching the results of its finders. Any write performed through
+// the decorator (Create, Update, Delete) clears the cache; writes done elsewhere are picked up once entries expire.
+type CachedRepository[T any] struct {
+	*Repository[T]
+	config CacheConfig
+	group  *singleflight.Group
+}
+
+// NewCachedRepository wraps a repository with a result cache.
+//
+// Parameters:
+//   - repo: The repository to decorate.
+//   - config: The cache configuration.
+//
+// Returns:
+//   - A pointer to a CachedRepository.
+func NewCachedRepository[T any](repo *Repository[T], config CacheConfig) *CachedRepository[T] {
+	if config.Store == nil {
+		config.Store = NewMemoryCache()
+	}
+
+	if config.TTL <= 0 {
+		config.TTL = time.Minute
+	}
+
+	if config.HardTTL < config.TTL {
+		config.HardTTL = config.TTL
+	}
+
+	return &CachedRepository[T]{Repository: repo, config: config, group: &singleflight.Group{}}
+}
+
+// WithContext returns a view of the cached repository whose operations use ctx, sharing the cache of `c`.
+//
+// Parameters:
+//   - ctx: The context of the operations of the view.
+//
+// Returns:
+//   - A pointer to a CachedRepository sharing the cache and configuration of `c`.
+func (c *CachedRepository[T]) WithContext(ctx context.Context) *CachedRepository[T] {
+	return &CachedRepository[T]{Repository: c.Repository.WithContext(ctx), config: c.config, group: c.group}
+}
+
+// FindByHexId retrieves a single entity by the string representation of its ObjectID, through the cache.
+//
+// Parameters:
+//   - id: the string representation of the object id.
+//
+// Returns:
+//   - A pointer to the entity of type `T`.
+//   - ErrInvalidID if the id is invalid, ErrNotFound if not found, or an error if the operation fails.
+func (c *CachedRepository[T]) FindByHexId(id string) (*T, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, &kindError{kind: ErrInvalidID, err: err}
+	}
+
+	return c.FindById(objectID)
+}
+
+// FindById retrieves an entity by its ID, through the cache.
+//
+// Parameters:
+//   - id: The ID of the entity to retrieve.
+//
+// Returns:
+//   - A pointer to the entity of type `T`.
+//   - ErrNotFound if not found, or an error if the operation fails.
+func (c *CachedRepository[T]) FindById(id any) (*T, error) {
+	return c.FindOne(bson.M{"_id": id})
+}
+
+// FindOne retrieves a single entity matching the query, through the cache.
+// Missing documents are cached too.
+//
+// Parameters:
+//   - query: A BSON map defining the search criteria.
+//   - opts: Optional FindOneOptions to modify the query behavior.
+//
+// Returns:
+//   - A pointer to the entity of type `T`.
+//   - ErrNotFound if no document matches the query, or an error if the operation fails.
+func (c *CachedRepository[T]) FindOne(query bson.M, opts ...*options.FindOneOptions) (*T, error) {
+	documents, err := c.remember(c.cacheKey("FindOne", query, opts), func(repo *Repository[T]) ([]*T, error) {
+		entity, err := repo.unredacted().FindOne(query, opts...)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return []*T{entity}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(documents) == 0 {
+		return nil, errNoDocuments
+	}
+	c.redact(documents[0])
+
+	return documents[0], nil
+}
+
+// Find retrieves all entities matching the query, through the cache.
+//
+// Parameters:
+//   - query: A BSON map defining the search criteria.
+//   - opts: Optional FindOptions to modify the query behavior (e.g., sorting, pagination).
+//
+// Returns:
+//   - A slice of pointers to entities of type `T` that match the query.
+//   - An error if the operation fails.
+func (c *CachedRepository[T]) Find(query bson.M, opts ...*options.FindOptions) ([]*T, error) {
+	entities, err := c.remember(c.cacheKey("Find", query, opts), func(repo *Repository[T]) ([]*T, error) {
+		return repo.unredacted().Find(query, opts...)
+	})
+	c.redact(entities...)
+
+	return entities, err
+}
+
+// Create inserts a new entity and clears the cache.
+//
+// Parameters:
+//   - entity: A pointer to the entity of type `T` to be inserted.
+//
+// Returns:
+//   - An error if the insertion fails.
+func (c *CachedRepository[T]) Create(entity *T) error {
+	defer c.Invalidate()
+	return c.Repository.Create(entity)
+}
+
+// CreateMany inserts several entities and clears the cache.
+//
+// Parameters:
+//   - entities: The pointers to the entities of type `T` to be inserted.
+//
+// Returns:
+//   - An error if an insertion fails.
+func (c *CachedRepository[T]) CreateMany(entities []*T) error {
+	defer c.Invalidate()
+	return c.Repository.CreateMany(entities)
+}
+
+// Update modifies an existing entity and clears the cache.
+//
+// Parameters:
+//   - entity: A pointer to the entity of type `T` with updated data.
+//
+// Returns:
+//   - An error if the update operation fails.
+func (c *CachedRepository[T]) Update(entity *T) error {
+	defer c.Invalidate()
+	return c.Repository.Update(entity)
+}
+
+// Delete removes an entity and clears the cache.
+//
+// Parameters:
+//   - entity: A pointer to the entity of type `T` to be deleted.
+//
+// Returns:
+//   - An error if the deletion fails.
+func (c *CachedRepository[T]) Delete(entity *T) error {
+	defer c.Invalidate()
+	return c.Repository.Delete(entity)
+}
+
+// Upsert updates or inserts an entity and clears the cache.
+//
+// Parameters:
+//   - entity: A pointer to the entity of type `T` to be written.
+//   - filter: A BSON map selecting the document to update.
+//
+// Returns:
+//   - true if the entity was inserted.
+//   - An error if the operation fails.
+func (c *CachedRepository[T]) Upsert(entity *T, filter bson.M) (bool, error) {
+	defer c.Invalidate()
+	return c.Repository.Upsert(entity, filter)
+}
+
+// UpdateFields writes only the given fields of a document and clears the cache.
+//
+// Parameters:
+//   - id: The ID of the document to update.
+//   - fields: A BSON map of the keys to set and their values.
+//
+// Returns:
+//   - An error if the update operation fails.
+func (c *CachedRepository[T]) UpdateFields(id any, fields bson.M) error {
+	defer c.Invalidate()
+	return c.Repository.UpdateFields(id, fields)
+}
+
+// ForceDelete permanently removes an entity and clears the cache.
+//
+// Parameters:
+//   - entity: A pointer to the entity of type `T` to be deleted.
+//
+// Returns:
+//   - An error if the deletion fails.
+func (c *CachedRepository[T]) ForceDelete(entity *T) error {
+	defer c.Invalidate()
+	return c.Repository.ForceDelete(entity)
+}
+
+// Restore undoes the soft delete of an entity and clears the cache.
+//
+// Parameters:
+//   - entity: A pointer to the soft-deleted entity of type `T`.
+//
+// Returns:
+//   - An error if the update fails.
+func (c *CachedRepository[T]) Restore(entity *T) error {
+	defer c.Invalidate()
+	return c.Repository.Restore(entity)
+}
+
+// UpdateMany updates every document matching the filter and clears the cache.
+//
+// Parameters:
+//   - filter: A BSON map selecting the documents to update.
+//   - update: A BSON map of update operators.
+//
+// Returns:
+//   - The number of modified documents.
+//   - An error if the update fails.
+func (c *CachedRepository[T]) UpdateMany(filter bson.M, update bson.M) (int64, error) {
+	defer c.Invalidate()
+	return c.Repository.UpdateMany(filter, update)
+}
+
+// DeleteMany deletes every document matching the filter and clears the cache.
+//
+// Parameters:
+//   - filter: A BSON map selecting the documents to delete.
+//
+// Returns:
+//   - The number of deleted documents.
+//   - An error if the deletion fails.
+func (c *CachedRepository[T]) DeleteMany(filter bson.M) (int64, error) {
+	defer c.Invalidate()
+	return c.Repository.DeleteMany(filter)
+}
+
+// ResyncCopies propagates the fields of a changed source document to the copies referencing it and clears the cache.
+//
+// Parameters:
+//   - sourceCollection: The collection of the changed document.
+//   - sourceID: The value of the SourceField of the changed document.
+//
+// Returns:
+//   - The number of documents whose copy changed.
+//   - An error if the resync fails.
+func (c *CachedRepository[T]) ResyncCopies(sourceCollection string, sourceID any) (int64, error) {
+	defer c.Invalidate()
+	return c.Repository.ResyncCopies(sourceCollection, sourceID)
+}
+
+// RestoreBackup writes the documents of a backup back into the collection and clears the cache.
+//
+// Parameters:
+//   - id: The id of the backup.
+//
+// Returns:
+//   - The number of restored documents.
+//   - An error if the restore fails.
+func (c *CachedRepository[T]) RestoreBackup(id primitive.ObjectID) (int64, error) {
+	defer c.Invalidate()
+	return c.Repository.RestoreBackup(id)
+}
+
+// Drop removes the collection and clears the cache.
+//
+// Returns:
+//   - An error if the backup or the drop fails.
+func (c *CachedRepository[T]) Drop() error {
+	defer c.Invalidate()
+	return c.Repository.Drop()
+}
+
+// DeleteManyThrottled removes every document matching the filter in paced batches and clears the cache.
+//
+// Parameters:
+//   - filter: A BSON map selecting the documents to delete.
+//   - batchSize: The number of documents deleted per batch.
+//   - pause: The time waited between two batches.
+//   - onProgress: Called after each batch, may be nil.
+//
+// Returns:
+//   - The progress of the deletion, also on failure.
+//   - An error if the deletion fails.
+func (c *CachedRepository[T]) DeleteManyThrottled(filter bson.M, batchSize int, pause time.Duration, onProgress func(progress DeleteProgress)) (*DeleteProgress, error) {
+	defer c.Invalidate()
+	return c.Repository.DeleteManyThrottled(filter, batchSize, pause, onProgress)
+}
+
+// FindOneAndUpdate atomically updates the first document matching the filter, returns it and clears the cache.
+//
+// Parameters:
+//   - filter: A BSON map selecting the document to update.
+//   - update: A BSON map of update operators.
+//   - opts: Optional FindOneAndUpdateOptions.
+//
+// Returns:
+//   - A pointer to the decoded entity of type `T`.
+//   - An error if the operation fails.
+func (c *CachedRepository[T]) FindOneAndUpdate(filter bson.M, update bson.M, opts ...*options.FindOneAndUpdateOptions) (*T, error) {
+	defer c.Invalidate()
+	return c.Repository.FindOneAndUpdate(filter, update, opts...)
+}
+
+// FindOneAndDelete atomically deletes the first document matching the filter, returns it and clears the cache.
+//
+// Parameters:
+//   - filter: A BSON map selecting the document to delete.
+//
+// Returns:
+//   - A pointer to the decoded entity of type `T`.
+//   - An error if the operation fails.
+func (c *CachedRepository[T]) FindOneAndDelete(filter bson.M) (*T, error) {
+	defer c.Invalidate()
+	return c.Repository.FindOneAndDelete(filter)
+}
+
+// ApplyJSONPatch validates and applies a patch to a document in a single atomic update and clears the cache.
+//
+// Parameters:
+//   - id: The ID of the document to patch.
+//   - patch: The patch document.
+//   - format: The format of the patch, MergePatch or JSONPatch.
+//
+// Returns:
+//   - An error if the patch is invalid or the update fails.
+func (c *CachedRepository[T]) ApplyJSONPatch(id any, patch []byte, format PatchFormat) error {
+	defer c.Invalidate()
+	return c.Repository.ApplyJSONPatch(id, patch, format)
+}
+
+// Modify loads a document, applies fn to it, saves it unless it changed concurrently and clears the cache.
+//
+// Parameters:
+//   - id: The ID of the document to modify.
+//   - fn: The modification applied to the loaded entity.
+//
+// Returns:
+//   - An error if fn, a read or the write fails, or every attempt lost against a concurrent write.
+func (c *CachedRepository[T]) Modify(id any, fn func(entity *T) error) error {
+	defer c.Invalidate()
+	return c.Repository.Modify(id, fn)
+}
+
+// ImportFrom streams entities from an external source into the collection in batches and clears the cache.
+//
+// Parameters:
+//   - iterator: Returns the next item, false once exhausted, or an error that aborts the import.
+//   - batchSize: The number of documents per insert.
+//   - transform: Maps each item before insertion, returning nil drops it; may be nil.
+//   - opts: Optional ImportOptions (checkpoint, progress callback).
+//
+// Returns:
+//   - The progress of the import, also on failure.
+//   - An error if the iterator, an insert or the checkpoint fails.
+func (c *CachedRepository[T]) ImportFrom(iterator func() (*T, bool, error), batchSize int, transform func(*T) *T, opts ...*ImportOptions) (*ImportProgress, error) {
+	defer c.Invalidate()
+	return c.Repository.ImportFrom(iterator, batchSize, transform, opts...)
+}
+
+// CreateWithGeneratedKey creates an entity identified by a generated key, retrying collisions, and clears the cache.
+//
+// Parameters:
+//   - entity: A pointer to the entity of type `T` to be inserted.
+//   - generator: Sets a new key on the entity.
+//   - maxRetries: The number of new keys generated after a collision.
+//
+// Returns:
+//   - An error if the generator or the insertion fails, or every generated key was taken.
+func (c *CachedRepository[T]) CreateWithGeneratedKey(entity *T, generator func(entity *T) error, maxRetries int) error {
+	defer c.Invalidate()
+	return c.Repository.CreateWithGeneratedKey(entity, generator, maxRetries)
+}
+
+// EnsureUniqueSlug generates a unique slug, persists the entity and clears the cache.
+//
+// Parameters:
+//   - entity: A pointer to the entity of type `T`.
+//   - sourceField: The field in the entity struct the slug is generated from.
+//   - slugField: The field in the entity struct storing the slug.
+//
+// Returns:
+//   - An error if the source field does not produce a slug or the entity cannot be persisted.
+func (c *CachedRepository[T]) EnsureUniqueSlug(entity *T, sourceField, slugField string) error {
+	defer c.Invalidate()
+	return c.Repository.EnsureUniqueSlug(entity, sourceField, slugField)
+}
+
+// Bulk starts a new ordered bulk write whose execution clears the cache.
+//
+// Returns:
+//   - A pointer to an empty Bulk.
+func (c *CachedRepository[T]) Bulk() *Bulk[T] {
+	bulk := c.Repository.Bulk()
+	bulk.written = c.Invalidate
+	return bulk
+}
+
+// Invalidate clears every cached entry.
+func (c *CachedRepository[T]) Invalidate() {
+	c.config.Store.Clear()
+}
+
+// InvalidateAggregate removes the cached results of a pipeline, e.g. after the data of a dashboard changed outside the
+// decorator. The pipeline and the options must be the ones given to AggregateCached, on a view of the same tenant.
+//
+// Parameters:
+//   - pipeline: The cached aggregation pipeline.
+//   - opts: The aggregation options it was run with.
+func (c *CachedRepository[T]) InvalidateAggregate(pipeline *mongo.Pipeline, opts ...*options.AggregateOptions) {
+	if deleter, ok := c.config.Store.(CacheDeleter); ok {
+		deleter.Delete(c.aggregateCacheKey(pipeline, opts))
+		return
+	}
+
+	c.Invalidate()
+}
+
+// AggregateCached runs an aggregation pipeline through the cache of a CachedRepository and decodes the results into
+// `R`, like AggregateInto. The results are cached under a canonical hash of the pipeline and the options, with the
+// TTL and HardTTL of the cache, so dashboards re-running the same heavy pipeline every few seconds hit the server once
+// per TTL. Writes through the decorator, Invalidate and InvalidateAggregate drop the cached results.
+//
+// Parameters:
+//   - c: The cached repository whose collection is aggregated.
+//   - pipeline: A MongoDB aggregation pipeline represented as a slice of aggregation stages.
+//   - opts: Optional aggregation options, part of the cache key.
+//
+// Returns:
+//   - A slice with the decoded results, empty if there is none.
+//   - An error if the aggregation or the decoding fails.
+func AggregateCached[R any, T any](c *CachedRepository[T], pipeline *mongo.Pipeline, opts ...*options.AggregateOptions) ([]R, error) {
+	documents, err := c.rememberDocuments(c.aggregateCacheKey(pipeline, opts), func(repo *Repository[T]) ([]bson.Raw, error) {
+		cursor, err := repo.Aggregate(pipeline, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		documents := []bson.Raw{}
+		err = cursor.All(repo.config.Context, &documents)
+		return documents, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]R, 0, len(documents))
+	for _, raw := range documents {
+		var result R
+		if err := bson.Unmarshal(raw, &result); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// aggregateCacheKey returns the cache key of the results of a pipeline.
+func (c *CachedRepository[T]) aggregateCacheKey(pipeline *mongo.Pipeline, opts []*options.AggregateOptions) string {
+	return c.cacheKey("Aggregate", pipeline, opts)
+}
+
+// cacheKey returns the cache key of the results of an operation: a canonical hash of the operation, its arguments
+// and everything else deciding its results, i.e. the database and collection the repository resolved to
+// (so tenants sharing a cache never read each other's entries), its soft-delete scope and its region.
+func (c *CachedRepository[T]) cacheKey(operation string, args ...any) string {
+	r := c.Repository
+
+	region := ""
+	if r.region != nil {
+		region = r.region.name
+	}
+
+	key := []any{operation, r.config.DbName, r.config.CollectionName, r.softDeleteScope(), region}
+	return canonicalHash(append(key, args...)...)
+}
+
+// remember serves the entities cached under key, applying the fresh / stale / expired policy,
+// and loads them with load, from the repository to query, when needed. Concurrent loads of the same key are coalesced.
+func (c *CachedRepository[T]) remember(key string, load func(repo *Repository[T]) ([]*T, error)) ([]*T, error) {
+	documents, err := c.rememberDocuments(key, func(repo *Repository[T]) ([]bson.Raw, error) {
+		entities, err := load(repo)
+		if err != nil {
+			return nil, err
+		}
+
+		documents := make([]bson.Raw, 0, len(entities))
+		for _, entity := range entities {
+			raw, err := bson.Marshal(entity)
+			if err != nil {
+				return nil, err
+			}
+			documents = append(documents, raw)
+		}
+		return documents, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeDocuments[T](documents)
+}
+
+// rememberDocuments serves the documents cached under key like remember, loading them with load when needed.
+func (c *CachedRepository[T]) rememberDocuments(key string, load func(repo *Repository[T]) ([]bson.Raw, error)) ([]bson.Raw, error) {
+	entry, ok := c.config.Store.Get(key)
+	age := time.Since(entry.StoredAt)
+
+	if !ok || age >= c.config.HardTTL {
+		documents, err, _ := c.group.Do(key, func() (any, error) { return c.refresh(key, c.Repository, load) })
+		if err != nil {
+			return nil, err
+		}
+		return documents.([]bson.Raw), nil
+	}
+
+	if age >= c.config.TTL {
+		go c.revalidate(key, load)
+	}
+
+	return entry.Documents, nil
+}
+
+// revalidate refreshes a stale entry in the background. The refresh outlives the request that found the entry
+// stale: it keeps the values of its context (tenant, roles, region) but not its cancellation or deadline.
+func (c *CachedRepository[T]) revalidate(key string, load func(repo *Repository[T]) ([]bson.Raw, error)) {
+	repo := c.Repository.WithContext(context.WithoutCancel(c.Repository.config.Context))
+	_, err, _ := c.group.Do(key, func() (any, error) { return c.refresh(key, repo, load) })
+	if err == nil {
+		return
+	}
+
+	if c.config.OnRefreshError != nil {
+		c.config.OnRefreshError(key, err)
+	} else {
+		c.Repository.logger().Error("mongorepo cache refresh failed",
+			slog.String("collection", c.Repository.config.CollectionName),
+			slog.String("key", key),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// refresh loads the documents from repo and stores them under key.
+func (c *CachedRepository[T]) refresh(key string, repo *Repository[T], load func(repo *Repository[T]) ([]bson.Raw, error)) ([]bson.Raw, error) {
+	documents, err := load(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	c.config.Store.Set(key, CacheEntry{Documents: documents, StoredAt: time.Now()})
+	return documents, nil
+}
+
+// decodeDocuments decodes BSON documents into new entities.
+func decodeDocuments[T any](documents []bson.Raw) ([]*T, error) {
+	entities := make([]*T, 0, len(documents))
+	for _, raw := range documents {
+		var entity T
+		if err := bson.Unmarshal(raw, &entity); err != nil {
+			return nil, err
+		}
+		entities = append(entities, &entity)
+	}
+
+	return entities, nil
+}
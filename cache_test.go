@@ -0,0 +1,129 @@
+package mongorepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type cachedUser struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	Email     string             `bson:"email"`
+	DeletedAt time.Time          `bson:"deleted_at,omitempty"`
+}
+
+// offlineClient returns a client of an unreachable server: the operations reaching the server fail fast.
+func offlineClient(t *testing.T) *mongo.Client {
+	t.Helper()
+
+	client, err := mongo.Connect(context.Background(), options.Client().
+		ApplyURI("mongodb://127.0.0.1:1").
+		SetServerSelectionTimeout(100*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Disconnect(context.Background()) })
+
+	return client
+}
+
+func TestCacheKeysOfTwoTenants(t *testing.T) {
+	repo := New[cachedUser](&Config{
+		MongoClient:    offlineClient(t),
+		DbName:         "shop",
+		DeletedAtField: "DeletedAt",
+		TenantResolver: TenantDatabases("shop_"),
+	})
+	cached := NewCachedRepository(repo, CacheConfig{TTL: time.Minute})
+
+	acme := cached.WithContext(WithTenant(context.Background(), "acme"))
+	globex := cached.WithContext(WithTenant(context.Background(), "globex"))
+
+	query := bson.M{"email": "jon@example.com"}
+	noOpts := []*options.FindOneOptions(nil)
+
+	key := acme.cacheKey("FindOne", query, noOpts)
+	if key != cached.WithContext(WithTenant(context.Background(), "acme")).cacheKey("FindOne", query, noOpts) {
+		t.Error("the same query of the same tenant got different keys")
+	}
+	if key == globex.cacheKey("FindOne", query, noOpts) {
+		t.Error("the same query of two tenants got the same key")
+	}
+	if key == NewCachedRepository(repo.WithContext(WithTenant(context.Background(), "acme")).WithTrashed(), CacheConfig{}).cacheKey("FindOne", query, noOpts) {
+		t.Error("the same query with and without the trashed documents got the same key")
+	}
+	if key == NewCachedRepository(repo.WithContext(WithTenant(context.Background(), "acme")).OnCollection("archived_users"), CacheConfig{}).cacheKey("FindOne", query, noOpts) {
+		t.Error("the same query on two collections got the same key")
+	}
+
+	// the entry of acme is served to acme only, globex goes to the (unreachable) server
+	stored := cachedUser{ID: primitive.NewObjectID(), Email: "jon@example.com"}
+	raw, err := bson.Marshal(stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached.config.Store.Set(key, CacheEntry{Documents: []bson.Raw{raw}, StoredAt: time.Now()})
+
+	found, err := acme.FindOne(query)
+	if err != nil {
+		t.Fatalf("acme missed its cached entry: %v", err)
+	}
+	if found.ID != stored.ID {
+		t.Errorf("acme got %v, expected %v", found.ID, stored.ID)
+	}
+
+	if found, err := globex.FindOne(query); err == nil {
+		t.Errorf("globex was served the entry of acme: %+v", found)
+	}
+}
+
+func TestCachedWritesInvalidate(t *testing.T) {
+	repo := New[cachedUser](&Config{MongoClient: offlineClient(t), DbName: "shop"})
+	cached := NewCachedRepository(repo, CacheConfig{TTL: time.Minute})
+
+	// the writes fail against the unreachable server, the cache is cleared anyway
+	writes := map[string]func() error{
+		"ApplyJSONPatch": func() error {
+			return cached.ApplyJSONPatch(primitive.NewObjectID(), []byte(`{"email":"jon@example.com"}`), MergePatch)
+		},
+		"Modify": func() error {
+			return cached.Modify(primitive.NewObjectID(), func(user *cachedUser) error { return nil })
+		},
+		"ImportFrom": func() error {
+			done := false
+			_, err := cached.ImportFrom(func() (*cachedUser, bool, error) {
+				if done {
+					return nil, false, nil
+				}
+				done = true
+				return &cachedUser{Email: "jon@example.com"}, true, nil
+			}, 0, nil)
+			return err
+		},
+		"CreateWithGeneratedKey": func() error {
+			return cached.CreateWithGeneratedKey(&cachedUser{}, func(user *cachedUser) error {
+				user.Email = "jon@example.com"
+				return nil
+			}, 1)
+		},
+		"EnsureUniqueSlug": func() error {
+			posts := NewCachedRepository(New[sluggedPost](&Config{MongoClient: offlineClient(t), DbName: "shop"}), CacheConfig{Store: cached.config.Store})
+			return posts.EnsureUniqueSlug(&sluggedPost{Title: "Hello World"}, "Title", "Slug")
+		},
+	}
+
+	for name, write := range writes {
+		t.Run(name, func(t *testing.T) {
+			cached.config.Store.Set("key", CacheEntry{StoredAt: time.Now()})
+			write()
+			if _, ok := cached.config.Store.Get("key"); ok {
+				t.Errorf("%s kept the cached entries", name)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package mongorepo
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrUnsupported is returned when an operation needs a feature the server does not provide.
+var ErrUnsupported = errors.New("mongorepo: not supported by the server")
+
+// Topology is the kind of deployment the repository is connected to.
+type Topology int
+
+const (
+	TopologyStandalone Topology = iota // A single mongod.
+	TopologyReplicaSet                 // A replica set member.
+	TopologySharded                    // A mongos router of a sharded cluster.
+)
+
+// Capabilities describes the server version and the features available to the repository.
+type Capabilities struct {
+	Version       string   // The server version, e.g. "7.0.4".
+	VersionArray  [3]int   // The major, minor and patch numbers of the version.
+	Topology      Topology // The kind of deployment.
+	Transactions  bool     // Multi-document transactions (replica sets 4.0+, sharded clusters 4.2+).
+	ChangeStreams bool     // Change streams (replica sets and sharded clusters 3.6+).
+	TimeSeries    bool     // Time-series collections (5.0+).
+	AtlasSearch   bool     // Atlas Search $search and search index management.
+	VectorSearch  bool     // Atlas Vector Search $vectorSearch (6.0.11+ with Atlas Search).
+}
+
+// AtLeast reports whether the server version is at least major.minor.patch.
+func (c *Capabilities) AtLeast(major, minor, patch int) bool {
+	wanted := [3]int{major, minor, patch}
+	for i := range wanted {
+		if c.VersionArray[i] != wanted[i] {
+			return c.VersionArray[i] > wanted[i]
+		}
+	}
+
+	return true
+}
+
+// capabilitiesCache holds the detected capabilities per client, since they do not change while connected.
+var capabilitiesCache sync.Map
+
+// Capabilities detects the server version and the supported features. The result is cached per *mongo.Client,
+// so it can be consulted freely to select compatible code paths.
+//
+// Returns:
+//   - The capabilities of the server.
+//   - An error if the detection commands fail.
+func (r *Repository[T]) Capabilities() (*Capabilities, error) {
+	if cached, ok := capabilitiesCache.Load(r.config.MongoClient); ok {
+		return cached.(*Capabilities), nil
+	}
+
+	capabilities, err := r.detectCapabilities()
+	if err != nil {
+		return nil, err
+	}
+
+	capabilitiesCache.Store(r.config.MongoClient, capabilities)
+	return capabilities, nil
+}
+
+// detectCapabilities runs the buildInfo and hello commands and probes Atlas Search.
+func (r *Repository[T]) detectCapabilities() (*Capabilities, error) {
+	ctx := r.config.Context
+	admin := r.config.MongoClient.Database("admin")
+
+	var build struct {
+		Version      string `bson:"version"`
+		VersionArray []int  `bson:"versionArray"`
+	}
+	if err := admin.RunCommand(ctx, bson.D{{Key: "buildInfo", Value: 1}}).Decode(&build); err != nil {
+		return nil, err
+	}
+
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	err := admin.RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		// servers older than 4.4.2 only know the legacy command
+		if err = admin.RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}}).Decode(&hello); err != nil {
+			return nil, err
+		}
+	}
+
+	c := &Capabilities{Version: build.Version}
+	copy(c.VersionArray[:], build.VersionArray)
+
+	switch {
+	case hello.Msg == "isdbgrid":
+		c.Topology = TopologySharded
+	case hello.SetName != "":
+		c.Topology = TopologyReplicaSet
+	}
+
+	c.Transactions = (c.Topology == TopologyReplicaSet && c.AtLeast(4, 0, 0)) ||
+		(c.Topology == TopologySharded && c.AtLeast(4, 2, 0))
+	c.ChangeStreams = c.Topology != TopologyStandalone && c.AtLeast(3, 6, 0)
+	c.TimeSeries = c.AtLeast(5, 0, 0)
+
+	if c.AtLeast(6, 0, 7) {
+		if c.AtlasSearch, err = r.probeAtlasSearch(); err != nil {
+			return nil, err
+		}
+	}
+	c.VectorSearch = c.AtlasSearch && c.AtLeast(6, 0, 11)
+
+	return c, nil
+}
+
+// probeAtlasSearch reports whether search indexes can be listed, which only Atlas Search deployments allow.
+func (r *Repository[T]) probeAtlasSearch() (bool, error) {
+	ctx := r.config.Context
+
+	cursor, err := r.Collection().SearchIndexes().List(ctx, nil)
+	if err == nil {
+		return true, cursor.Close(ctx)
+	}
+
+	var commandErr mongo.CommandError
+	if errors.As(err, &commandErr) {
+		return commandErr.HasErrorCode(26), nil // NamespaceNotFound: supported, the collection just does not exist yet
+	}
+
+	var serverErr mongo.ServerError
+	if errors.As(err, &serverErr) {
+		return false, nil
+	}
+
+	return false, err
+}
+
+// requireCapability returns ErrUnsupported, naming the feature, when the server lacks it.
+func (r *Repository[T]) requireCapability(feature string, supported func(*Capabilities) bool) error {
+	capabilities, err := r.Capabilities()
+	if err != nil {
+		return err
+	}
+
+	if !supported(capabilities) {
+		return fmt.Errorf("%w: %s (server %s)", ErrUnsupported, feature, capabilities.Version)
+	}
+
+	return nil
+}
@@ -0,0 +1,265 @@
+package mongorepo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ChangeOperation is the operation type of a change event.
+type ChangeOperation string
+
+const (
+	ChangeInsert  ChangeOperation = "insert"  // A document was inserted.
+	ChangeUpdate  ChangeOperation = "update"  // A document was updated.
+	ChangeReplace ChangeOperation = "replace" // A document was replaced.
+	ChangeDelete  ChangeOperation = "delete"  // A document was deleted.
+)
+
+// ChangeFilter builds the pipeline of Watch from typed predicates: the operation types to receive, and conditions
+// on the fields of `T` named after the struct fields (e.g. "Status" or "Address.City"), compiled into a $match on
+// the fullDocument of the events. Update events only carry a fullDocument with the FullDocument option
+// (options.UpdateLookup), without it they never match a field predicate.
+//
+//	filter := mongorepo.Changes[Order]().
+//		Operations(mongorepo.ChangeInsert, mongorepo.ChangeUpdate).
+//		Eq("Status", "paid").
+//		Gte("Total", 100).
+//		Build()
+//	events, err := repo.Watch(filter, options.ChangeStream().SetFullDocument(options.UpdateLookup))
+type ChangeFilter[T any] struct {
+	operations []ChangeOperation
+	conditions bson.A
+}
+
+// Changes starts an empty change filter, receiving every event.
+//
+// Returns:
+//   - A pointer to a ChangeFilter.
+func Changes[T any]() *ChangeFilter[T] {
+	return &ChangeFilter[T]{conditions: bson.A{}}
+}
+
+// Operations restricts the events to the given operation types.
+//
+// Parameters:
+//   - operations: The operation types to receive.
+//
+// Returns:
+//   - The filter, for chaining.
+func (f *ChangeFilter[T]) Operations(operations ...ChangeOperation) *ChangeFilter[T] {
+	f.operations = append(f.operations, operations...)
+	return f
+}
+
+// Eq keeps the events whose document field equals value.
+//
+// Parameters:
+//   - field: The field in the entity struct, dotted for nested structs, e.g. "Address.City".
+//   - value: The expected value.
+//
+// Returns:
+//   - The filter, for chaining.
+//
+// Panics:
+//   - If the field is not found in `T`.
+func (f *ChangeFilter[T]) Eq(field string, value any) *ChangeFilter[T] {
+	return f.where(field, "$eq", value)
+}
+
+// Ne keeps the events whose document field differs from value.
+//
+// Parameters:
+//   - field: The field in the entity struct, dotted for nested structs.
+//   - value: The excluded value.
+//
+// Returns:
+//   - The filter, for chaining.
+//
+// Panics:
+//   - If the field is not found in `T`.
+func (f *ChangeFilter[T]) Ne(field string, value any) *ChangeFilter[T] {
+	return f.where(field, "$ne", value)
+}
+
+// In keeps the events whose document field equals one of the values.
+//
+// Parameters:
+//   - field: The field in the entity struct, dotted for nested structs.
+//   - values: The accepted values.
+//
+// Returns:
+//   - The filter, for chaining.
+//
+// Panics:
+//   - If the field is not found in `T`.
+func (f *ChangeFilter[T]) In(field string, values ...any) *ChangeFilter[T] {
+	return f.where(field, "$in", bson.A(values))
+}
+
+// Gt keeps the events whose document field is greater than value.
+//
+// Parameters:
+//   - field: The field in the entity struct, dotted for nested structs.
+//   - value: The exclusive lower bound.
+//
+// Returns:
+//   - The filter, for chaining.
+//
+// Panics:
+//   - If the field is not found in `T`.
+func (f *ChangeFilter[T]) Gt(field string, value any) *ChangeFilter[T] {
+	return f.where(field, "$gt", value)
+}
+
+// Gte keeps the events whose document field is greater than or equal to value.
+//
+// Parameters:
+//   - field: The field in the entity struct, dotted for nested structs.
+//   - value: The inclusive lower bound.
+//
+// Returns:
+//   - The filter, for chaining.
+//
+// Panics:
+//   - If the field is not found in `T`.
+func (f *ChangeFilter[T]) Gte(field string, value any) *ChangeFilter[T] {
+	return f.where(field, "$gte", value)
+}
+
+// Lt keeps the events whose document field is less than value.
+//
+// Parameters:
+//   - field: The field in the entity struct, dotted for nested structs.
+//   - value: The exclusive upper bound.
+//
+// Returns:
+//   - The filter, for chaining.
+//
+// Panics:
+//   - If the field is not found in `T`.
+func (f *ChangeFilter[T]) Lt(field string, value any) *ChangeFilter[T] {
+	return f.where(field, "$lt", value)
+}
+
+// Lte keeps the events whose document field is less than or equal to value.
+//
+// Parameters:
+//   - field: The field in the entity struct, dotted for nested structs.
+//   - value: The inclusive upper bound.
+//
+// Returns:
+//   - The filter, for chaining.
+//
+// Panics:
+//   - If the field is not found in `T`.
+func (f *ChangeFilter[T]) Lte(field string, value any) *ChangeFilter[T] {
+	return f.where(field, "$lte", value)
+}
+
+// Exists keeps the events whose document holds the field, with any value.
+//
+// Parameters:
+//   - field: The field in the entity struct, dotted for nested structs.
+//
+// Returns:
+//   - The filter, for chaining.
+//
+// Panics:
+//   - If the field is not found in `T`.
+func (f *ChangeFilter[T]) Exists(field string) *ChangeFilter[T] {
+	return f.where(field, "$exists", true)
+}
+
+// Changed keeps the update events setting or removing the field, its parent or one of its nested fields, e.g. to
+// react to status changes only. Other operation types do not match.
+//
+// Parameters:
+//   - field: The field in the entity struct, dotted for nested structs.
+//
+// Returns:
+//   - The filter, for chaining.
+//
+// Panics:
+//   - If the field is not found in `T`.
+func (f *ChangeFilter[T]) Changed(field string) *ChangeFilter[T] {
+	key := f.key(field)
+
+	// updatedFields holds dotted keys ("address.city"), so they are compared as strings rather than queried as paths.
+	touches := func(path any) bson.M {
+		return bson.M{"$or": bson.A{
+			bson.M{"$eq": bson.A{path, key}},
+			bson.M{"$eq": bson.A{bson.M{"$indexOfBytes": bson.A{path, key + "."}}, 0}},
+			bson.M{"$eq": bson.A{bson.M{"$indexOfBytes": bson.A{key, bson.M{"$concat": bson.A{path, "."}}}}, 0}},
+		}}
+	}
+	updated := bson.M{"$filter": bson.M{
+		"input": bson.M{"$objectToArray": bson.M{"$ifNull": bson.A{"$updateDescription.updatedFields", bson.M{}}}},
+		"cond":  touches("$$this.k"),
+	}}
+	removed := bson.M{"$filter": bson.M{
+		"input": bson.M{"$ifNull": bson.A{"$updateDescription.removedFields", bson.A{}}},
+		"cond":  touches("$$this"),
+	}}
+
+	f.conditions = append(f.conditions, bson.M{"$expr": bson.M{"$gt": bson.A{
+		bson.M{"$add": bson.A{bson.M{"$size": updated}, bson.M{"$size": removed}}}, 0,
+	}}})
+	return f
+}
+
+// Build compiles the filter into the pipeline passed to Watch.
+//
+// Returns:
+//   - The change stream pipeline, empty when the filter has no condition.
+func (f *ChangeFilter[T]) Build() mongo.Pipeline {
+	conditions := bson.A{}
+	if len(f.operations) > 0 {
+		conditions = append(conditions, bson.M{"operationType": bson.M{"$in": f.operations}})
+	}
+	conditions = append(conditions, f.conditions...)
+
+	switch len(conditions) {
+	case 0:
+		return mongo.Pipeline{}
+	case 1:
+		return mongo.Pipeline{{{Key: "$match", Value: conditions[0]}}}
+	}
+
+	return mongo.Pipeline{{{Key: "$match", Value: bson.M{"$and": conditions}}}}
+}
+
+// where adds a condition on a field of the fullDocument.
+func (f *ChangeFilter[T]) where(field, operator string, value any) *ChangeFilter[T] {
+	f.conditions = append(f.conditions, bson.M{"fullDocument." + f.key(field): bson.M{operator: value}})
+	return f
+}
+
+// key resolves a dotted path of struct fields into the BSON path of `T`.
+func (f *ChangeFilter[T]) key(field string) string {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	keys := []string{}
+
+	for _, name := range strings.Split(field, ".") {
+		t = derefType(t)
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			t = derefType(t.Elem())
+		}
+		if t.Kind() != reflect.Struct {
+			panic(fmt.Sprintf("Error: Field %q not found in entity: %s is not a struct.", field, t.String()))
+		}
+
+		structField, ok := t.FieldByName(name)
+		if !ok {
+			panic(fmt.Sprintf("Error: Field %q not found in entity. Ensure the field name is correct.", field))
+		}
+
+		keys = append(keys, bsonFieldName(t, name))
+		t = structField.Type
+	}
+
+	return strings.Join(keys, ".")
+}
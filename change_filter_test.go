@@ -0,0 +1,105 @@
+package mongorepo
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type changeAddress struct {
+	City string `bson:"city"`
+}
+
+type changeLine struct {
+	SKU string `bson:"sku"`
+}
+
+type changeOrder struct {
+	Status  string         `bson:"status"`
+	Total   float64        `bson:"total"`
+	Address *changeAddress `bson:"address"`
+	Lines   []changeLine   `bson:"lines"`
+}
+
+func TestChangeFilterBuild(t *testing.T) {
+	match := func(condition any) mongo.Pipeline {
+		return mongo.Pipeline{{{Key: "$match", Value: condition}}}
+	}
+
+	tests := []struct {
+		name     string
+		filter   *ChangeFilter[changeOrder]
+		expected mongo.Pipeline
+	}{
+		{name: "empty", filter: Changes[changeOrder](), expected: mongo.Pipeline{}},
+		{
+			name:     "operations",
+			filter:   Changes[changeOrder]().Operations(ChangeInsert, ChangeReplace),
+			expected: match(bson.M{"operationType": bson.M{"$in": []ChangeOperation{ChangeInsert, ChangeReplace}}}),
+		},
+		{
+			name:     "eq",
+			filter:   Changes[changeOrder]().Eq("Status", "paid"),
+			expected: match(bson.M{"fullDocument.status": bson.M{"$eq": "paid"}}),
+		},
+		{
+			name:     "nested pointer field",
+			filter:   Changes[changeOrder]().Ne("Address.City", "Salto"),
+			expected: match(bson.M{"fullDocument.address.city": bson.M{"$ne": "Salto"}}),
+		},
+		{
+			name:     "field of array elements",
+			filter:   Changes[changeOrder]().Exists("Lines.SKU"),
+			expected: match(bson.M{"fullDocument.lines.sku": bson.M{"$exists": true}}),
+		},
+		{
+			name:     "in",
+			filter:   Changes[changeOrder]().In("Status", "paid", "shipped"),
+			expected: match(bson.M{"fullDocument.status": bson.M{"$in": bson.A{"paid", "shipped"}}}),
+		},
+		{
+			name:   "combined",
+			filter: Changes[changeOrder]().Operations(ChangeUpdate).Gte("Total", 100).Lt("Total", 200),
+			expected: match(bson.M{"$and": bson.A{
+				bson.M{"operationType": bson.M{"$in": []ChangeOperation{ChangeUpdate}}},
+				bson.M{"fullDocument.total": bson.M{"$gte": 100}},
+				bson.M{"fullDocument.total": bson.M{"$lt": 200}},
+			}}),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if pipeline := test.filter.Build(); !reflect.DeepEqual(pipeline, test.expected) {
+				t.Errorf("got %v, expected %v", pipeline, test.expected)
+			}
+		})
+	}
+}
+
+func TestChangeFilterChanged(t *testing.T) {
+	pipeline := Changes[changeOrder]().Changed("Address.City").Build()
+	if len(pipeline) != 1 || pipeline[0][0].Key != "$match" {
+		t.Fatalf("expected a single $match, got %v", pipeline)
+	}
+
+	condition, ok := pipeline[0][0].Value.(bson.M)
+	if _, expr := condition["$expr"]; !ok || !expr {
+		t.Errorf("expected an $expr on the update description, got %v", pipeline[0][0].Value)
+	}
+}
+
+func TestChangeFilterUnknownField(t *testing.T) {
+	for _, field := range []string{"Unknown", "Status.Length", "Address.Unknown"} {
+		t.Run(field, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected a panic for %q", field)
+				}
+			}()
+			Changes[changeOrder]().Eq(field, 1)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package mongorepo
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// loadCheckpoint decodes the named checkpoint of the checkpoints collection (Config.CheckpointsCollection) into state.
+// It reports false when no checkpoint exists yet.
+func (r *Repository[T]) loadCheckpoint(name string, state any) (bool, error) {
+	var checkpoint struct {
+		State bson.Raw `bson:"state"`
+	}
+
+	err := r.checkpoints().FindOne(r.config.Context, bson.M{"_id": name}).Decode(&checkpoint)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, bson.Unmarshal(checkpoint.State, state)
+}
+
+// saveCheckpoint stores the state of the named checkpoint, replacing the previous one.
+func (r *Repository[T]) saveCheckpoint(name string, state any) error {
+	update := bson.M{"$set": bson.M{"state": state, "collection": r.config.CollectionName, "saved_at": time.Now()}}
+	_, err := r.checkpoints().UpdateOne(r.config.Context, bson.M{"_id": name}, update, options.Update().SetUpsert(true))
+	return err
+}
+
+// clearCheckpoint removes the named checkpoint once the work it tracks is complete.
+func (r *Repository[T]) clearCheckpoint(name string) error {
+	_, err := r.checkpoints().DeleteOne(r.config.Context, bson.M{"_id": name})
+	return err
+}
+
+// checkpoints returns the collection storing the checkpoints.
+func (r *Repository[T]) checkpoints() *mongo.Collection {
+	return r.Database().Collection(r.config.CheckpointsCollection)
+}
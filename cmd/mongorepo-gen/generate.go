@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// header marks the generated files.
+const header = "// Code generated by mongorepo-gen. DO NOT EDIT.\n\n"
+
+// generateFinders writes the <Type>Finders types, their finders and filter builders.
+func generateFinders(source *sourceFile) []byte {
+	var b bytes.Buffer
+	b.WriteString(header)
+	fmt.Fprintf(&b, "package %s\n\n", source.pkg)
+
+	b.WriteString(importBlock(source, "github.com/eliasnoya/mongorepo", "go.mongodb.org/mongo-driver/bson", "go.mongodb.org/mongo-driver/mongo/options"))
+
+	for _, e := range source.entities {
+		fmt.Fprintf(&b, "// %sFinders adds the generated finders to a %s repository.\n", e.name, e.name)
+		fmt.Fprintf(&b, "type %sFinders struct {\n\tmongorepo.IRepository[%s]\n}\n\n", e.name, e.name)
+
+		for _, f := range e.finders {
+			writeFinder(&b, e, f)
+		}
+	}
+
+	return b.Bytes()
+}
+
+// writeFinder writes a finder method and its filter builder. The finders call the Find and FindOne of the
+// repository with the built filter, so they get the scopes, tagging, metrics, limits and redaction of the
+// built-in operations.
+func writeFinder(b *bytes.Buffer, e *entity, f *finder) {
+	var params, args, conditions []string
+	for _, field := range f.fields {
+		name := paramName(field.name)
+		params = append(params, name+" "+field.typeExpr)
+		args = append(args, name)
+		conditions = append(conditions, fmt.Sprintf("%q: %s", field.key, name))
+	}
+
+	var described []string
+	for _, field := range f.fields {
+		described = append(described, field.name)
+	}
+
+	// filter builder
+	fmt.Fprintf(b, "// %s builds the filter of %s.\n", f.filter, f.name)
+	fmt.Fprintf(b, "func %s(%s) bson.M {\n\treturn bson.M{%s}\n}\n\n", f.filter, strings.Join(params, ", "), strings.Join(conditions, ", "))
+
+	// finder
+	if f.many {
+		fmt.Fprintf(b, "// %s retrieves the %s entities matching the given %s", f.name, e.name, strings.Join(described, " and "))
+		if f.orderBy != nil {
+			direction := "ascending"
+			if f.orderDesc {
+				direction = "descending"
+			}
+			fmt.Fprintf(b, ", sorted by %s %s", f.orderBy.name, direction)
+		}
+		fmt.Fprintf(b, ".\n")
+		fmt.Fprintf(b, "func (f %sFinders) %s(%s, opts ...*options.FindOptions) ([]*%s, error) {\n", e.name, f.name, strings.Join(params, ", "), e.name)
+		if f.orderBy != nil {
+			direction := 1
+			if f.orderDesc {
+				direction = -1
+			}
+			fmt.Fprintf(b, "\topts = append([]*options.FindOptions{options.Find().SetSort(bson.D{{Key: %q, Value: %d}})}, opts...)\n", f.orderBy.key, direction)
+		}
+		fmt.Fprintf(b, "\treturn f.Find(%s(%s), opts...)\n}\n\n", f.filter, strings.Join(args, ", "))
+		return
+	}
+
+	fmt.Fprintf(b, "// %s retrieves the %s entity matching the given %s.\n", f.name, e.name, strings.Join(described, " and "))
+	fmt.Fprintf(b, "func (f %sFinders) %s(%s, opts ...*options.FindOneOptions) (*%s, error) {\n", e.name, f.name, strings.Join(params, ", "), e.name)
+	fmt.Fprintf(b, "\treturn f.FindOne(%s(%s), opts...)\n}\n\n", f.filter, strings.Join(args, ", "))
+}
+
+// generateTests writes a test per finder checking the keys of its filter.
+func generateTests(source *sourceFile) []byte {
+	var b bytes.Buffer
+	b.WriteString(header)
+	fmt.Fprintf(&b, "package %s\n\n", source.pkg)
+	b.WriteString(importBlock(source, "testing"))
+	b.WriteString("\n")
+
+	for _, e := range source.entities {
+		for _, f := range e.finders {
+			var args, keys []string
+			for _, field := range f.fields {
+				args = append(args, fmt.Sprintf("*new(%s)", field.typeExpr))
+				keys = append(keys, fmt.Sprintf("%q", field.key))
+			}
+
+			fmt.Fprintf(&b, "func Test%s%s(t *testing.T) {\n", e.name, strings.TrimPrefix(f.name, "Find")+"Filter")
+			fmt.Fprintf(&b, "\tfilter := %s(%s)\n\n", f.filter, strings.Join(args, ", "))
+			fmt.Fprintf(&b, "\tkeys := []string{%s}\n", strings.Join(keys, ", "))
+			fmt.Fprintf(&b, "\tif len(filter) != len(keys) {\n\t\tt.Fatalf(\"expected %%d conditions, got %%d\", len(keys), len(filter))\n\t}\n")
+			fmt.Fprintf(&b, "\tfor _, key := range keys {\n\t\tif _, ok := filter[key]; !ok {\n\t\t\tt.Errorf(\"missing condition on %%q\", key)\n\t\t}\n\t}\n}\n\n")
+		}
+	}
+
+	return b.Bytes()
+}
+
+// importBlock writes the import declaration of the given paths and of the packages used by the finder parameters,
+// standard library first.
+func importBlock(source *sourceFile, paths ...string) string {
+	specs := map[string]string{}
+	for _, path := range paths {
+		specs[path] = fmt.Sprintf("%q", path)
+	}
+	for name, path := range source.imports {
+		if path[strings.LastIndex(path, "/")+1:] == name {
+			specs[path] = fmt.Sprintf("%q", path)
+		} else {
+			specs[path] = fmt.Sprintf("%s %q", name, path)
+		}
+	}
+
+	var std, external []string
+	for path, spec := range specs {
+		if strings.Contains(strings.Split(path, "/")[0], ".") {
+			external = append(external, spec)
+		} else {
+			std = append(std, spec)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(external)
+
+	groups := []string{}
+	for _, group := range [][]string{std, external} {
+		if len(group) > 0 {
+			groups = append(groups, "\t"+strings.Join(group, "\n\t"))
+		}
+	}
+
+	return "import (\n" + strings.Join(groups, "\n\n") + "\n)\n"
+}
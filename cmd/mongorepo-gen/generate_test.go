@@ -0,0 +1,164 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sampleEntity is an annotated entity covering single and many finders, ordering, acronyms and imported types.
+const sampleEntity = `package sample
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//mongorepo:find ByEmail
+//mongorepo:find ByStatusAndCountry
+//mongorepo:find AllByStatusOrderByCreatedAtDesc
+//mongorepo:find AllByOwnerID
+type User struct {
+	ID        primitive.ObjectID ` + "`bson:\"_id\"`" + `
+	Email     string             ` + "`bson:\"email\"`" + `
+	Status    string             ` + "`bson:\"status\"`" + `
+	Country   string
+	OwnerID   primitive.ObjectID ` + "`bson:\"owner_id\"`" + `
+	CreatedAt time.Time          ` + "`bson:\"created_at\"`" + `
+}
+`
+
+func TestGeneratedCodeCompiles(t *testing.T) {
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("the go tool is not available")
+	}
+
+	// inside the module, so the generated code resolves mongorepo and the driver
+	dir, err := os.MkdirTemp(".", "sample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	file := filepath.Join(dir, "user.go")
+	if err := os.WriteFile(file, []byte(sampleEntity), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	source, err := parseFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "user_finders_gen.go")
+	if err := write(out, generateFinders(source)); err != nil {
+		t.Fatal(err)
+	}
+	if err := write(strings.TrimSuffix(out, ".go")+"_test.go", generateTests(source)); err != nil {
+		t.Fatal(err)
+	}
+
+	generated, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, call := range []string{"f.FindOne(userByEmailFilter(email), opts...)", "f.Find(userAllByOwnerIDFilter(ownerID), opts...)"} {
+		if !strings.Contains(string(generated), call) {
+			t.Errorf("the generated finders do not call %s:\n%s", call, generated)
+		}
+	}
+
+	// go test compiles and vets the finders against the repository, then runs the generated filter tests
+	command := exec.Command(goTool, "test", "./"+filepath.ToSlash(dir))
+	if output, err := command.CombinedOutput(); err != nil {
+		t.Fatalf("the generated code does not compile: %v\n%s\n%s", err, output, generated)
+	}
+}
+
+func TestParseFinder(t *testing.T) {
+	e := &entity{name: "User", fields: map[string]*field{
+		"Email":      {name: "Email", key: "email"},
+		"Status":     {name: "Status", key: "status"},
+		"BrandAndCo": {name: "BrandAndCo", key: "brand"},
+		"Co":         {name: "Co", key: "co"},
+		"CreatedAt":  {name: "CreatedAt", key: "created_at"},
+	}}
+
+	tests := []struct {
+		definition string
+		name       string
+		many       bool
+		keys       []string
+		orderBy    string
+		desc       bool
+		err        bool
+	}{
+		{definition: "ByEmail", name: "FindByEmail", keys: []string{"email"}},
+		{definition: "FindByEmail", name: "FindByEmail", keys: []string{"email"}},
+		{definition: "ByEmailAndStatus", name: "FindByEmailAndStatus", keys: []string{"email", "status"}},
+		{definition: "ByBrandAndCo", name: "FindByBrandAndCo", keys: []string{"brand"}},
+		{definition: "ByBrandAndCoAndCo", name: "FindByBrandAndCoAndCo", keys: []string{"brand", "co"}},
+		{definition: "AllByStatusOrderByCreatedAt", name: "FindAllByStatusOrderByCreatedAt", many: true, keys: []string{"status"}, orderBy: "created_at"},
+		{definition: "AllByStatusOrderByCreatedAtDesc", name: "FindAllByStatusOrderByCreatedAtDesc", many: true, keys: []string{"status"}, orderBy: "created_at", desc: true},
+		{definition: "ByStatusOrderByCreatedAt", err: true},
+		{definition: "ByUnknown", err: true},
+		{definition: "ByEmailOrStatus", err: true},
+		{definition: "Email", err: true},
+		{definition: "By", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.definition, func(t *testing.T) {
+			f, err := parseFinder(e, test.definition)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", f)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if f.name != test.name || f.many != test.many || f.orderDesc != test.desc {
+				t.Errorf("got name %q, many %v, desc %v", f.name, f.many, f.orderDesc)
+			}
+
+			var keys []string
+			for _, used := range f.fields {
+				keys = append(keys, used.key)
+			}
+			if strings.Join(keys, ",") != strings.Join(test.keys, ",") {
+				t.Errorf("got keys %v, expected %v", keys, test.keys)
+			}
+
+			orderBy := ""
+			if f.orderBy != nil {
+				orderBy = f.orderBy.key
+			}
+			if orderBy != test.orderBy {
+				t.Errorf("got order %q, expected %q", orderBy, test.orderBy)
+			}
+		})
+	}
+}
+
+func TestLowerFirst(t *testing.T) {
+	tests := map[string]string{
+		"CreatedAt": "createdAt",
+		"ID":        "id",
+		"URLPath":   "urlPath",
+		"OwnerID":   "ownerID",
+		"x":         "x",
+	}
+
+	for in, expected := range tests {
+		if got := lowerFirst(in); got != expected {
+			t.Errorf("lowerFirst(%q) = %q, expected %q", in, got, expected)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Command mongorepo-gen generates typed finder methods from query definitions declared as comments
+// on entity structs, cutting the boilerplate of hand-written custom finders:
+//
+//	//mongorepo:find ByEmail
+//	//mongorepo:find AllByStatusAndCountryOrderByCreatedAtDesc
+//	type User struct {
+//		ID        primitive.ObjectID `bson:"_id"`
+//		Email     string             `bson:"email"`
+//		Status    string             `bson:"status"`
+//		Country   string             `bson:"country"`
+//		CreatedAt time.Time          `bson:"created_at"`
+//	}
+//
+// Definitions follow the grammar [All]By<Field>[And<Field>...][OrderBy<Field>[Desc]]: "All" finders return
+// every match, the others a single entity. For each annotated struct, a <Type>Finders type wrapping
+// mongorepo.IRepository[<Type>] is generated, to be embedded in the custom repository:
+//
+//	type UserRepository struct {
+//		UserFinders
+//	}
+//
+//	repo := UserRepository{UserFinders{mongorepo.New[User](config)}}
+//	user, err := repo.FindByEmail("a@b.c")
+//
+// Generated finders run the Find and FindOne of the repository with the built filter, so they behave exactly like
+// the built-in operations: soft-delete and region scopes, $comment tagging, metrics, MaxFindLimit and redaction.
+// A test file checking the generated filters is written next to the finders.
+//
+// Usage, typically from a go:generate directive in the file declaring the entities:
+//
+//	//go:generate go run github.com/eliasnoya/mongorepo/cmd/mongorepo-gen -file $GOFILE
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"strings"
+)
+
+func main() {
+	file := flag.String("file", os.Getenv("GOFILE"), "The Go file declaring the annotated entities, default: $GOFILE")
+	out := flag.String("out", "", "The generated file, default: <file>_finders_gen.go")
+	tests := flag.Bool("tests", true, "Also generate <out>_test.go checking the generated filters")
+	flag.Parse()
+
+	if *file == "" {
+		fail(fmt.Errorf("no input file, set -file or run through go:generate"))
+	}
+	if *out == "" {
+		*out = strings.TrimSuffix(*file, ".go") + "_finders_gen.go"
+	}
+
+	source, err := parseFile(*file)
+	if err != nil {
+		fail(err)
+	}
+	if len(source.entities) == 0 {
+		fail(fmt.Errorf("%s: no //mongorepo:find definitions found", *file))
+	}
+
+	if err := write(*out, generateFinders(source)); err != nil {
+		fail(err)
+	}
+
+	if *tests {
+		if err := write(strings.TrimSuffix(*out, ".go")+"_test.go", generateTests(source)); err != nil {
+			fail(err)
+		}
+	}
+}
+
+// write formats the generated code and writes it to path.
+func write(path string, code []byte) error {
+	formatted, err := format.Source(code)
+	if err != nil {
+		return fmt.Errorf("formatting %s: %w", path, err)
+	}
+
+	return os.WriteFile(path, formatted, 0o644)
+}
+
+// fail reports an error and exits.
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "mongorepo-gen:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// directive is the comment prefix declaring a finder.
+const directive = "//mongorepo:find "
+
+// sourceFile holds the annotated entities of a Go file.
+type sourceFile struct {
+	pkg      string
+	imports  map[string]string // package name -> import path, of the packages used by the finder parameters
+	entities []*entity
+}
+
+// entity is an annotated struct and its finders.
+type entity struct {
+	name    string
+	fields  map[string]*field // by Go name
+	finders []*finder
+}
+
+// field is a struct field usable in a finder.
+type field struct {
+	name     string // the Go name, e.g. "CreatedAt"
+	key      string // the BSON key, e.g. "created_at"
+	typeExpr string // the Go type, e.g. "time.Time"
+	packages []string
+}
+
+// finder is a parsed finder definition.
+type finder struct {
+	name      string // the method name, e.g. "FindAllByStatus"
+	filter    string // the filter builder name, e.g. "userAllByStatusFilter"
+	many      bool
+	fields    []*field
+	orderBy   *field
+	orderDesc bool
+}
+
+// parseFile parses a Go file and collects the //mongorepo:find definitions of its structs.
+func parseFile(path string) (*sourceFile, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	imports := map[string]string{}
+	for _, spec := range file.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		name := importPath[strings.LastIndex(importPath, "/")+1:]
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = importPath
+	}
+
+	source := &sourceFile{pkg: file.Name.Name, imports: map[string]string{}}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range gen.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			structType, ok := typeSpec.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+
+			definitions := directives(gen.Doc, typeSpec.Doc)
+			if len(definitions) == 0 {
+				continue
+			}
+
+			e := &entity{name: typeSpec.Name.Name, fields: structFields(fset, structType)}
+			for _, definition := range definitions {
+				f, err := parseFinder(e, definition)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %s: %w", path, e.name, err)
+				}
+				e.finders = append(e.finders, f)
+
+				for _, used := range f.fields {
+					for _, pkg := range used.packages {
+						if importPath, ok := imports[pkg]; ok {
+							source.imports[pkg] = importPath
+						}
+					}
+				}
+			}
+			source.entities = append(source.entities, e)
+		}
+	}
+
+	return source, nil
+}
+
+// directives returns the finder definitions found in the doc comments of a type.
+func directives(groups ...*ast.CommentGroup) []string {
+	var definitions []string
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+		for _, comment := range group.List {
+			if strings.HasPrefix(comment.Text, directive) {
+				definitions = append(definitions, strings.TrimSpace(strings.TrimPrefix(comment.Text, directive)))
+			}
+		}
+	}
+
+	return definitions
+}
+
+// structFields collects the named, stored fields of a struct with their BSON key and type.
+func structFields(fset *token.FileSet, structType *ast.StructType) map[string]*field {
+	fields := map[string]*field{}
+	for _, f := range structType.Fields.List {
+		var tag reflect.StructTag
+		if f.Tag != nil {
+			unquoted, _ := strconv.Unquote(f.Tag.Value)
+			tag = reflect.StructTag(unquoted)
+		}
+
+		// same rules as the repository: the tag name, or the lowercased field name
+		key, _, _ := strings.Cut(tag.Get("bson"), ",")
+		if key == "-" {
+			continue
+		}
+
+		var typeExpr bytes.Buffer
+		printer.Fprint(&typeExpr, fset, f.Type)
+
+		for _, name := range f.Names {
+			if !name.IsExported() {
+				continue
+			}
+			fieldKey := key
+			if fieldKey == "" {
+				fieldKey = strings.ToLower(name.Name)
+			}
+			fields[name.Name] = &field{name: name.Name, key: fieldKey, typeExpr: typeExpr.String(), packages: typePackages(f.Type)}
+		}
+	}
+
+	return fields
+}
+
+// typePackages returns the package names referenced by a type expression.
+func typePackages(expr ast.Expr) []string {
+	var packages []string
+	ast.Inspect(expr, func(node ast.Node) bool {
+		if selector, ok := node.(*ast.SelectorExpr); ok {
+			if ident, ok := selector.X.(*ast.Ident); ok {
+				packages = append(packages, ident.Name)
+			}
+		}
+		return true
+	})
+
+	return packages
+}
+
+// parseFinder parses a definition such as "AllByStatusAndCountryOrderByCreatedAtDesc".
+func parseFinder(e *entity, definition string) (*finder, error) {
+	rest := strings.TrimPrefix(definition, "Find")
+	f := &finder{name: "Find" + rest, filter: lowerFirst(e.name) + rest + "Filter"}
+
+	if strings.HasPrefix(rest, "All") {
+		f.many = true
+		rest = strings.TrimPrefix(rest, "All")
+	}
+
+	if !strings.HasPrefix(rest, "By") {
+		return nil, fmt.Errorf("invalid definition %q, expected [All]By<Field>[And<Field>...][OrderBy<Field>[Desc]]", definition)
+	}
+	rest = strings.TrimPrefix(rest, "By")
+
+	if i := strings.Index(rest, "OrderBy"); i >= 0 {
+		if !f.many {
+			return nil, fmt.Errorf("invalid definition %q, OrderBy requires an All finder", definition)
+		}
+		order := rest[i+len("OrderBy"):]
+		rest = rest[:i]
+
+		if _, ok := e.fields[order]; !ok && strings.HasSuffix(order, "Desc") {
+			order, f.orderDesc = strings.TrimSuffix(order, "Desc"), true
+		}
+		if f.orderBy = e.fields[order]; f.orderBy == nil {
+			return nil, fmt.Errorf("invalid definition %q, unknown field %s", definition, order)
+		}
+	}
+
+	for rest != "" {
+		matched := matchField(e, rest)
+		if matched == nil {
+			return nil, fmt.Errorf("invalid definition %q, unknown field in %q", definition, rest)
+		}
+		f.fields = append(f.fields, matched)
+
+		rest = rest[len(matched.name):]
+		if rest != "" {
+			if !strings.HasPrefix(rest, "And") {
+				return nil, fmt.Errorf("invalid definition %q, expected And before %q", definition, rest)
+			}
+			rest = strings.TrimPrefix(rest, "And")
+		}
+	}
+
+	if len(f.fields) == 0 {
+		return nil, fmt.Errorf("invalid definition %q, no fields", definition)
+	}
+
+	return f, nil
+}
+
+// matchField returns the longest field name prefixing s that is followed by "And" or the end of s,
+// so field names containing "And" are still recognized.
+func matchField(e *entity, s string) *field {
+	names := make([]string, 0, len(e.fields))
+	for name := range e.fields {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+
+	for _, name := range names {
+		if s == name || strings.HasPrefix(s, name+"And") {
+			return e.fields[name]
+		}
+	}
+
+	return nil
+}
+
+// paramName returns the parameter name of a field, e.g. "CreatedAt" -> "createdAt", "ID" -> "id".
+func paramName(fieldName string) string {
+	name := lowerFirst(fieldName)
+	if token.IsKeyword(name) || name == "opts" || name == "f" {
+		name += "Value"
+	}
+
+	return name
+}
+
+// lowerFirst lowercases the leading capitals of an identifier, keeping the last one of an acronym
+// followed by a lowercase letter: "URLPath" -> "urlPath".
+func lowerFirst(s string) string {
+	runes := []rune(s)
+	for i := range runes {
+		if !unicode.IsUpper(runes[i]) {
+			break
+		}
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+
+	return string(runes)
+}
@@ -0,0 +1,167 @@
+package mongorepo
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// BatchLoaderConfig holds the configuration of a BatchLoader.
+type BatchLoaderConfig struct {
+	Window   time.Duration // How long the first FindById of a batch waits for the others, default: 2 milliseconds
+	MaxBatch int           // The number of distinct IDs sending a batch before the end of its window, default: 100
+}
+
+// BatchLoader coalesces the FindById calls arriving within a short window across goroutines into one $in query,
+// e.g. for GraphQL or BFF resolvers loading the same kind of entity concurrently. Each call still gets its own
+// entity or ErrNotFound, at the cost of up to Window of added latency. The batches run with the context of the
+// repository, as they serve several callers.
+type BatchLoader[T any] struct {
+	repo   *Repository[T]
+	config BatchLoaderConfig
+	mu     sync.Mutex
+	batch  *idBatch[T]
+}
+
+// idBatch is a batch of IDs waiting for their query.
+type idBatch[T any] struct {
+	ids     []any
+	waiters map[string]int // The number of calls waiting for each ID, keyed by batchKey.
+	timer   *time.Timer
+	done    chan struct{} // Closed once found and err are set.
+	mu      sync.Mutex
+	found   map[string][]*T // One entity per waiter of each ID found.
+	err     error
+}
+
+// NewBatchLoader creates a BatchLoader on top of a repository.
+//
+// Parameters:
+//   - repo: The repository the entities are loaded from.
+//   - config: The batching configuration.
+//
+// Returns:
+//   - A pointer to a BatchLoader.
+func NewBatchLoader[T any](repo *Repository[T], config BatchLoaderConfig) *BatchLoader[T] {
+	if config.Window <= 0 {
+		config.Window = 2 * time.Millisecond
+	}
+
+	if config.MaxBatch <= 0 {
+		config.MaxBatch = 100
+	}
+
+	return &BatchLoader[T]{repo: repo, config: config}
+}
+
+// FindById retrieves an entity by its ID like Repository.FindById, through the batch of the current window.
+// Concurrent calls for the same ID get distinct copies of the entity.
+//
+// Parameters:
+//   - id: The ID of the entity to retrieve.
+//
+// Returns:
+//   - A pointer to the entity of type `T`.
+//   - ErrNotFound if not found, or the error of the batch query.
+func (b *BatchLoader[T]) FindById(id any) (*T, error) {
+	key := batchKey(id)
+
+	b.mu.Lock()
+	batch := b.batch
+	if batch == nil {
+		batch = &idBatch[T]{waiters: map[string]int{}, done: make(chan struct{})}
+		batch.timer = time.AfterFunc(b.config.Window, func() { b.dispatch(batch) })
+		b.batch = batch
+	}
+
+	if batch.waiters[key] == 0 {
+		batch.ids = append(batch.ids, id)
+	}
+	batch.waiters[key]++
+
+	// a full batch stops accepting calls and is sent right away, unless its timer already fired
+	full := len(batch.ids) >= b.config.MaxBatch
+	if full {
+		b.batch = nil
+	}
+	b.mu.Unlock()
+
+	if full && batch.timer.Stop() {
+		go b.dispatch(batch)
+	}
+
+	<-batch.done
+	if batch.err != nil {
+		return nil, batch.err
+	}
+
+	return batch.take(key)
+}
+
+// dispatch detaches a batch from the loader and runs its query.
+func (b *BatchLoader[T]) dispatch(batch *idBatch[T]) {
+	b.mu.Lock()
+	if b.batch == batch {
+		b.batch = nil
+	}
+	b.mu.Unlock()
+
+	defer close(batch.done)
+
+	entities, err := b.repo.Unbounded().Find(bson.M{"_id": bson.M{"$in": batch.ids}})
+	if err != nil {
+		batch.err = err
+		return
+	}
+
+	batch.found = make(map[string][]*T, len(entities))
+	for _, entity := range entities {
+		key := batchKey(NewEntityReflection(b.repo.config, entity).GetID())
+		copies := []*T{entity}
+		for len(copies) < batch.waiters[key] {
+			clone, err := cloneEntity(entity)
+			if err != nil {
+				batch.err = err
+				return
+			}
+			copies = append(copies, clone)
+		}
+		batch.found[key] = copies
+	}
+}
+
+// take returns one of the entities found for an ID to one of its waiters.
+func (batch *idBatch[T]) take(key string) (*T, error) {
+	batch.mu.Lock()
+	defer batch.mu.Unlock()
+
+	copies := batch.found[key]
+	if len(copies) == 0 {
+		return nil, errNoDocuments
+	}
+
+	batch.found[key] = copies[1:]
+	return copies[0], nil
+}
+
+// cloneEntity returns a deep copy of an entity, through its BSON encoding.
+func cloneEntity[T any](entity *T) (*T, error) {
+	raw, err := bson.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	clone := new(T)
+	if err := bson.Unmarshal(raw, clone); err != nil {
+		return nil, err
+	}
+
+	return clone, nil
+}
+
+// batchKey identifies an ID in a batch, its type included since 1 and "1" are distinct IDs.
+func batchKey(id any) string {
+	return fmt.Sprintf("%T/%v", id, id)
+}
@@ -0,0 +1,178 @@
+package mongorepo
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CommentFunc builds the $comment of an operation from its context and the name of the repository method
+// running it (e.g. "Find"); an empty comment leaves the operation untagged.
+type CommentFunc func(ctx context.Context, operation string) string
+
+// commentTagsKey is the context key of the tags added to the $comment of the operations.
+type commentTagsKey struct{}
+
+// WithCommentTags returns a context adding tags (e.g. the endpoint and the trace id of a request) to the
+// $comment of the operations of the repositories using it, merged with the tags already in ctx.
+//
+// Parameters:
+//   - ctx: The parent context.
+//   - tags: The tags, e.g. map[string]string{"endpoint": "GET /orders", "trace_id": traceID}.
+//
+// Returns:
+//   - The derived context, to be passed to WithContext.
+func WithCommentTags(ctx context.Context, tags map[string]string) context.Context {
+	merged := map[string]string{}
+	for key, value := range CommentTagsFromContext(ctx) {
+		merged[key] = value
+	}
+	for key, value := range tags {
+		merged[key] = value
+	}
+
+	return context.WithValue(ctx, commentTagsKey{}, merged)
+}
+
+// CommentTagsFromContext returns the tags added by WithCommentTags, nil if there is none.
+//
+// Parameters:
+//   - ctx: The context.
+//
+// Returns:
+//   - The tags.
+func CommentTagsFromContext(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+
+	tags, _ := ctx.Value(commentTagsKey{}).(map[string]string)
+	return tags
+}
+
+// DefaultComment builds the $comment used when ServiceName is set and Comment is not: a JSON object with
+// the service, the operation, the collection and the tags of the context, shown as is in the mongod logs,
+// the profiler and currentOp, e.g. {"collection":"orders","endpoint":"GET /orders","op":"Find","service":"billing"}.
+//
+// Parameters:
+//   - ctx: The context of the operation.
+//   - service: The service name.
+//   - collection: The collection name.
+//   - operation: The repository method running the operation, e.g. "Find".
+//
+// Returns:
+//   - The comment.
+func DefaultComment(ctx context.Context, service, collection, operation string) string {
+	fields := map[string]string{}
+	for key, value := range CommentTagsFromContext(ctx) {
+		fields[key] = value
+	}
+	fields["service"] = service
+	fields["collection"] = collection
+	fields["op"] = operation
+
+	comment, _ := json.Marshal(fields)
+	return string(comment)
+}
+
+// comment returns the $comment of an operation, empty when tagging is disabled.
+func (r *Repository[T]) comment(operation string) string {
+	ctx := r.config.Context
+	if r.config.Comment != nil {
+		return r.config.Comment(ctx, operation)
+	}
+
+	if r.config.ServiceName == "" {
+		return ""
+	}
+
+	return DefaultComment(ctx, r.config.ServiceName, r.config.CollectionName, operation)
+}
+
+// The helpers below return the options setting the $comment of an operation, nil when tagging is disabled,
+// to be placed before the options of the caller so an explicit comment wins.
+
+func (r *Repository[T]) findComment(operation string) *options.FindOptions {
+	if comment := r.comment(operation); comment != "" {
+		return options.Find().SetComment(comment)
+	}
+	return nil
+}
+
+func (r *Repository[T]) findOneComment(operation string) *options.FindOneOptions {
+	if comment := r.comment(operation); comment != "" {
+		return options.FindOne().SetComment(comment)
+	}
+	return nil
+}
+
+func (r *Repository[T]) aggregateComment(operation string) *options.AggregateOptions {
+	if comment := r.comment(operation); comment != "" {
+		return options.Aggregate().SetComment(comment)
+	}
+	return nil
+}
+
+func (r *Repository[T]) countComment(operation string) *options.CountOptions {
+	if comment := r.comment(operation); comment != "" {
+		return options.Count().SetComment(comment)
+	}
+	return nil
+}
+
+func (r *Repository[T]) distinctComment(operation string) *options.DistinctOptions {
+	if comment := r.comment(operation); comment != "" {
+		return options.Distinct().SetComment(comment)
+	}
+	return nil
+}
+
+func (r *Repository[T]) insertOneComment(operation string) *options.InsertOneOptions {
+	if comment := r.comment(operation); comment != "" {
+		return options.InsertOne().SetComment(comment)
+	}
+	return nil
+}
+
+func (r *Repository[T]) insertManyComment(operation string) *options.InsertManyOptions {
+	if comment := r.comment(operation); comment != "" {
+		return options.InsertMany().SetComment(comment)
+	}
+	return nil
+}
+
+func (r *Repository[T]) updateComment(operation string) *options.UpdateOptions {
+	if comment := r.comment(operation); comment != "" {
+		return options.Update().SetComment(comment)
+	}
+	return nil
+}
+
+func (r *Repository[T]) deleteComment(operation string) *options.DeleteOptions {
+	if comment := r.comment(operation); comment != "" {
+		return options.Delete().SetComment(comment)
+	}
+	return nil
+}
+
+func (r *Repository[T]) findOneAndUpdateComment(operation string) *options.FindOneAndUpdateOptions {
+	if comment := r.comment(operation); comment != "" {
+		return options.FindOneAndUpdate().SetComment(comment)
+	}
+	return nil
+}
+
+func (r *Repository[T]) findOneAndDeleteComment(operation string) *options.FindOneAndDeleteOptions {
+	if comment := r.comment(operation); comment != "" {
+		return options.FindOneAndDelete().SetComment(comment)
+	}
+	return nil
+}
+
+func (r *Repository[T]) bulkWriteComment(operation string) *options.BulkWriteOptions {
+	if comment := r.comment(operation); comment != "" {
+		return options.BulkWrite().SetComment(comment)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package mongorepo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// Compression identifies the algorithm used to compress CompressedString and CompressedBytes fields.
+type Compression byte
+
+const (
+	CompressionGzip Compression = 1 // gzip (compress/gzip), widely portable.
+	CompressionZstd Compression = 2 // zstd, faster and denser than gzip.
+)
+
+// compressedSubtype is the BSON binary subtype (user defined range) marking compressed values.
+const compressedSubtype byte = 0x80
+
+// DefaultCompression is the algorithm used when writing compressed fields. Reads detect the algorithm
+// of each stored value, so it can be changed at any time.
+var DefaultCompression = CompressionZstd
+
+// CompressedString is a string field stored compressed, e.g. a large JSON payload. Declare the field with
+// this type to get transparent compression on write and decompression on read:
+//
+//	Payload mongorepo.CompressedString `bson:"payload"`
+//
+// Plain strings already stored in the field are still decoded, so existing collections can be migrated
+// progressively. Compressed fields cannot be queried or indexed.
+type CompressedString string
+
+// CompressedBytes is a binary field stored compressed. Plain binary values already stored are still decoded.
+type CompressedBytes []byte
+
+// MarshalBSONValue compresses the string.
+func (s CompressedString) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return marshalCompressed([]byte(s))
+}
+
+// UnmarshalBSONValue decompresses the stored value.
+func (s *CompressedString) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	if t == bson.TypeString {
+		value, _, ok := bsoncore.ReadString(data)
+		if !ok {
+			return fmt.Errorf("mongorepo: invalid string value")
+		}
+		*s = CompressedString(value)
+		return nil
+	}
+
+	plain, err := unmarshalCompressed(t, data)
+	if err != nil {
+		return err
+	}
+
+	*s = CompressedString(plain)
+	return nil
+}
+
+// MarshalBSONValue compresses the bytes.
+func (b CompressedBytes) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	if b == nil {
+		return bson.TypeNull, nil, nil
+	}
+
+	return marshalCompressed(b)
+}
+
+// UnmarshalBSONValue decompresses the stored value.
+func (b *CompressedBytes) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	plain, err := unmarshalCompressed(t, data)
+	if err != nil {
+		return err
+	}
+
+	*b = plain
+	return nil
+}
+
+// marshalCompressed encodes data as a binary value holding the algorithm byte followed by the compressed data.
+func marshalCompressed(data []byte) (bsontype.Type, []byte, error) {
+	compressed, err := compress(DefaultCompression, data)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	payload := append([]byte{byte(DefaultCompression)}, compressed...)
+	return bson.TypeBinary, bsoncore.AppendBinary(nil, compressedSubtype, payload), nil
+}
+
+// unmarshalCompressed decodes a value written by marshalCompressed. Binary values of other subtypes are returned as is.
+func unmarshalCompressed(t bsontype.Type, data []byte) ([]byte, error) {
+	switch t {
+	case bson.TypeNull:
+		return nil, nil
+	case bson.TypeBinary:
+	default:
+		return nil, fmt.Errorf("mongorepo: cannot decode a compressed field from BSON type %s", t)
+	}
+
+	subtype, payload, _, ok := bsoncore.ReadBinary(data)
+	if !ok {
+		return nil, fmt.Errorf("mongorepo: invalid binary value")
+	}
+
+	if subtype != compressedSubtype {
+		return append([]byte(nil), payload...), nil
+	}
+
+	if len(payload) == 0 {
+		return nil, fmt.Errorf("mongorepo: empty compressed value")
+	}
+
+	return decompress(Compression(payload[0]), payload[1:])
+}
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdErr     error
+)
+
+// zstdCodec lazily creates the shared zstd encoder and decoder, which are safe for concurrent use.
+func zstdCodec() (*zstd.Encoder, *zstd.Decoder, error) {
+	zstdOnce.Do(func() {
+		if zstdEncoder, zstdErr = zstd.NewWriter(nil); zstdErr != nil {
+			return
+		}
+		zstdDecoder, zstdErr = zstd.NewReader(nil)
+	})
+
+	return zstdEncoder, zstdDecoder, zstdErr
+}
+
+// compress compresses data with the given algorithm.
+func compress(algorithm Compression, data []byte) ([]byte, error) {
+	switch algorithm {
+	case CompressionZstd:
+		encoder, _, err := zstdCodec()
+		if err != nil {
+			return nil, err
+		}
+		return encoder.EncodeAll(data, nil), nil
+	case CompressionGzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("mongorepo: unknown compression algorithm %d", algorithm)
+	}
+}
+
+// decompress decompresses data compressed with the given algorithm.
+func decompress(algorithm Compression, data []byte) ([]byte, error) {
+	switch algorithm {
+	case CompressionZstd:
+		_, decoder, err := zstdCodec()
+		if err != nil {
+			return nil, err
+		}
+		return decoder.DecodeAll(data, nil)
+	case CompressionGzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	default:
+		return nil, fmt.Errorf("mongorepo: unknown compression algorithm %d", algorithm)
+	}
+}
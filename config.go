@@ -2,21 +2,71 @@ package mongorepo
 
 import (
 	"context"
+	"log/slog"
+	"time"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 )
 
 // Config holds the configuration necessary for connecting and interacting with a MongoDB collection.
 type Config struct {
-	MongoClient       *mongo.Client              // The MongoDB client instance used for database connections.
-	DatabaseOptions   *options.DatabaseOptions   // The MongoDb Database options, default: nil
-	CollectionOptions *options.CollectionOptions // The MongoDb Collection options, default: nil
-	DbName            string                     // The name of the database where the collection resides.
-	CollectionName    string                     // The name of the collection representing the entity.
-	Context           context.Context            // The context to manage request lifecycle (e.g., timeouts, cancellations) during MongoDB operations.
-	IdField           string                     // The field in the entity struct that represents the "_id" field in MongoDB, which must be a primitive.ObjectID.
-	DeletedAtField    string                     // The field in the entity struct to track soft deletes, indicating when a document is marked as deleted.
-	CreatedAtField    string                     // The field in the entity struct to store the timestamp of when the document was created; must be of type time.Time.
-	UpdatedAtField    string                     // The field in the entity struct to store the timestamp of when the document was last updated; must be of type time.Time.
+	MongoClient            *mongo.Client               // The MongoDB client instance used for database connections.
+	DatabaseOptions        *options.DatabaseOptions    // The MongoDb Database options, default: nil
+	CollectionOptions      *options.CollectionOptions  // The MongoDb Collection options, default: nil
+	ReadPreference         *readpref.ReadPref          // The members the reads target, overriding CollectionOptions, see WithReadPreference, default: the client setting
+	ReadConcern            *readconcern.ReadConcern    // The read concern of the reads, overriding CollectionOptions, see WithReadConcern, default: the client setting
+	WriteConcern           *writeconcern.WriteConcern  // The write concern of the writes, overriding CollectionOptions, see WithWriteConcern, default: the client setting
+	DbName                 string                      // The name of the database where the collection resides.
+	CollectionName         string                      // The name of the collection representing the entity.
+	Context                context.Context             // The context to manage request lifecycle (e.g., timeouts, cancellations) during MongoDB operations.
+	TenantResolver         TenantResolver              // Routes the operations to the database and collection suffix of the tenant of the context, e.g. TenantDatabases("shop_"), default: nil
+	IdField                string                      // The field in the entity struct that represents the "_id" field in MongoDB: a primitive.ObjectID, or any type set by the caller or an IDGenerator.
+	DeletedAtField         string                      // The field in the entity struct to track soft deletes, indicating when a document is marked as deleted.
+	CreatedAtField         string                      // The field in the entity struct to store the timestamp of when the document was created; must be of type time.Time.
+	UpdatedAtField         string                      // The field in the entity struct to store the timestamp of when the document was last updated; must be of type time.Time.
+	ShardKeyFields         []string                    // The fields in the entity struct of the shard key, added to the filters of Update, Delete, ForceDelete and Restore, default: nil
+	DisableSoftDeleteScope bool                        // Opt-out of the automatic exclusion of soft-deleted documents when DeletedAtField is set, default: false
+	CountersCollection     string                      // The collection storing the NextSequence counters, default: counters
+	DedupeFields           []string                    // The fields in the entity struct whose values are hashed into the dedupe key, default: nil
+	DedupeKeyField         string                      // The field in the entity struct storing the dedupe key (must be a string with a unique index), default: disabled
+	DedupeReturnExisting   bool                        // On duplicate content, Create loads the existing document into the entity instead of failing, default: false
+	ModifyMaxAttempts      int                         // The number of attempts of Modify before giving up on concurrent writes, default: 3
+	CheckpointsCollection  string                      // The collection storing the progress of resumable imports and exports, default: checkpoints
+	OversizeStrategy       OversizeStrategy            // What writes do with documents larger than MaxDocumentSize, default: OversizeIgnore
+	MaxDocumentSize        int                         // The encoded size in bytes above which OversizeStrategy applies, default: 16MB
+	OffloadFields          []string                    // The fields in the entity struct moved to GridFS, in order, by OversizeOffload, default: nil
+	GridFSBucket           string                      // The GridFS bucket storing the offloaded fields, default: fs
+	ReadYourWrites         bool                        // Create waits for majority acknowledgment and reads the document back with majority read concern, default: false
+	LockTTL                time.Duration               // How long WithDocumentLock holds the lock of a document, default: 30 seconds
+	InsertBatchSize        int                         // The number of entities inserted per InsertMany by CreateMany, default: 1000
+	VersionField           string                      // The field in the entity struct holding the version used for optimistic locking by Update (an integer), default: disabled
+	MaxFindLimit           int64                       // The limit applied to the Find queries without limit, lifted by Unbounded(), default: 0 (no limit)
+	StrictDecode           bool                        // Whether the finders fail with an UnknownFieldsError on stored fields the entity struct does not declare, default: false
+	ServiceName            string                      // The service name tagged, with the operation and the WithCommentTags tags, in the $comment of the operations, default: no comment
+	Comment                CommentFunc                 // Builds the $comment of the operations instead of DefaultComment, default: nil
+	BackupCollection       string                      // The collection receiving a copy of the documents affected by UpdateMany, DeleteMany and Drop before they run, default: disabled
+	BackupRetention        time.Duration               // How long the backups are kept before expiring, default: 7 days
+	CopyFieldsOnWrite      []CopyRule                  // The fields of referenced documents copied into the entity by Create, CreateMany, Update and Upsert, default: nil
+	ExpireAtField          string                      // The field in the entity struct (a time.Time) of the TTL index created by EnsureIndexes, default: disabled
+	ExpireAfter            time.Duration               // How long after the ExpireAtField time documents are removed, default: 0 (the field holds the expiration time)
+	CursorSecret           []byte                      // The HMAC-SHA256 key signing the cursors of FindCursorPage, default: nil (unsigned cursors)
+	OnDeprecatedWrite      DeprecationFunc             // Called when an entity is written with a `deprecated` field populated, default: a warning to the Logger
+	IDGenerator            func() any                  // Generates the IDs assigned by Create (ULIDs, UUIDv7s, prefixed strings...) of the IdField type, default: primitive.NewObjectID
+	AggregationGuard       *AggregationGuard           // The cost guardrails Aggregate checks the pipelines against, lifted by Unguarded(), default: nil (disabled)
+	IDSequence             string                      // The NextSequence counter whose values Create assigns to the IdField (an integer), e.g. "orders", default: disabled
+	DeadLetterCollection   string                      // The collection storing the change events a ChangeConsumer handler kept failing on, default: dead_letters
+	MetricName             MetricNameFunc              // Names the metrics and trace spans of the operations, e.g. "billing.order.find_by_id", default: DefaultMetricName
+	CollectionLabel        LabelFunc                   // Maps the collection name reported in the metric labels (HashLabel, PrefixLabel, AllowLabel), default: unchanged
+	Metrics                Metrics                     // Receives the duration, documents returned and error of every operation, e.g. NewPrometheusMetrics(), default: nil
+	Logger                 *slog.Logger                // Receives the warnings and errors of the repository, and its operations at the debug level, default: slog.Default()
+	SlowOperationThreshold time.Duration               // The duration from which an operation is logged as a warning with its redacted filter, default: 0 (disabled)
+	OnSlowOperation        func(SlowOperation)         // Called with every operation reaching SlowOperationThreshold, e.g. to sample or alert, default: nil
+	QueryShapes            *QueryShapeRecorder         // Counts the queries and their latency percentiles per query shape, see NewQueryShapeRecorder, default: nil
+	WriteAmplification     *WriteAmplificationReporter // Compares a sample of the full-entity updates with their diffs, see NewWriteAmplificationReporter, default: nil
+	WriteTargets           []WriteTarget               // The secondary collections receiving a copy of the entities written, in a transaction or via the outbox, default: nil
+	OutboxCollection       string                      // The collection storing the pending copies of the FanOutOutbox WriteTargets, default: outbox
 }
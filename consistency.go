@@ -0,0 +1,202 @@
+package mongorepo
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// WithReadPreference returns a view of the repository whose reads target the given members, e.g. the secondaries
+// for a report tolerating stale data:
+//
+//	orders, err := repo.WithReadPreference(readpref.SecondaryPreferred()).Find(filter)
+//
+// The original repository is not modified.
+//
+// Parameters:
+//   - preference: The read preference of the view.
+//
+// Returns:
+//   - A pointer to a Repository sharing the configuration of `r` except for the read preference.
+func (r *Repository[T]) WithReadPreference(preference *readpref.ReadPref) *Repository[T] {
+	view := r.view()
+	view.config.ReadPreference = preference
+	return view
+}
+
+// WithReadConcern returns a view of the repository whose reads use the given read concern, e.g. majority to only
+// read the data that cannot be rolled back. The original repository is not modified.
+//
+// Parameters:
+//   - concern: The read concern of the view.
+//
+// Returns:
+//   - A pointer to a Repository sharing the configuration of `r` except for the read concern.
+func (r *Repository[T]) WithReadConcern(concern *readconcern.ReadConcern) *Repository[T] {
+	view := r.view()
+	view.config.ReadConcern = concern
+	return view
+}
+
+// WithWriteConcern returns a view of the repository whose writes use the given write concern, e.g. majority for a
+// critical write:
+//
+//	err := repo.WithWriteConcern(writeconcern.Majority()).Create(&payment)
+//
+// The original repository is not modified. Inside a transaction, the write concern of the transaction applies.
+//
+// Parameters:
+//   - concern: The write concern of the view.
+//
+// Returns:
+//   - A pointer to a Repository sharing the configuration of `r` except for the write concern.
+func (r *Repository[T]) WithWriteConcern(concern *writeconcern.WriteConcern) *Repository[T] {
+	view := r.view()
+	view.config.WriteConcern = concern
+	return view
+}
+
+// concernOptions returns the collection options setting the ReadPreference, ReadConcern and WriteConcern of
+// the repository, nil when none is set.
+func (r *Repository[T]) concernOptions() *options.CollectionOptions {
+	if r.config.ReadPreference == nil && r.config.ReadConcern == nil && r.config.WriteConcern == nil {
+		return nil
+	}
+
+	opts := options.Collection()
+	if r.config.ReadPreference != nil {
+		opts.SetReadPreference(r.config.ReadPreference)
+	}
+	if r.config.ReadConcern != nil {
+		opts.SetReadConcern(r.config.ReadConcern)
+	}
+	if r.config.WriteConcern != nil {
+		opts.SetWriteConcern(r.config.WriteConcern)
+	}
+
+	return opts
+}
+
+// majorityCollection returns the repository collection with majority write and read concerns
+// and primary reads, used by the read-your-writes paths.
+func (r *Repository[T]) majorityCollection() *mongo.Collection {
+	majority := options.Collection().
+		SetWriteConcern(writeconcern.Majority()).
+		SetReadConcern(readconcern.Majority()).
+		SetReadPreference(readpref.Primary())
+
+	return r.Database().Collection(r.config.CollectionName, r.config.CollectionOptions, r.concernOptions(), majority)
+}
+
+// readBack waits until a written document is majority committed, by reading it back with majority
+// read concern, so reads on any up-to-date node will see it.
+func (r *Repository[T]) readBack(id any) error {
+	return r.majorityCollection().FindOne(r.config.Context, bson.M{"_id": id},
+		options.FindOne().SetProjection(bson.M{"_id": 1})).Err()
+}
+
+// ConsistencyTokenHeader is the HTTP header conventionally carrying a ConsistencyToken between an API and its clients.
+const ConsistencyTokenHeader = "X-Consistency-Token"
+
+// ErrInvalidConsistencyToken is returned for a ConsistencyToken that cannot be decoded.
+var ErrInvalidConsistencyToken = errors.New("mongorepo: invalid consistency token")
+
+// ConsistencyToken is the causal-consistency position of a session after its writes (its cluster and operation
+// times), encoded as an opaque URL-safe string. Handed to an API client after a write and sent back with its next
+// request, it lets any instance of a stateless API tier read its own writes, see BeginSessionAfter.
+type ConsistencyToken string
+
+// consistencyPosition is the decoded content of a ConsistencyToken.
+type consistencyPosition struct {
+	ClusterTime   bson.Raw            `bson:"ct"`
+	OperationTime primitive.Timestamp `bson:"ot"`
+}
+
+// ConsistencyTokenOf returns the ConsistencyToken of the session stashed in ctx by BeginSession or
+// BeginSessionAfter, once its writes ran:
+//
+//	sessCtx, err := mongorepo.BeginSession(ctx, client)
+//	defer mongorepo.EndSession(sessCtx)
+//	err = orders.WithContext(sessCtx).WithWriteConcern(writeconcern.Majority()).Create(order)
+//	token, err := mongorepo.ConsistencyTokenOf(sessCtx)
+//	w.Header().Set(mongorepo.ConsistencyTokenHeader, string(token))
+//
+// Parameters:
+//   - ctx: The context carrying the session.
+//
+// Returns:
+//   - The token.
+//   - An error if ctx carries no session, or the session has not run an operation yet.
+func ConsistencyTokenOf(ctx context.Context) (ConsistencyToken, error) {
+	session := mongo.SessionFromContext(ctx)
+	if session == nil {
+		return "", errors.New("mongorepo: the context carries no session")
+	}
+
+	if session.ClusterTime() == nil || session.OperationTime() == nil {
+		return "", errors.New("mongorepo: the session has not run an operation yet")
+	}
+
+	raw, err := bson.Marshal(consistencyPosition{ClusterTime: session.ClusterTime(), OperationTime: *session.OperationTime()})
+	if err != nil {
+		return "", err
+	}
+
+	return ConsistencyToken(base64.RawURLEncoding.EncodeToString(raw)), nil
+}
+
+// BeginSessionAfter starts a causally consistent session on client, advanced to a ConsistencyToken, and stashes
+// it in the returned context like BeginSession: the reads run with WithContext(sessCtx) see the writes the token was
+// taken after, on whichever node serves them. The guarantee requires the writes to use a majority write concern
+// and the reads a majority read concern. End the session with EndSession when done:
+//
+//	sessCtx, err := mongorepo.BeginSessionAfter(ctx, client, mongorepo.ConsistencyToken(r.Header.Get(mongorepo.ConsistencyTokenHeader)))
+//	if err != nil {
+//		return err
+//	}
+//	defer mongorepo.EndSession(sessCtx)
+//	order, err := orders.WithContext(sessCtx).WithReadConcern(readconcern.Majority()).FindById(id)
+//
+// Parameters:
+//   - ctx: The parent context.
+//   - client: The client starting the session.
+//   - token: The token of the writes to observe, empty to start a causally consistent session from scratch.
+//
+// Returns:
+//   - The context carrying the session.
+//   - ErrInvalidConsistencyToken if the token cannot be decoded, or an error if the session cannot be started.
+func BeginSessionAfter(ctx context.Context, client *mongo.Client, token ConsistencyToken) (context.Context, error) {
+	var position consistencyPosition
+	if token != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(string(token))
+		if err != nil || bson.Unmarshal(raw, &position) != nil || position.ClusterTime == nil {
+			return nil, ErrInvalidConsistencyToken
+		}
+	}
+
+	session, err := client.StartSession(options.Session().SetCausalConsistency(true))
+	if err != nil {
+		return nil, err
+	}
+
+	if token != "" {
+		if err := session.AdvanceClusterTime(position.ClusterTime); err != nil {
+			session.EndSession(ctx)
+			return nil, err
+		}
+		if err := session.AdvanceOperationTime(&position.OperationTime); err != nil {
+			session.EndSession(ctx)
+			return nil, err
+		}
+	}
+
+	return mongo.NewSessionContext(ctx, session), nil
+}
@@ -0,0 +1,183 @@
+package mongorepo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrReferenceNotFound is returned by the writes when a CopyRule reference has no source document.
+var ErrReferenceNotFound = errors.New("mongorepo: referenced document not found")
+
+// CopyRule denormalizes fields of a referenced document into the entity at write time (the extended reference
+// pattern), e.g. the name of the customer embedded into its orders, so reads need no join. Create, CreateMany,
+// Update and Upsert refresh the copy from the source, and ResyncCopies propagates later changes of a source.
+type CopyRule struct {
+	RefField         string   // The field in the entity struct holding the reference, e.g. "CustomerID".
+	SourceCollection string   // The referenced collection of the same database, e.g. "customers".
+	SourceField      string   // The referenced key in the source collection, default: "_id"
+	Fields           []string // The top-level BSON keys copied from the source document, e.g. []string{"name", "tier"}.
+	Into             string   // The field in the entity struct receiving the copy, a struct or map decoding the copied keys.
+}
+
+// copyFieldsOnWrite refreshes the copies of the CopyFieldsOnWrite rules in the entities, with one query
+// per rule. A zero reference clears the copy.
+func (r *Repository[T]) copyFieldsOnWrite(entities ...*T) error {
+	for _, rule := range r.config.CopyFieldsOnWrite {
+		if err := r.copyFields(rule, entities); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyFields applies a single rule to the entities.
+func (r *Repository[T]) copyFields(rule CopyRule, entities []*T) error {
+	sourceField := rule.SourceField
+	if sourceField == "" {
+		sourceField = "_id"
+	}
+
+	refs := make([]bson.RawValue, len(entities))
+	values := bson.A{}
+	for i, entity := range entities {
+		ref := NewEntityReflection(r.config, entity).field(rule.RefField)
+		if ref.IsZero() {
+			continue
+		}
+
+		t, data, err := bson.MarshalValue(ref.Interface())
+		if err != nil {
+			return err
+		}
+		refs[i] = bson.RawValue{Type: t, Value: data}
+		values = append(values, refs[i])
+	}
+
+	sources := map[string]bson.Raw{}
+	if len(values) > 0 {
+		projection := bson.M{sourceField: 1}
+		for _, field := range rule.Fields {
+			projection[field] = 1
+		}
+
+		ctx := r.config.Context
+		collection := r.Database().Collection(rule.SourceCollection)
+		cursor, err := collection.Find(ctx, bson.M{sourceField: bson.M{"$in": values}}, options.Find().SetProjection(projection))
+		if err != nil {
+			return err
+		}
+
+		for cursor.Next(ctx) {
+			source := append(bson.Raw(nil), cursor.Current...)
+			sources[rawValueKey(source.Lookup(strings.Split(sourceField, ".")...))] = source
+		}
+		err = cursor.Err()
+		cursor.Close(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, entity := range entities {
+		into := NewEntityReflection(r.config, entity).field(rule.Into)
+		if refs[i].Type == 0 {
+			into.Set(reflect.Zero(into.Type()))
+			continue
+		}
+
+		source, ok := sources[rawValueKey(refs[i])]
+		if !ok {
+			return fmt.Errorf("%w: %s.%s = %v", ErrReferenceNotFound, rule.SourceCollection, sourceField, decodeRawValue(refs[i]))
+		}
+
+		if err := decodeCopy(source, rule.Fields, into); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeCopy decodes the copied keys of a source document into the field receiving them.
+func decodeCopy(source bson.Raw, fields []string, into reflect.Value) error {
+	copied := bson.D{}
+	for _, field := range fields {
+		if value := source.Lookup(field); value.Type != 0 && value.Type != bsontype.Undefined {
+			copied = append(copied, bson.E{Key: field, Value: value})
+		}
+	}
+
+	raw, err := bson.Marshal(copied)
+	if err != nil {
+		return err
+	}
+
+	decoded := reflect.New(into.Type())
+	if err := bson.Unmarshal(raw, decoded.Interface()); err != nil {
+		return err
+	}
+
+	into.Set(decoded.Elem())
+	return nil
+}
+
+// ResyncCopies propagates the current fields of a source document to the copies held by the documents
+// referencing it, through every CopyFieldsOnWrite rule of the source collection, e.g. from an update hook of
+// the source repository or from a change stream on the source collection. Soft-deleted documents are updated too.
+//
+// Parameters:
+//   - sourceCollection: The collection of the changed document, e.g. "customers".
+//   - sourceID: The value of the SourceField of the changed document.
+//
+// Returns:
+//   - The number of documents whose copy changed.
+//   - ErrReferenceNotFound if the source document does not exist, or an error if a query or an update fails.
+func (r *Repository[T]) ResyncCopies(sourceCollection string, sourceID any) (int64, error) {
+	ctx := r.config.Context
+	entityType := reflect.TypeOf((*T)(nil)).Elem()
+	var modified int64
+
+	for _, rule := range r.config.CopyFieldsOnWrite {
+		if rule.SourceCollection != sourceCollection {
+			continue
+		}
+
+		sourceField := rule.SourceField
+		if sourceField == "" {
+			sourceField = "_id"
+		}
+
+		source, err := r.Database().Collection(sourceCollection).FindOne(ctx, bson.M{sourceField: sourceID}).Raw()
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return modified, fmt.Errorf("%w: %s.%s = %v", ErrReferenceNotFound, sourceCollection, sourceField, sourceID)
+		}
+		if err != nil {
+			return modified, err
+		}
+
+		intoKey := r.fieldKey(rule.Into)
+		field, _ := entityType.FieldByName(rule.Into)
+		into := reflect.New(field.Type).Elem()
+		if err := decodeCopy(source, rule.Fields, into); err != nil {
+			return modified, err
+		}
+
+		filter := bson.M{r.fieldKey(rule.RefField): sourceID}
+		update := r.stampUpdate(bson.M{"$set": bson.M{intoKey: into.Interface()}})
+		result, err := r.Collection().UpdateMany(ctx, filter, update, r.updateComment("ResyncCopies"))
+		if err != nil {
+			return modified, err
+		}
+		modified += result.ModifiedCount
+	}
+
+	return modified, nil
+}
@@ -0,0 +1,191 @@
+package mongorepo
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrInvalidCursor is returned by FindCursorPage for a cursor that is malformed, tampered with, or was issued
+// for another query or sort.
+var ErrInvalidCursor = errors.New("mongorepo: invalid pagination cursor")
+
+// CursorPage holds one page of a keyset pagination.
+type CursorPage[T any] struct {
+	Items      []*T   // The entities of the page.
+	NextCursor string // The cursor of the next page, empty on the last page.
+}
+
+// keysetCursor is the payload of a pagination cursor.
+type keysetCursor struct {
+	Values []bson.RawValue `bson:"v"` // The sort values of the last entity of the page.
+	Query  string          `bson:"q"` // The canonical hash of the collection, the query and the sort the cursor belongs to.
+}
+
+// FindCursorPage returns one page of a keyset (cursor) pagination: instead of skipping documents, each page
+// starts after the sort values of the last entity of the previous one, so deep pages stay cheap and pages do not
+// shift when documents are inserted. The sort gets an _id tiebreaker (see StableSort). The cursors are opaque,
+// stateless (a retried request gets the same page) and bound to the query and the sort; with a CursorSecret they
+// are signed with HMAC-SHA256, so clients of a public API cannot forge them to reach documents the query excludes.
+//
+// Parameters:
+//   - query: A BSON map defining the search criteria.
+//   - sort: The sort specification, the sorted fields must be present in every document, may be nil (_id order).
+//   - perPage: The maximum number of entities per page, default: 20
+//   - cursor: The NextCursor of the previous page, empty for the first page.
+//
+// Returns:
+//   - The page, with the cursor of the next page.
+//   - ErrInvalidCursor if the cursor cannot be trusted, or an error if the query fails.
+//
+// Panics:
+//   - If a sort direction is not 1 or -1.
+func (r *Repository[T]) FindCursorPage(query bson.M, sort any, perPage int, cursor string) (*CursorPage[T], error) {
+	_, perPage = normalizePage(1, perPage)
+	stable, err := StableSort(sort)
+	if err != nil {
+		return nil, err
+	}
+	hash := canonicalHash(r.config.CollectionName, query, stable)
+
+	filter := query
+	if cursor != "" {
+		position, err := r.decodeCursor(cursor, hash)
+		if err != nil {
+			return nil, err
+		}
+		if len(position.Values) != len(stable) {
+			return nil, ErrInvalidCursor
+		}
+		filter = bson.M{"$and": bson.A{query, keysetFilter(stable, position.Values)}}
+	}
+
+	opts := options.Find().SetSort(stable).SetLimit(int64(perPage) + 1)
+	items, err := r.Find(filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &CursorPage[T]{Items: items}
+	if len(items) <= perPage {
+		return page, nil
+	}
+
+	page.Items = items[:perPage]
+	page.NextCursor, err = r.encodeCursor(page.Items[perPage-1], stable, hash)
+	return page, err
+}
+
+// keysetFilter matches the documents sorted after the given sort values:
+// (k1 > v1) or (k1 = v1 and k2 > v2) or ..., with < for the descending keys.
+func keysetFilter(sort bson.D, values []bson.RawValue) bson.M {
+	branches := bson.A{}
+	for i, key := range sort {
+		branch := bson.M{}
+		for j := 0; j < i; j++ {
+			branch[sort[j].Key] = values[j]
+		}
+
+		operator := "$gt"
+		if sortDirection(key) < 0 {
+			operator = "$lt"
+		}
+		branch[key.Key] = bson.M{operator: values[i]}
+
+		branches = append(branches, branch)
+	}
+
+	return bson.M{"$or": branches}
+}
+
+// sortDirection returns the direction (1 or -1) of a sort key.
+func sortDirection(key bson.E) int {
+	switch direction := key.Value.(type) {
+	case int:
+		if direction == 1 || direction == -1 {
+			return direction
+		}
+	case int32:
+		if direction == 1 || direction == -1 {
+			return int(direction)
+		}
+	case int64:
+		if direction == 1 || direction == -1 {
+			return int(direction)
+		}
+	case float64:
+		if direction == 1 || direction == -1 {
+			return int(direction)
+		}
+	}
+
+	panic(fmt.Sprintf("Configuration error: The sort direction of %q must be 1 or -1 for cursor pagination.", key.Key))
+}
+
+// encodeCursor builds the cursor positioned after an entity.
+func (r *Repository[T]) encodeCursor(last *T, sort bson.D, hash string) (string, error) {
+	raw, err := bson.Marshal(last)
+	if err != nil {
+		return "", err
+	}
+
+	position := keysetCursor{Query: hash}
+	for _, key := range sort {
+		value := bson.Raw(raw).Lookup(strings.Split(key.Key, ".")...)
+		if value.Type == 0 {
+			value = bson.RawValue{Type: bsontype.Null}
+		}
+		position.Values = append(position.Values, value)
+	}
+
+	payload, err := bson.Marshal(position)
+	if err != nil {
+		return "", err
+	}
+
+	if r.config.CursorSecret != nil {
+		payload = append(payload, r.cursorMAC(payload)...)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(payload), nil
+}
+
+// decodeCursor verifies and decodes a cursor issued for the query with the given hash.
+func (r *Repository[T]) decodeCursor(cursor string, hash string) (*keysetCursor, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if r.config.CursorSecret != nil {
+		if len(payload) < sha256.Size {
+			return nil, ErrInvalidCursor
+		}
+		mac := payload[len(payload)-sha256.Size:]
+		payload = payload[:len(payload)-sha256.Size]
+		if !hmac.Equal(mac, r.cursorMAC(payload)) {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	position := &keysetCursor{}
+	if err := bson.Unmarshal(payload, position); err != nil || position.Query != hash {
+		return nil, ErrInvalidCursor
+	}
+
+	return position, nil
+}
+
+// cursorMAC signs the payload of a cursor with the CursorSecret.
+func (r *Repository[T]) cursorMAC(payload []byte) []byte {
+	mac := hmac.New(sha256.New, r.config.CursorSecret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,258 @@
+package mongorepo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DeadLetter is a change event whose handler kept failing, stored in the dead-letter collection
+// (Config.DeadLetterCollection) with the context of the failure until it is replayed.
+type DeadLetter struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	Consumer   string             `bson:"consumer"`   // The name of the ChangeConsumer.
+	Collection string             `bson:"collection"` // The watched collection.
+	Event      bson.Raw           `bson:"event"`      // The change event, as received from the stream.
+	Error      string             `bson:"error"`      // The error of the last attempt.
+	Attempts   int                `bson:"attempts"`   // The number of failed attempts, replays included.
+	FailedAt   time.Time          `bson:"failed_at"`  // When the last attempt failed.
+}
+
+// ChangeConsumerConfig holds the configuration of a ChangeConsumer.
+type ChangeConsumerConfig[T any] struct {
+	Name          string                                                // Identifies the dead letters of the consumer, default: the collection name
+	Pipeline      mongo.Pipeline                                        // Filters the events, e.g. built with Changes, default: every event
+	StreamOptions *options.ChangeStreamOptions                          // The options of the change stream, e.g. SetFullDocument or SetResumeAfter, default: nil
+	Handler       func(ctx context.Context, event ChangeEvent[T]) error // Processes an event.
+	MaxAttempts   int                                                   // The number of attempts of an event before it is dead-lettered, default: 3
+	RetryDelay    time.Duration                                         // The delay between two attempts of an event, default: 1 second
+	OnDeadLetter  func(letter DeadLetter)                               // Optional callback invoked when an event is dead-lettered.
+}
+
+// ChangeConsumer runs a handler on the events of Watch. An event the handler still fails on after MaxAttempts is
+// written to the dead-letter collection with its error, and the consumer moves on to the next event, so one poison
+// event cannot stall the stream. Once the cause is fixed, Replay runs the handler on the dead letters again.
+type ChangeConsumer[T any] struct {
+	repo   *Repository[T]
+	config ChangeConsumerConfig[T]
+}
+
+// NewChangeConsumer creates a ChangeConsumer on top of a repository.
+//
+// Parameters:
+//   - repo: The repository whose collection is watched.
+//   - config: The consumer configuration.
+//
+// Returns:
+//   - A pointer to a ChangeConsumer.
+//
+// Panics:
+//   - If config.Handler is not set.
+func NewChangeConsumer[T any](repo *Repository[T], config ChangeConsumerConfig[T]) *ChangeConsumer[T] {
+	if config.Handler == nil {
+		panic("Configuration error: The ChangeConsumerConfig.Handler is not set.")
+	}
+
+	if config.Name == "" {
+		config.Name = repo.config.CollectionName
+	}
+
+	if config.Pipeline == nil {
+		config.Pipeline = mongo.Pipeline{}
+	}
+
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 3
+	}
+
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = time.Second
+	}
+
+	return &ChangeConsumer[T]{repo: repo, config: config}
+}
+
+// Run watches the collection and handles its events until the context is cancelled.
+//
+// Parameters:
+//   - ctx: The context controlling the lifetime of the stream, passed to the handler.
+//
+// Returns:
+//   - The context error once cancelled.
+//   - ErrUnsupported if the deployment does not support change streams (standalone servers).
+//   - An error if the stream fails or an event cannot be dead-lettered.
+func (c *ChangeConsumer[T]) Run(ctx context.Context) error {
+	repo := c.repo.WithContext(ctx)
+	if err := repo.requireCapability("change streams", func(c *Capabilities) bool { return c.ChangeStreams }); err != nil {
+		return err
+	}
+
+	changeStream, err := repo.Collection().Watch(ctx, c.config.Pipeline, c.config.StreamOptions)
+	if err != nil {
+		return err
+	}
+	defer changeStream.Close(context.Background())
+
+	for changeStream.Next(ctx) {
+		raw := append(bson.Raw{}, changeStream.Current...)
+
+		attempts, handlerErr := c.handle(ctx, raw)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if handlerErr == nil {
+			continue
+		}
+
+		if err := c.deadLetter(ctx, raw, attempts, handlerErr); err != nil {
+			return err
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return changeStream.Err()
+}
+
+// DeadLetters returns the dead letters of the consumer.
+//
+// Parameters:
+//   - ctx: The context of the operation.
+//
+// Returns:
+//   - The dead letters, oldest failure first.
+//   - An error if the query fails.
+func (c *ChangeConsumer[T]) DeadLetters(ctx context.Context) ([]DeadLetter, error) {
+	return c.findDeadLetters(ctx, nil)
+}
+
+// Replay runs the handler once more on dead letters of the consumer. The ones it succeeds on are removed, the others
+// are kept with their new error and attempt count.
+//
+// Parameters:
+//   - ctx: The context of the operation, passed to the handler.
+//   - ids: The dead letters to replay, default: all of them.
+//
+// Returns:
+//   - The number of dead letters handled successfully.
+//   - An error if the dead letters cannot be read or updated.
+func (c *ChangeConsumer[T]) Replay(ctx context.Context, ids ...primitive.ObjectID) (int, error) {
+	letters, err := c.findDeadLetters(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	for _, letter := range letters {
+		if ctx.Err() != nil {
+			return replayed, ctx.Err()
+		}
+
+		handlerErr := c.attempt(ctx, letter.Event)
+		if handlerErr == nil {
+			if _, err := c.deadLetters().DeleteOne(ctx, bson.M{"_id": letter.ID}); err != nil {
+				return replayed, err
+			}
+			replayed++
+			continue
+		}
+
+		update := bson.M{
+			"$set": bson.M{"error": handlerErr.Error(), "failed_at": time.Now()},
+			"$inc": bson.M{"attempts": 1},
+		}
+		if _, err := c.deadLetters().UpdateOne(ctx, bson.M{"_id": letter.ID}, update); err != nil {
+			return replayed, err
+		}
+	}
+
+	return replayed, nil
+}
+
+// handle runs the handler on an event up to MaxAttempts times, returning the number of attempts made and the error
+// of the last one, nil once it succeeds.
+func (c *ChangeConsumer[T]) handle(ctx context.Context, raw bson.Raw) (int, error) {
+	var err error
+	for attempt := 1; attempt <= c.config.MaxAttempts; attempt++ {
+		if err = c.attempt(ctx, raw); err == nil {
+			return attempt, nil
+		}
+
+		if attempt == c.config.MaxAttempts {
+			return attempt, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempt, ctx.Err()
+		case <-time.After(c.config.RetryDelay):
+		}
+	}
+
+	return c.config.MaxAttempts, err
+}
+
+// attempt decodes an event and runs the handler on it. An event that cannot be decoded into `T` fails like
+// a handler error, so it is dead-lettered too.
+func (c *ChangeConsumer[T]) attempt(ctx context.Context, raw bson.Raw) error {
+	var event ChangeEvent[T]
+	if err := bson.Unmarshal(raw, &event); err != nil {
+		return err
+	}
+
+	return c.config.Handler(ctx, event)
+}
+
+// deadLetter stores an event the handler failed on.
+func (c *ChangeConsumer[T]) deadLetter(ctx context.Context, raw bson.Raw, attempts int, handlerErr error) error {
+	letter := DeadLetter{
+		ID:         primitive.NewObjectID(),
+		Consumer:   c.config.Name,
+		Collection: c.repo.config.CollectionName,
+		Event:      raw,
+		Error:      handlerErr.Error(),
+		Attempts:   attempts,
+		FailedAt:   time.Now(),
+	}
+
+	if _, err := c.deadLetters().InsertOne(ctx, letter); err != nil {
+		return err
+	}
+
+	if c.config.OnDeadLetter != nil {
+		c.config.OnDeadLetter(letter)
+	}
+
+	return nil
+}
+
+// findDeadLetters returns the dead letters of the consumer, oldest failure first, restricted to ids when given.
+func (c *ChangeConsumer[T]) findDeadLetters(ctx context.Context, ids []primitive.ObjectID) ([]DeadLetter, error) {
+	filter := bson.M{"consumer": c.config.Name, "collection": c.repo.config.CollectionName}
+	if len(ids) > 0 {
+		filter["_id"] = bson.M{"$in": ids}
+	}
+
+	cursor, err := c.deadLetters().Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "failed_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	letters := []DeadLetter{}
+	if err := cursor.All(ctx, &letters); err != nil {
+		return nil, err
+	}
+
+	return letters, nil
+}
+
+// deadLetters returns the dead-letter collection.
+func (c *ChangeConsumer[T]) deadLetters() *mongo.Collection {
+	return c.repo.Database().Collection(c.repo.config.DeadLetterCollection)
+}
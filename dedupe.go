@@ -0,0 +1,88 @@
+package mongorepo
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrDuplicateContent is returned by Create when a document with the same dedupe key already exists
+// and DedupeReturnExisting is disabled.
+var ErrDuplicateContent error = &kindError{kind: ErrDuplicateKey, err: errors.New("mongorepo: a document with the same content already exists")}
+
+// EnsureDedupeIndex creates the unique index on DedupeKeyField that Create relies on to detect duplicates.
+//
+// Returns:
+//   - An error if the index cannot be created.
+//
+// Panics:
+//   - If DedupeKeyField is not configured.
+func (r *Repository[T]) EnsureDedupeIndex() error {
+	if r.config.DedupeKeyField == "" {
+		panic("Configuration error: The DedupeKeyField is not set.")
+	}
+
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: r.fieldKey(r.config.DedupeKeyField), Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+
+	_, err := r.Collection().Indexes().CreateOne(r.config.Context, index)
+	return err
+}
+
+// dedupeKey hashes the DedupeFields of the entity, in the configured order.
+func (r *Repository[T]) dedupeKey(er *EntityReflection) string {
+	values := make([]any, 0, len(r.config.DedupeFields))
+	for _, field := range r.config.DedupeFields {
+		values = append(values, er.GetField(field))
+	}
+
+	return canonicalHash(values...)
+}
+
+// setDedupeKey stores the dedupe key of the entity, when deduplication is configured. Every write of a whole
+// entity recomputes it, so the key always matches the current DedupeFields.
+func (r *Repository[T]) setDedupeKey(er *EntityReflection) {
+	if r.config.DedupeKeyField != "" {
+		er.SetString(r.config.DedupeKeyField, r.dedupeKey(er))
+	}
+}
+
+// resolveDuplicate handles a duplicate-key error raised by Create when deduplication is enabled.
+// If the conflict comes from the dedupe key, the existing document is either copied into entity
+// (DedupeReturnExisting), decoded through hydrate, or reported with ErrDuplicateContent; conflicts of other
+// unique indexes are returned unchanged, without looking up the existing document.
+func (r *Repository[T]) resolveDuplicate(entity *T, er *EntityReflection, insertErr error) error {
+	dedupeKey := r.fieldKey(r.config.DedupeKeyField)
+	if !isKeyConflict(insertErr, dedupeKey) {
+		return insertErr
+	}
+
+	filter := bson.M{dedupeKey: er.GetString(r.config.DedupeKeyField)}
+	start := time.Now()
+	raw, err := r.Collection().FindOne(r.config.Context, filter, r.findOneComment("Create")).Raw()
+	r.observe("Create", filter, start, singleResult(err), err)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return insertErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if !r.config.DedupeReturnExisting {
+		return fmt.Errorf("%w: %w", ErrDuplicateContent, classify(insertErr))
+	}
+
+	var existing T
+	if err := r.hydrate(raw, &existing); err != nil {
+		return err
+	}
+
+	*entity = existing
+	return nil
+}
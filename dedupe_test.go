@@ -0,0 +1,59 @@
+package mongorepo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+type dedupedMessage struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	Email     string             `bson:"email"`
+	Body      string             `bson:"body"`
+	DedupeKey string             `bson:"dedupe_key"`
+}
+
+func TestCreateResolvesDedupeConflictsOnly(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	tests := []struct {
+		name      string
+		index     string
+		commands  string
+		duplicate bool // whether ErrDuplicateContent is expected
+	}{
+		{name: "dedupe key", index: "dedupe_key_1", commands: "insert,find", duplicate: true},
+		{name: "other unique index", index: "email_1", commands: "insert"},
+	}
+
+	for _, test := range tests {
+		mt.Run(test.name, func(mt *mtest.T) {
+			repo := New[dedupedMessage](&Config{MongoClient: mt.Client, DbName: "inbox", DedupeFields: []string{"Body"}, DedupeKeyField: "DedupeKey"})
+			mt.AddMockResponses(
+				mtest.CreateWriteErrorsResponse(mtest.WriteError{Code: 11000, Message: "E11000 duplicate key error collection: inbox.deduped_messages index: " + test.index + " dup key: { }"}),
+				mtest.CreateCursorResponse(0, "inbox.deduped_messages", mtest.FirstBatch, bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "body", Value: "hi"}}),
+			)
+
+			err := repo.Create(&dedupedMessage{Email: "jon@example.com", Body: "hi"})
+			if errors.Is(err, ErrDuplicateContent) != test.duplicate {
+				mt.Errorf("got %v, expected ErrDuplicateContent %v", err, test.duplicate)
+			}
+			var duplicate *DuplicateKeyError
+			if !errors.As(err, &duplicate) || duplicate.Index != test.index {
+				mt.Errorf("got %v, expected the duplicate-key error of %s", err, test.index)
+			}
+
+			var commands []string
+			for _, event := range mt.GetAllStartedEvents() {
+				commands = append(commands, event.CommandName)
+			}
+			if strings.Join(commands, ",") != test.commands {
+				mt.Errorf("got the commands %v, expected %s", commands, test.commands)
+			}
+		})
+	}
+}
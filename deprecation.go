@@ -0,0 +1,183 @@
+package mongorepo
+
+import (
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// DeprecatedField describes a field marked with the `deprecated` struct tag and its usage since the process started.
+type DeprecatedField struct {
+	Path   string // The BSON path of the field, e.g. "address.zip".
+	Reason string // The value of the tag, e.g. "use postal_code".
+	Reads  int64  // The number of documents read holding the field.
+	Writes int64  // The number of entities written with the field populated.
+}
+
+// DeprecationFunc is called when an entity is written with a deprecated field still populated.
+type DeprecationFunc func(collection string, field DeprecatedField)
+
+// deprecatedField is a deprecated field of a struct type.
+type deprecatedField struct {
+	path   []string
+	reason string
+}
+
+// deprecationCounters counts the usage of a deprecated field of a collection.
+type deprecationCounters struct {
+	reads  atomic.Int64
+	writes atomic.Int64
+}
+
+// deprecationKey identifies the counters of a deprecated field.
+type deprecationKey struct {
+	db, collection, path string
+}
+
+var (
+	// deprecatedFieldsCache caches the deprecated fields of the struct types, keyed by reflect.Type.
+	deprecatedFieldsCache sync.Map
+
+	// deprecationUsage holds the deprecationCounters of the deprecated fields, keyed by deprecationKey.
+	deprecationUsage sync.Map
+)
+
+// DeprecatedFields lists the fields of `T` marked deprecated, tracking schema cleanup: a field can be removed
+// from the struct once its reads and writes stay at zero. The tag value explains the deprecation:
+//
+//	type User struct {
+//		FullName string `bson:"full_name"`
+//		Name     string `bson:"name,omitempty" deprecated:"use full_name"`
+//	}
+//
+// The finders count the documents still holding a deprecated field, and the writes of an entity with a deprecated
+// field populated are counted and reported to Config.OnDeprecatedWrite. Nested structs are looked into,
+// not slices of structs.
+//
+// Returns:
+//   - The deprecated fields with their usage counts in the collection since the process started.
+func (r *Repository[T]) DeprecatedFields() []DeprecatedField {
+	fields := []DeprecatedField{}
+	for _, field := range deprecatedFieldsOf(reflect.TypeOf((*T)(nil)).Elem()) {
+		counters := r.deprecationCounters(field)
+		fields = append(fields, DeprecatedField{
+			Path:   strings.Join(field.path, "."),
+			Reason: field.reason,
+			Reads:  counters.reads.Load(),
+			Writes: counters.writes.Load(),
+		})
+	}
+
+	return fields
+}
+
+// countDeprecatedReads counts the deprecated fields a document read from the collection holds.
+func (r *Repository[T]) countDeprecatedReads(raw bson.Raw) {
+	for _, field := range deprecatedFieldsOf(reflect.TypeOf((*T)(nil)).Elem()) {
+		if holdsField(raw, field.path) {
+			r.deprecationCounters(field).reads.Add(1)
+		}
+	}
+}
+
+// warnDeprecatedWrites counts and reports the deprecated fields still populated in an entity about to be written.
+func (r *Repository[T]) warnDeprecatedWrites(entity *T) {
+	fields := deprecatedFieldsOf(reflect.TypeOf((*T)(nil)).Elem())
+	if len(fields) == 0 {
+		return
+	}
+
+	raw, err := bson.Marshal(entity)
+	if err != nil {
+		return
+	}
+
+	for _, field := range fields {
+		if !holdsField(raw, field.path) {
+			continue
+		}
+
+		counters := r.deprecationCounters(field)
+		counters.writes.Add(1)
+
+		usage := DeprecatedField{
+			Path:   strings.Join(field.path, "."),
+			Reason: field.reason,
+			Reads:  counters.reads.Load(),
+			Writes: counters.writes.Load(),
+		}
+		if r.config.OnDeprecatedWrite != nil {
+			r.config.OnDeprecatedWrite(r.config.CollectionName, usage)
+		} else {
+			r.logger().Warn("mongorepo deprecated field written",
+				slog.String("collection", r.config.CollectionName),
+				slog.String("field", usage.Path),
+				slog.String("reason", usage.Reason),
+				slog.Int64("writes", usage.Writes),
+			)
+		}
+	}
+}
+
+// deprecationCounters returns the counters of a deprecated field in the collection.
+func (r *Repository[T]) deprecationCounters(field deprecatedField) *deprecationCounters {
+	key := deprecationKey{db: r.config.DbName, collection: r.config.CollectionName, path: strings.Join(field.path, ".")}
+	counters, _ := deprecationUsage.LoadOrStore(key, &deprecationCounters{})
+	return counters.(*deprecationCounters)
+}
+
+// holdsField reports whether a document holds a non-null value at the path.
+func holdsField(raw bson.Raw, path []string) bool {
+	value, err := raw.LookupErr(path...)
+	return err == nil && value.Type != bson.TypeNull && value.Type != bson.TypeUndefined
+}
+
+// deprecatedFieldsOf returns the deprecated fields of a struct type.
+func deprecatedFieldsOf(t reflect.Type) []deprecatedField {
+	if cached, ok := deprecatedFieldsCache.Load(t); ok {
+		return cached.([]deprecatedField)
+	}
+
+	fields := []deprecatedField{}
+	collectDeprecatedFields(t, nil, &fields, map[reflect.Type]bool{})
+	deprecatedFieldsCache.Store(t, fields)
+
+	return fields
+}
+
+// collectDeprecatedFields adds the deprecated fields of a struct type, prefixing their paths with prefix.
+func collectDeprecatedFields(t reflect.Type, prefix []string, fields *[]deprecatedField, visiting map[reflect.Type]bool) {
+	if visiting[t] {
+		return
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, flags, _ := strings.Cut(field.Tag.Get("bson"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+
+		fieldType := derefType(field.Type)
+		if strings.Contains(flags, "inline") && fieldType.Kind() == reflect.Struct {
+			collectDeprecatedFields(fieldType, prefix, fields, visiting)
+			continue
+		}
+
+		path := append(append([]string{}, prefix...), bsonFieldName(t, field.Name))
+		if reason, ok := field.Tag.Lookup("deprecated"); ok {
+			*fields = append(*fields, deprecatedField{path: path, reason: reason})
+			continue
+		}
+
+		if fieldType.Kind() == reflect.Struct && !customDecoded(fieldType) {
+			collectDeprecatedFields(fieldType, path, fields, visiting)
+		}
+	}
+}
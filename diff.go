@@ -0,0 +1,102 @@
+package mongorepo
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// ChangeKind classifies a FieldChange.
+type ChangeKind string
+
+const (
+	FieldAdded    ChangeKind = "added"    // The field is only present in the new version.
+	FieldRemoved  ChangeKind = "removed"  // The field is only present in the old version.
+	FieldModified ChangeKind = "modified" // The field is present in both versions with different values.
+)
+
+// FieldChange is a difference between two versions of a document, at a BSON path.
+type FieldChange struct {
+	Path string     // The dotted BSON path of the field, e.g. "address.city".
+	Kind ChangeKind // The kind of change.
+	Old  any        // The old value, nil for added fields.
+	New  any        // The new value, nil for removed fields.
+}
+
+// Diff compares two entities as they would be stored and returns the changed fields.
+// Embedded documents are compared field by field, arrays and other values as a whole.
+//
+// Parameters:
+//   - a: The old version of the entity, may be nil.
+//   - b: The new version of the entity, may be nil.
+//
+// Returns:
+//   - The changes turning a into b, in document order.
+//   - An error if an entity cannot be encoded.
+func Diff[T any](a, b *T) ([]FieldChange, error) {
+	oldDoc, err := marshalOrEmpty(a)
+	if err != nil {
+		return nil, err
+	}
+
+	newDoc, err := marshalOrEmpty(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffDocuments("", oldDoc, newDoc)
+}
+
+// marshalOrEmpty encodes an entity, returning an empty document for nil.
+func marshalOrEmpty[T any](entity *T) (bson.Raw, error) {
+	if entity == nil {
+		return bson.Marshal(bson.D{})
+	}
+
+	return bson.Marshal(entity)
+}
+
+// diffDocuments compares two documents recursively.
+func diffDocuments(prefix string, oldDoc, newDoc bson.Raw) ([]FieldChange, error) {
+	oldElements, err := oldDoc.Elements()
+	if err != nil {
+		return nil, err
+	}
+
+	newElements, err := newDoc.Elements()
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []FieldChange
+	for _, element := range oldElements {
+		path := prefix + element.Key()
+		oldValue := element.Value()
+
+		newValue, err := newDoc.LookupErr(element.Key())
+		if err != nil {
+			changes = append(changes, FieldChange{Path: path, Kind: FieldRemoved, Old: decodeRawValue(oldValue)})
+			continue
+		}
+
+		if oldValue.Type == bsontype.EmbeddedDocument && newValue.Type == bsontype.EmbeddedDocument {
+			nested, err := diffDocuments(path+".", oldValue.Document(), newValue.Document())
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, nested...)
+			continue
+		}
+
+		if !oldValue.Equal(newValue) {
+			changes = append(changes, FieldChange{Path: path, Kind: FieldModified, Old: decodeRawValue(oldValue), New: decodeRawValue(newValue)})
+		}
+	}
+
+	for _, element := range newElements {
+		if _, err := oldDoc.LookupErr(element.Key()); err != nil {
+			changes = append(changes, FieldChange{Path: prefix + element.Key(), Kind: FieldAdded, New: decodeRawValue(element.Value())})
+		}
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,95 @@
+package mongorepo
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type diffAddress struct {
+	City string `bson:"city"`
+	Zip  string `bson:"zip,omitempty"`
+}
+
+type diffEntity struct {
+	Name    string      `bson:"name"`
+	Age     int32       `bson:"age,omitempty"`
+	Tags    []string    `bson:"tags,omitempty"`
+	Address diffAddress `bson:"address"`
+}
+
+func TestDiff(t *testing.T) {
+	base := diffEntity{Name: "Jorge", Age: 30, Tags: []string{"a"}, Address: diffAddress{City: "Montevideo"}}
+	with := func(change func(e *diffEntity)) *diffEntity {
+		e := base
+		e.Tags = append([]string(nil), base.Tags...)
+		change(&e)
+		return &e
+	}
+
+	tests := []struct {
+		name     string
+		a, b     *diffEntity
+		expected []FieldChange
+	}{
+		{name: "equal", a: &base, b: with(func(e *diffEntity) {})},
+		{
+			name: "modified",
+			a:    &base, b: with(func(e *diffEntity) { e.Name = "Elías" }),
+			expected: []FieldChange{{Path: "name", Kind: FieldModified, Old: "Jorge", New: "Elías"}},
+		},
+		{
+			name: "removed",
+			a:    &base, b: with(func(e *diffEntity) { e.Age = 0 }),
+			expected: []FieldChange{{Path: "age", Kind: FieldRemoved, Old: int32(30)}},
+		},
+		{
+			name: "added",
+			a:    with(func(e *diffEntity) { e.Age = 0 }), b: &base,
+			expected: []FieldChange{{Path: "age", Kind: FieldAdded, New: int32(30)}},
+		},
+		{
+			name: "nested fields",
+			a:    &base, b: with(func(e *diffEntity) { e.Address = diffAddress{City: "Salto", Zip: "50000"} }),
+			expected: []FieldChange{
+				{Path: "address.city", Kind: FieldModified, Old: "Montevideo", New: "Salto"},
+				{Path: "address.zip", Kind: FieldAdded, New: "50000"},
+			},
+		},
+		{
+			name: "arrays as a whole",
+			a:    &base, b: with(func(e *diffEntity) { e.Tags = append(e.Tags, "b") }),
+			expected: []FieldChange{{Path: "tags", Kind: FieldModified, Old: primitive.A{"a"}, New: primitive.A{"a", "b"}}},
+		},
+		{
+			name: "nil old version",
+			a:    nil, b: &diffEntity{Name: "Jorge", Address: diffAddress{City: "Salto"}},
+			expected: []FieldChange{
+				{Path: "name", Kind: FieldAdded, New: "Jorge"},
+				{Path: "address", Kind: FieldAdded, New: bson.D{{Key: "city", Value: "Salto"}}},
+			},
+		},
+		{
+			name: "nil new version",
+			a:    &diffEntity{Name: "Jorge", Address: diffAddress{City: "Salto"}}, b: nil,
+			expected: []FieldChange{
+				{Path: "name", Kind: FieldRemoved, Old: "Jorge"},
+				{Path: "address", Kind: FieldRemoved, Old: bson.D{{Key: "city", Value: "Salto"}}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			changes, err := Diff(test.a, test.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(changes, test.expected) {
+				t.Errorf("got %#v, expected %#v", changes, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package mongorepo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// The helpers below are meant for types embedding Repository[T] to add their own finders:
+//
+//	type UserRepository struct {
+//		*mongorepo.Repository[User]
+//	}
+//
+//	func (r *UserRepository) FindActiveByEmail(email string) (user *User, err error) {
+//		filter := r.ScopedFilter(bson.M{r.FieldKey("Email"): email, "active": true})
+//		err = r.Observe("FindActiveByEmail", filter, func() (int, error) {
+//			opts := options.FindOne()
+//			if comment := r.Comment("FindActiveByEmail"); comment != "" {
+//				opts.SetComment(comment)
+//			}
+//			user, err = r.DecodeOne(r.Collection().FindOne(r.Context(), filter, opts))
+//			return 1, err
+//		})
+//		return user, err
+//	}
+//
+// Going through them keeps custom queries consistent with the built-in operations: the filters get the soft-delete
+// and region scopes, the operations are tagged, measured and logged, and the entities are hydrated (strict decoding,
+// offloaded fields, region check) and redacted like the ones of Find.
+
+// Context returns the context configured for the repository operations.
+//
+// Returns:
+//   - The context of the configuration.
+func (r *Repository[T]) Context() context.Context {
+	return r.config.Context
+}
+
+// FieldKey resolves the BSON key of a struct field of the entity `T`.
+//
+// Parameters:
+//   - field: The name of the struct field, e.g. "CreatedAt".
+//
+// Returns:
+//   - The BSON key of the field, e.g. "created_at".
+//
+// Panics:
+//   - If the field does not exist or is not stored.
+func (r *Repository[T]) FieldKey(field string) string {
+	return r.fieldKey(field)
+}
+
+// ScopedFilter applies the repository scopes (the soft-delete exclusion and the region of a regional repository)
+// to a query filter, the same way the built-in operations do.
+//
+// Parameters:
+//   - query: A BSON map defining the search criteria.
+//
+// Returns:
+//   - A new filter containing the query and the scope conditions.
+func (r *Repository[T]) ScopedFilter(query bson.M) bson.M {
+	return r.scopeFilter(query)
+}
+
+// ScopedPipeline applies the repository scopes to an aggregation pipeline, the same way Aggregate does.
+//
+// Parameters:
+//   - pipeline: The aggregation pipeline to scope.
+//
+// Returns:
+//   - A new pipeline with the scope applied.
+func (r *Repository[T]) ScopedPipeline(pipeline mongo.Pipeline) mongo.Pipeline {
+	return r.scopePipeline(pipeline)
+}
+
+// Comment returns the $comment the built-in operations set for an operation, see Config.Comment and ServiceName.
+//
+// Parameters:
+//   - operation: The name of the operation, e.g. "FindActiveByEmail".
+//
+// Returns:
+//   - The comment, empty when tagging is disabled (leave the comment unset then).
+func (r *Repository[T]) Comment(operation string) string {
+	return r.comment(operation)
+}
+
+// Observe runs a custom operation the way the built-in operations run: the region of a regional repository is
+// checked first, and the operation is then reported to Config.Metrics, Config.QueryShapes, the slow-operation
+// warning and the debug log.
+//
+// Parameters:
+//   - operation: The name of the operation reported, e.g. "FindActiveByEmail".
+//   - filter: The filter or pipeline of the operation, summarized in the reports; may be nil.
+//   - run: The operation, returning the number of documents it returned and its error.
+//
+// Returns:
+//   - ErrRegionMismatch if the context is bound to another region, otherwise the error of run.
+func (r *Repository[T]) Observe(operation string, filter any, run func() (int, error)) error {
+	if err := r.guardRegion(); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	documents, err := run()
+	if err != nil {
+		documents = 0
+	}
+	r.observe(operation, filter, start, documents, err)
+
+	return err
+}
+
+// DecodeOne decodes the result of a FindOne or FindOneAnd* operation into a new entity, hydrated and redacted
+// like the entity of FindOne.
+//
+// Parameters:
+//   - result: The single result to decode.
+//
+// Returns:
+//   - A pointer to the decoded entity of type `T`.
+//   - ErrNotFound if nothing matched, or the error of the operation or the decoding.
+func (r *Repository[T]) DecodeOne(result *mongo.SingleResult) (*T, error) {
+	raw, err := result.Raw()
+	if err != nil {
+		return nil, classify(err)
+	}
+
+	return r.decodeEntity(raw)
+}
+
+// DecodeAll drains a cursor into new entities and closes it. The entities are hydrated and redacted like the ones
+// of Find.
+//
+// Parameters:
+//   - cursor: The cursor of a Find or Aggregate operation.
+//
+// Returns:
+//   - A slice of pointers to the decoded entities of type `T`.
+//   - An error if the iteration or the decoding fails.
+func (r *Repository[T]) DecodeAll(cursor *mongo.Cursor) ([]*T, error) {
+	ctx := r.config.Context
+	defer cursor.Close(ctx)
+
+	var entities []*T
+	for cursor.Next(ctx) {
+		entity, err := r.decodeEntity(cursor.Current)
+		if err != nil {
+			return nil, err
+		}
+		entities = append(entities, entity)
+	}
+
+	return entities, cursor.Err()
+}
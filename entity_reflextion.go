@@ -3,6 +3,7 @@ package mongorepo
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -42,19 +43,24 @@ func NewEntityReflection(config *Config, entity any) *EntityReflection {
 	}
 }
 
-// GetID retrieves the ObjectID from the entity's ID field specified in the configuration.
+// GetID retrieves the value of the entity's ID field specified in the configuration, e.g. a primitive.ObjectID,
+// a string slug, a uuid.UUID or an int64.
+// It panics if the ID field is not found.
+//
+// Returns:
+//   - The value of the entity's ID field.
+func (er *EntityReflection) GetID() any {
+	return er.idField().Interface()
+}
+
+// GetObjectID retrieves the ObjectID from the entity's ID field specified in the configuration, for the
+// features relying on ObjectIDs such as materialized tree paths.
 // It panics if the ID field is not found or is not of type primitive.ObjectID.
 //
 // Returns:
 //   - The ObjectID from the entity's ID field.
-func (er *EntityReflection) GetID() primitive.ObjectID {
-	entityElem := reflect.ValueOf(er.entity).Elem()
-	idField := entityElem.FieldByName(er.config.IdField)
-
-	if !idField.IsValid() {
-		exception := fmt.Sprintf("Error: Field %q not found in entity. Check if %q is the correct field name in the entity struct.", er.config.IdField, er.config.IdField)
-		panic(exception)
-	}
+func (er *EntityReflection) GetObjectID() primitive.ObjectID {
+	idField := er.idField()
 
 	if idField.Type() != reflect.TypeOf(primitive.ObjectID{}) {
 		exception := fmt.Sprintf("Error: Field %q in entity is not of type primitive.ObjectID. Actual type: %s", er.config.IdField, idField.Type().String())
@@ -64,20 +70,129 @@ func (er *EntityReflection) GetID() primitive.ObjectID {
 	return idField.Interface().(primitive.ObjectID)
 }
 
-// SetNewID sets a new ObjectID to the entity's ID field specified in the configuration.
-// It panics if the ID field is not found, cannot be set, or is not of type primitive.ObjectID.
+// HasID reports whether the entity's ID field specified in the configuration holds a non-zero value.
+// It panics if the ID field is not found.
+//
+// Returns:
+//   - Whether the entity has an ID.
+func (er *EntityReflection) HasID() bool {
+	return !er.idField().IsZero()
+}
+
+// SetNewID sets a new ID to the entity's ID field specified in the configuration: the value returned by the
+// configured IDGenerator, or a new ObjectID. Without IDGenerator, an ID field of another type (a string slug,
+// a uuid.UUID, an int64...) keeps the ID set by the caller.
+// It panics if the ID field is not found, cannot be set, cannot hold the generated ID, or is of another type
+// than primitive.ObjectID and holds no ID.
 func (er *EntityReflection) SetNewID() {
-	entityElem := reflect.ValueOf(er.entity).Elem()
-	idField := entityElem.FieldByName(er.config.IdField)
+	idField := er.idField()
 
-	if !idField.IsValid() || !idField.CanSet() || idField.Type() != reflect.TypeOf(primitive.ObjectID{}) {
-		errorStr := fmt.Sprintf("Error: ID field %q is either not found or cannot be set. Ensure it is defined as primitive.ObjectID", er.config.IdField)
+	if er.config.IDGenerator != nil {
+		er.setGeneratedID(idField)
+		return
+	}
+
+	if idField.Type() != reflect.TypeOf(primitive.ObjectID{}) {
+		if idField.IsZero() {
+			errorStr := fmt.Sprintf("Error: ID field %q of type %s holds no ID. Set it before the insertion, or configure an IDGenerator", er.config.IdField, idField.Type().String())
+			panic(errorStr)
+		}
+		return
+	}
+
+	if !idField.CanSet() {
+		errorStr := fmt.Sprintf("Error: ID field %q cannot be set.", er.config.IdField)
 		panic(errorStr)
 	}
 
 	idField.Set(reflect.ValueOf(primitive.NewObjectID()))
 }
 
+// setGeneratedID sets the value returned by the IDGenerator to the ID field, converting it to the field
+// type when both share the same kind, e.g. a string to a custom string type.
+// It panics if the ID field is not found, cannot be set, or cannot hold the generated ID.
+func (er *EntityReflection) setGeneratedID(idField reflect.Value) {
+	id := reflect.ValueOf(er.config.IDGenerator())
+
+	if !idField.CanSet() {
+		errorStr := fmt.Sprintf("Error: ID field %q cannot be set.", er.config.IdField)
+		panic(errorStr)
+	}
+
+	switch {
+	case id.IsValid() && id.Type().AssignableTo(idField.Type()):
+		idField.Set(id)
+	case id.IsValid() && id.Kind() == idField.Kind() && id.Type().ConvertibleTo(idField.Type()):
+		idField.Set(id.Convert(idField.Type()))
+	default:
+		generated := "nil"
+		if id.IsValid() {
+			generated = id.Type().String()
+		}
+		errorStr := fmt.Sprintf("Error: The IDGenerator returned a %s, which cannot be set to the ID field %q of type %s", generated, er.config.IdField, idField.Type().String())
+		panic(errorStr)
+	}
+}
+
+// GetField retrieves the value of the specified field in the entity.
+// It panics if the field is not found.
+//
+// Parameters:
+//   - field: The name of the field to read.
+//
+// Returns:
+//   - The value stored in the field.
+func (er *EntityReflection) GetField(field string) any {
+	return er.field(field).Interface()
+}
+
+// SetField assigns a value to the specified field in the entity.
+// It panics if the field is not found, cannot be set, or the value is not assignable to the field type.
+//
+// Parameters:
+//   - field: The name of the field to set.
+//   - value: The value to assign.
+func (er *EntityReflection) SetField(field string, value any) {
+	entityField := er.field(field)
+	newValue := reflect.ValueOf(value)
+
+	if !entityField.CanSet() || !newValue.IsValid() || !newValue.Type().AssignableTo(entityField.Type()) {
+		exception := fmt.Sprintf("Error: Field %q cannot be set to a value of type %T. Expected type: %s", field, value, entityField.Type().String())
+		panic(exception)
+	}
+
+	entityField.Set(newValue)
+}
+
+// GetString retrieves the value of the specified string field in the entity, including custom string types.
+// It panics if the field is not found or its kind is not string.
+//
+// Parameters:
+//   - field: The name of the field to read.
+//
+// Returns:
+//   - The string stored in the field.
+func (er *EntityReflection) GetString(field string) string {
+	return er.stringField(field).String()
+}
+
+// SetString assigns a string to the specified string field in the entity, including custom string types.
+// It panics if the field is not found, cannot be set, or its kind is not string.
+//
+// Parameters:
+//   - field: The name of the field to set.
+//   - value: The string to assign.
+func (er *EntityReflection) SetString(field string, value string) {
+	stringField := er.stringField(field)
+
+	if !stringField.CanSet() {
+		exception := fmt.Sprintf("Error: Field %q in entity cannot be set.", field)
+		panic(exception)
+	}
+
+	stringField.SetString(value)
+}
+
 // SetUpdateAt sets the current time to the entity's UpdatedAt field specified in the configuration.
 func (er *EntityReflection) SetUpdateAt() {
 	er.setTimeStampField(er.config.UpdatedAtField)
@@ -93,24 +208,139 @@ func (er *EntityReflection) SetDeletedAt() {
 	er.setTimeStampField(er.config.DeletedAtField)
 }
 
+// ClearDeletedAt resets the entity's DeletedAt field specified in the configuration to the zero time.
+func (er *EntityReflection) ClearDeletedAt() {
+	er.timeField(er.config.DeletedAtField).SetZero()
+}
+
+// GetVersion retrieves the value of the entity's Version field specified in the configuration.
+func (er *EntityReflection) GetVersion() int64 {
+	return er.intField(er.config.VersionField).Int()
+}
+
+// SetVersion sets the entity's Version field specified in the configuration.
+func (er *EntityReflection) SetVersion(version int64) {
+	er.intField(er.config.VersionField).SetInt(version)
+}
+
+// GetTimeField retrieves the value of the specified field of type time.Time in the entity.
+// It panics if the field is not found or is not of type time.Time.
+//
+// Parameters:
+//   - field: The name of the field to read.
+//
+// Returns:
+//   - The time stored in the field.
+func (er *EntityReflection) GetTimeField(field string) time.Time {
+	return er.timeField(field).Interface().(time.Time)
+}
+
 // setTimeStampField sets the current time to the specified field of type time.Time in the entity.
 // It panics if the field is not found or is not of type time.Time.
 //
 // Parameters:
 //   - field: The name of the field to set the timestamp on.
 func (er *EntityReflection) setTimeStampField(field string) {
-	entityElem := reflect.ValueOf(er.entity).Elem()
-	timeField := entityElem.FieldByName(field)
+	er.timeField(field).Set(reflect.ValueOf(time.Now()))
+}
 
-	if !timeField.IsValid() {
+// field looks up the specified field in the entity.
+// It panics if the field is not found.
+func (er *EntityReflection) field(field string) reflect.Value {
+	entityField := reflect.ValueOf(er.entity).Elem().FieldByName(field)
+
+	if !entityField.IsValid() {
 		exception := fmt.Sprintf("Error: Field %q not found in entity. Ensure the field name is correct.", field)
 		panic(exception)
 	}
 
+	return entityField
+}
+
+// idField looks up the entity's ID field specified in the configuration.
+// It panics if the ID field is not found.
+func (er *EntityReflection) idField() reflect.Value {
+	idField := reflect.ValueOf(er.entity).Elem().FieldByName(er.config.IdField)
+
+	if !idField.IsValid() {
+		exception := fmt.Sprintf("Error: Field %q not found in entity. Check if %q is the correct field name in the entity struct.", er.config.IdField, er.config.IdField)
+		panic(exception)
+	}
+
+	return idField
+}
+
+// stringField looks up the specified field of kind string in the entity.
+// It panics if the field is not found or its kind is not string.
+func (er *EntityReflection) stringField(field string) reflect.Value {
+	stringField := er.field(field)
+
+	if stringField.Kind() != reflect.String {
+		exception := fmt.Sprintf("Error: Field %q in entity is not a string. Actual type: %s", field, stringField.Type().String())
+		panic(exception)
+	}
+
+	return stringField
+}
+
+// intField looks up the specified field of an integer kind in the entity.
+// It panics if the field is not found or its kind is not an integer.
+func (er *EntityReflection) intField(field string) reflect.Value {
+	intField := er.field(field)
+
+	switch intField.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return intField
+	}
+
+	exception := fmt.Sprintf("Error: Field %q in entity is not an integer. Actual type: %s", field, intField.Type().String())
+	panic(exception)
+}
+
+// timeField looks up the specified field of type time.Time in the entity.
+// It panics if the field is not found or is not of type time.Time.
+func (er *EntityReflection) timeField(field string) reflect.Value {
+	timeField := er.field(field)
+
 	if timeField.Type() != reflect.TypeOf(time.Time{}) {
 		exception := fmt.Sprintf("Error: Field %q in entity is not of type time.Time. Actual type: %s", field, timeField.Type().String())
 		panic(exception)
 	}
 
-	timeField.Set(reflect.ValueOf(time.Now()))
+	return timeField
+}
+
+// bsonFieldName resolves the BSON key used to store the given struct field of entityType.
+// It follows the driver's default behaviour: the name declared in the `bson` tag, or the
+// lowercased struct field name when the tag does not declare one.
+// It panics if the field is not found in the struct or is excluded from encoding.
+//
+// Parameters:
+//   - entityType: The struct type (or pointer to struct type) of the entity.
+//   - field: The name of the struct field.
+//
+// Returns:
+//   - The BSON key of the field.
+func bsonFieldName(entityType reflect.Type, field string) string {
+	if entityType.Kind() == reflect.Ptr {
+		entityType = entityType.Elem()
+	}
+
+	structField, ok := entityType.FieldByName(field)
+	if !ok {
+		exception := fmt.Sprintf("Error: Field %q not found in entity %s. Ensure the field name is correct.", field, entityType.Name())
+		panic(exception)
+	}
+
+	name, _, _ := strings.Cut(structField.Tag.Get("bson"), ",")
+	if name == "-" {
+		exception := fmt.Sprintf("Error: Field %q in entity %s is excluded from BSON encoding (bson:\"-\").", field, entityType.Name())
+		panic(exception)
+	}
+
+	if name == "" {
+		return strings.ToLower(structField.Name)
+	}
+
+	return name
 }
@@ -0,0 +1,149 @@
+package mongorepo
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// The categories of the errors returned by the repository, to check with errors.Is regardless of the operation
+// or of the driver error behind them. The specific errors of the package belong to one of them, e.g.
+// errors.Is(ErrStaleDocument, ErrStale) is true, and the driver errors they classify still match, e.g.
+// errors.Is(err, mongo.ErrNoDocuments) and mongo.IsDuplicateKeyError(err) keep working.
+var (
+	// ErrNotFound is returned when the document an operation reads or writes does not exist
+	// (wrapping mongo.ErrNoDocuments), and by the lookups of the package such as RestoreBackup.
+	ErrNotFound = errors.New("mongorepo: document not found")
+
+	// ErrDuplicateKey is returned when a write violates a unique index, as a DuplicateKeyError naming the index,
+	// and by ErrDuplicateContent and ErrKeyCollision.
+	ErrDuplicateKey = errors.New("mongorepo: duplicate key")
+
+	// ErrInvalidID is returned when an ID cannot be parsed, e.g. a malformed hex string given to FindByHexId.
+	ErrInvalidID = errors.New("mongorepo: invalid id")
+
+	// ErrStale is returned when a write lost a race against a concurrent one: ErrStaleDocument,
+	// ErrModifyConflict and ErrTransitionConflict.
+	ErrStale = errors.New("mongorepo: stale write")
+)
+
+// kindError is an error belonging to a category of the taxonomy: it reads as err and matches both err and kind.
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+func (e *kindError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// DuplicateKeyError is returned by the writes rejected by a unique index, identifying the constraint that failed,
+// e.g. to answer 409 Conflict with the offending field. It wraps ErrDuplicateKey and the driver error.
+type DuplicateKeyError struct {
+	Index    string // The name of the unique index, e.g. "email_1", empty if the server did not report it.
+	KeyValue bson.M // The duplicate key, e.g. {"email": "jon@example.com"}, nil if the server did not report it.
+	err      error
+}
+
+func (e *DuplicateKeyError) Error() string {
+	return e.err.Error()
+}
+
+func (e *DuplicateKeyError) Unwrap() []error {
+	return []error{ErrDuplicateKey, e.err}
+}
+
+// duplicateIndexPattern extracts the index name from an E11000 message, e.g.
+// "E11000 duplicate key error collection: shop.users index: email_1 dup key: { email: "jon@example.com" }".
+var duplicateIndexPattern = regexp.MustCompile(`index: (\S+) dup key`)
+
+// errNoDocuments is returned by the operations finding no document to work on.
+var errNoDocuments error = &kindError{kind: ErrNotFound, err: mongo.ErrNoDocuments}
+
+// classify tags the driver errors with their category: missing documents with ErrNotFound and unique index
+// violations with ErrDuplicateKey. Other errors, and the ones already classified, are returned as is.
+func classify(err error) error {
+	var classified *kindError
+	var duplicate *DuplicateKeyError
+	switch {
+	case err == nil || errors.As(err, &classified) || errors.As(err, &duplicate):
+		return err
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return &kindError{kind: ErrNotFound, err: err}
+	case mongo.IsDuplicateKeyError(err):
+		return newDuplicateKeyError(err)
+	}
+
+	return err
+}
+
+// newDuplicateKeyError reads the index and the key of a duplicate-key error from the first duplicate reported in
+// the server response: the keyValue field of the error document (servers 4.2+) and the index name of its message.
+func newDuplicateKeyError(err error) *DuplicateKeyError {
+	duplicate := &DuplicateKeyError{err: err}
+
+	var message string
+	var raw bson.Raw
+	var writeException mongo.WriteException
+	var bulkException mongo.BulkWriteException
+	var commandError mongo.CommandError
+	switch {
+	case errors.As(err, &writeException):
+		for _, writeError := range writeException.WriteErrors {
+			if writeError.HasErrorCode(11000) || writeError.HasErrorCode(11001) {
+				message, raw = writeError.Message, writeError.Raw
+				break
+			}
+		}
+	case errors.As(err, &bulkException):
+		for _, writeError := range bulkException.WriteErrors {
+			if writeError.HasErrorCode(11000) || writeError.HasErrorCode(11001) {
+				message, raw = writeError.Message, writeError.Raw
+				break
+			}
+		}
+	case errors.As(err, &commandError):
+		message, raw = commandError.Message, commandError.Raw
+	}
+
+	if match := duplicateIndexPattern.FindStringSubmatch(message); match != nil {
+		duplicate.Index = match[1]
+	}
+
+	if document, ok := raw.Lookup("keyValue").DocumentOK(); ok {
+		keyValue := bson.M{}
+		if bson.Unmarshal(document, &keyValue) == nil {
+			duplicate.KeyValue = keyValue
+		}
+	}
+
+	return duplicate
+}
+
+// isKeyConflict reports whether err is a duplicate-key error of a unique index on key: the duplicate key holds
+// the key or, when the server did not report the key, the name of the index mentions it.
+func isKeyConflict(err error, key string) bool {
+	if !mongo.IsDuplicateKeyError(err) {
+		return false
+	}
+
+	var duplicate *DuplicateKeyError
+	if !errors.As(err, &duplicate) {
+		duplicate = newDuplicateKeyError(err)
+	}
+
+	if duplicate.KeyValue != nil {
+		_, ok := duplicate.KeyValue[key]
+		return ok
+	}
+
+	// default index names join the keys and their directions, e.g. "slug_1" or "tenant_1_slug_1"
+	return strings.HasPrefix(duplicate.Index, key+"_") || strings.Contains(duplicate.Index, "_"+key+"_")
+}
@@ -0,0 +1,38 @@
+package mongorepo
+
+import (
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestIsKeyConflict(t *testing.T) {
+	duplicate := mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000, Message: "E11000 duplicate key error"}}}
+
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "nil", err: nil},
+		{name: "other error", err: errors.New("boom")},
+		{name: "slug key", err: &DuplicateKeyError{Index: "slug_1", KeyValue: bson.M{"slug": "a"}, err: duplicate}, expected: true},
+		{name: "compound index with the slug", err: &DuplicateKeyError{Index: "tenant_1_slug_1", KeyValue: bson.M{"tenant": "t", "slug": "a"}, err: duplicate}, expected: true},
+		{name: "other key", err: &DuplicateKeyError{Index: "email_1", KeyValue: bson.M{"email": "a"}, err: duplicate}},
+		{name: "slug index without key", err: &DuplicateKeyError{Index: "slug_1", err: duplicate}, expected: true},
+		{name: "compound index without key", err: &DuplicateKeyError{Index: "tenant_1_slug_-1", err: duplicate}, expected: true},
+		{name: "other index without key", err: &DuplicateKeyError{Index: "email_1", err: duplicate}},
+		{name: "similar index without key", err: &DuplicateKeyError{Index: "slugs_1", err: duplicate}},
+		{name: "unclassified driver error", err: mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000, Message: "E11000 duplicate key error collection: blog.posts index: slug_1 dup key: { slug: \"a\" }"}}}, expected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := isKeyConflict(test.err, "slug"); got != test.expected {
+				t.Errorf("got %v, expected %v", got, test.expected)
+			}
+		})
+	}
+}
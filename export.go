@@ -0,0 +1,189 @@
+package mongorepo
+
+import (
+	"bytes"
+	"io"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ExportFormat identifies the encoding of exported documents.
+type ExportFormat int
+
+const (
+	ExportBSON      ExportFormat = iota // Concatenated BSON documents, the format of mongodump .bson files.
+	ExportJSONLines                     // One canonical Extended JSON document per line.
+)
+
+// ExportProgress reports the progress of Export.
+type ExportProgress struct {
+	Done   int64 `bson:"done"`    // The number of documents exported, including the ones of previous runs.
+	Total  int64 `bson:"total"`   // The number of documents matching the filter when the export started.
+	Bytes  int64 `bson:"bytes"`   // The number of bytes written, including the ones of previous runs.
+	LastID any   `bson:"last_id"` // The _id of the last exported document.
+}
+
+// ExportOptions holds the optional settings of Export.
+type ExportOptions struct {
+	Format ExportFormat // The encoding of the documents, default: ExportBSON
+	// Checkpoint names the export to make it resumable: the progress is stored in Config.CheckpointsCollection
+	// after each batch, and a restarted export continues after the last committed _id. default: disabled
+	Checkpoint string
+	BatchSize  int32                         // The number of documents between checkpoints and progress reports, default: 500
+	OnProgress func(progress ExportProgress) // Called after each batch, default: nil
+}
+
+// Export writes the documents matching the filter to w, in _id order, as raw documents: soft-deleted
+// documents are included unless the filter excludes them. If w has a Flush() error method, it is flushed
+// before each checkpoint so the checkpoint never gets ahead of the written data.
+//
+// To resume a named export, reopen the output, truncate it to the Bytes of ExportCheckpoint (data written
+// after the last checkpoint is exported again) and call Export with the same filter and checkpoint.
+// The checkpoint is removed on completion.
+//
+// Parameters:
+//   - w: The destination of the exported documents.
+//   - filter: A BSON map selecting the documents to export.
+//   - opts: Optional ExportOptions (format, checkpoint, progress callback).
+//
+// Returns:
+//   - The progress of the export, also on failure.
+//   - An error if the query, a write or the checkpoint fails.
+func (r *Repository[T]) Export(w io.Writer, filter bson.M, opts ...*ExportOptions) (*ExportProgress, error) {
+	settings := ExportOptions{BatchSize: defaultBatchSize}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if opt.Format != ExportBSON {
+			settings.Format = opt.Format
+		}
+		if opt.Checkpoint != "" {
+			settings.Checkpoint = opt.Checkpoint
+		}
+		if opt.BatchSize > 0 {
+			settings.BatchSize = opt.BatchSize
+		}
+		if opt.OnProgress != nil {
+			settings.OnProgress = opt.OnProgress
+		}
+	}
+
+	ctx := r.config.Context
+	progress := &ExportProgress{}
+	resumed := false
+	if settings.Checkpoint != "" {
+		var err error
+		if resumed, err = r.loadCheckpoint(settings.Checkpoint, progress); err != nil {
+			return progress, err
+		}
+	}
+
+	if !resumed {
+		total, err := r.Collection().CountDocuments(ctx, filter)
+		if err != nil {
+			return progress, err
+		}
+		progress.Total = total
+	}
+
+	query := filter
+	if resumed && progress.LastID != nil {
+		query = bson.M{"$and": bson.A{filter, bson.M{"_id": bson.M{"$gt": progress.LastID}}}}
+	}
+
+	findOpts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetBatchSize(settings.BatchSize)
+	cursor, err := r.Collection().Find(ctx, query, findOpts)
+	if err != nil {
+		return progress, err
+	}
+	defer cursor.Close(ctx)
+
+	pending := 0
+	for cursor.Next(ctx) {
+		written, err := writeExported(w, cursor.Current, settings.Format)
+		progress.Bytes += int64(written)
+		if err != nil {
+			return progress, err
+		}
+
+		progress.Done++
+		progress.LastID = decodeRawValue(cursor.Current.Lookup("_id"))
+
+		if pending++; pending == int(settings.BatchSize) {
+			if err := r.commitExport(w, settings, progress); err != nil {
+				return progress, err
+			}
+			pending = 0
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return progress, err
+	}
+
+	if pending > 0 {
+		if err := r.commitExport(w, settings, progress); err != nil {
+			return progress, err
+		}
+	}
+
+	if settings.Checkpoint != "" {
+		return progress, r.clearCheckpoint(settings.Checkpoint)
+	}
+
+	return progress, nil
+}
+
+// ExportCheckpoint returns the progress stored by an interrupted export, to prepare its output before resuming.
+//
+// Parameters:
+//   - name: The Checkpoint of the export.
+//
+// Returns:
+//   - The stored progress, or nil if the export has no checkpoint.
+//   - An error if the checkpoint cannot be read.
+func (r *Repository[T]) ExportCheckpoint(name string) (*ExportProgress, error) {
+	progress := &ExportProgress{}
+	found, err := r.loadCheckpoint(name, progress)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return progress, nil
+}
+
+// commitExport flushes the output, stores the checkpoint and reports the progress of a completed batch.
+func (r *Repository[T]) commitExport(w io.Writer, settings ExportOptions, progress *ExportProgress) error {
+	if flusher, ok := w.(interface{ Flush() error }); ok {
+		if err := flusher.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if settings.Checkpoint != "" {
+		if err := r.saveCheckpoint(settings.Checkpoint, progress); err != nil {
+			return err
+		}
+	}
+
+	if settings.OnProgress != nil {
+		settings.OnProgress(*progress)
+	}
+
+	return nil
+}
+
+// writeExported writes a document in the export format, returning the number of bytes written.
+func writeExported(w io.Writer, doc bson.Raw, format ExportFormat) (int, error) {
+	if format != ExportJSONLines {
+		return w.Write(doc)
+	}
+
+	line, err := bson.MarshalExtJSON(doc, true, false)
+	if err != nil {
+		return 0, err
+	}
+
+	return w.Write(append(bytes.TrimSpace(line), '\n'))
+}
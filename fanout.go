@@ -0,0 +1,205 @@
+package mongorepo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// FanOutMode is how the copy of an entity reaches a WriteTarget.
+type FanOutMode int
+
+const (
+	FanOutTransaction FanOutMode = iota // The copy is written in the transaction of the primary write.
+	FanOutOutbox                        // An outbox record is written in the transaction, RelayOutbox writes the copy later.
+)
+
+// WriteTarget is a secondary collection receiving a copy of the entities written by Create, CreateMany, Update,
+// Upsert and ForceDelete, e.g. a slimmed projection in a search-optimized collection. The copies share the _id of
+// the entities; the partial writes (UpdateFields, UpdateMany, DeleteMany, FindOneAnd*) are not fanned out.
+type WriteTarget struct {
+	DbName         string     // The database of the target, default: the database of the repository
+	CollectionName string     // The target collection.
+	Fields         []string   // The fields in the entity struct copied, the ID always included, default: every field
+	Mode           FanOutMode // How the copy is written, default: FanOutTransaction
+}
+
+// outboxRecord is a pending write of a FanOutOutbox target, stored in Config.OutboxCollection.
+type outboxRecord struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	Collection string             `bson:"collection"`         // The collection of the repository.
+	Target     outboxTarget       `bson:"target"`             // The target of the copy.
+	DocumentID any                `bson:"document_id"`        // The _id of the copy.
+	Document   bson.D             `bson:"document,omitempty"` // The copy, nil to delete it.
+	CreatedAt  time.Time          `bson:"created_at"`
+}
+
+// outboxTarget is the namespace of the copy of an outbox record.
+type outboxTarget struct {
+	DbName         string `bson:"db"`
+	CollectionName string `bson:"collection"`
+}
+
+// fanOut runs a write of entities and the writes of their copies to the WriteTargets in one transaction,
+// joining the transaction of the context when there is one.
+func (r *Repository[T]) fanOut(write func(repo *Repository[T]) error, remove bool, entities ...*T) error {
+	run := func(ctx context.Context) error {
+		repo := r.WithContext(ctx)
+		repo.config.WriteTargets = nil
+
+		if err := write(repo); err != nil {
+			return err
+		}
+
+		return r.WithContext(ctx).writeCopies(remove, entities)
+	}
+
+	if inTransaction(r.config.Context) {
+		return run(r.config.Context)
+	}
+
+	return r.WithTransaction(r.config.Context, run)
+}
+
+// writeCopies writes, or deletes when remove is set, the copies of entities to the WriteTargets, or their
+// outbox records for the FanOutOutbox targets.
+func (r *Repository[T]) writeCopies(remove bool, entities []*T) error {
+	for _, target := range r.config.WriteTargets {
+		for _, entity := range entities {
+			id := NewEntityReflection(r.config, entity).GetID()
+
+			var document bson.D
+			if !remove {
+				var err error
+				if document, err = r.projectCopy(target, entity); err != nil {
+					return err
+				}
+			}
+
+			if target.Mode == FanOutOutbox {
+				record := outboxRecord{
+					ID:         primitive.NewObjectID(),
+					Collection: r.config.CollectionName,
+					Target:     outboxTarget{DbName: r.targetDbName(target), CollectionName: target.CollectionName},
+					DocumentID: id,
+					Document:   document,
+					CreatedAt:  time.Now(),
+				}
+				if _, err := r.outbox().InsertOne(r.config.Context, record); err != nil {
+					return err
+				}
+				continue
+			}
+
+			collection := r.config.MongoClient.Database(r.targetDbName(target)).Collection(target.CollectionName)
+			if err := applyCopy(r.config.Context, collection, id, document); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// RelayOutbox writes the copies of the outbox records of the repository collection to their FanOutOutbox targets,
+// oldest first, removing each record once applied. Run it periodically from a single worker per collection, so the
+// copies are applied in order; a failed record stops the relay and is retried on the next run.
+//
+// Returns:
+//   - The number of records applied.
+//   - An error if a record cannot be read, applied or removed.
+func (r *Repository[T]) RelayOutbox() (int, error) {
+	ctx := r.config.Context
+
+	cursor, err := r.outbox().Find(ctx, bson.M{"collection": r.config.CollectionName}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	relayed := 0
+	for cursor.Next(ctx) {
+		var record outboxRecord
+		if err := cursor.Decode(&record); err != nil {
+			return relayed, err
+		}
+
+		collection := r.config.MongoClient.Database(record.Target.DbName).Collection(record.Target.CollectionName)
+		if err := applyCopy(ctx, collection, record.DocumentID, record.Document); err != nil {
+			return relayed, err
+		}
+
+		if _, err := r.outbox().DeleteOne(ctx, bson.M{"_id": record.ID}); err != nil {
+			return relayed, err
+		}
+		relayed++
+	}
+
+	return relayed, cursor.Err()
+}
+
+// projectCopy encodes the copy of an entity for a target: its _id and the target fields.
+func (r *Repository[T]) projectCopy(target WriteTarget, entity *T) (bson.D, error) {
+	raw, err := bson.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	elements, err := rawElements(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(target.Fields) == 0 {
+		return elements, nil
+	}
+
+	keys := map[string]bool{"_id": true}
+	for _, field := range target.Fields {
+		keys[r.fieldKey(field)] = true
+	}
+
+	document := bson.D{}
+	for _, element := range elements {
+		if keys[element.Key] {
+			document = append(document, element)
+		}
+	}
+
+	return document, nil
+}
+
+// targetDbName returns the database of a target.
+func (r *Repository[T]) targetDbName(target WriteTarget) string {
+	if target.DbName != "" {
+		return target.DbName
+	}
+
+	return r.config.DbName
+}
+
+// outbox returns the collection storing the outbox records.
+func (r *Repository[T]) outbox() *mongo.Collection {
+	return r.Database().Collection(r.config.OutboxCollection)
+}
+
+// applyCopy replaces the copy of a document, inserting it if needed, or deletes it when document is nil.
+func applyCopy(ctx context.Context, collection *mongo.Collection, id any, document bson.D) error {
+	if document == nil {
+		_, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+		return err
+	}
+
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": id}, document, options.Replace().SetUpsert(true))
+	return err
+}
+
+// inTransaction reports whether ctx carries a session running a transaction, which writes must join.
+func inTransaction(ctx context.Context) bool {
+	session, ok := mongo.SessionFromContext(ctx).(mongo.XSession)
+	return ok && session.ClientSession().TransactionRunning()
+}
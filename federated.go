@@ -0,0 +1,150 @@
+package mongorepo
+
+import (
+	"fmt"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/sync/errgroup"
+)
+
+// FederatedQuery is the query run by a FederatedSearch on each of its repositories.
+type FederatedQuery struct {
+	Text   string // The $text search string (requires a text index on every collection), default: none
+	Filter bson.M // Additional search criteria, applied to every collection, default: none
+	Limit  int64  // The maximum number of results per repository, default: 0 (no limit)
+}
+
+// SearchHit is a result of a FederatedSearch.
+type SearchHit struct {
+	Kind     string  // The kind under which the repository of the document was registered, e.g. "user".
+	Document any     // A pointer to the decoded entity, e.g. *User; see HitAs.
+	Score    float64 // The text score of the document, 0 for filter-only queries.
+}
+
+// FederatedSearch runs a query across several repositories concurrently and merges their results,
+// e.g. for a global search box spanning users, orders and products.
+type FederatedSearch struct {
+	sources []federatedSource
+}
+
+// federatedSource is a registered repository, its entity type erased behind its search function.
+type federatedSource struct {
+	kind   string
+	search func(query FederatedQuery) ([]SearchHit, error)
+}
+
+// NewFederatedSearch creates an empty FederatedSearch; add repositories with RegisterSearch.
+//
+// Returns:
+//   - A pointer to a FederatedSearch.
+func NewFederatedSearch() *FederatedSearch {
+	return &FederatedSearch{}
+}
+
+// RegisterSearch adds a repository to a FederatedSearch under a kind identifying its documents in the results.
+//
+// Parameters:
+//   - fs: The federated search.
+//   - kind: The kind of the documents of the repository, e.g. "user".
+//   - repo: The repository to search.
+//
+// Panics:
+//   - If kind is empty or already registered.
+func RegisterSearch[T any](fs *FederatedSearch, kind string, repo *Repository[T]) {
+	if kind == "" {
+		panic("Configuration error: The kind of a federated search repository is not set.")
+	}
+
+	for _, source := range fs.sources {
+		if source.kind == kind {
+			panic(fmt.Sprintf("Configuration error: The federated search kind %q is already registered.", kind))
+		}
+	}
+
+	fs.sources = append(fs.sources, federatedSource{
+		kind: kind,
+		search: func(query FederatedQuery) ([]SearchHit, error) {
+			if query.Text != "" {
+				results, err := repo.TextSearch(query.Text, query.Filter, &SearchOptions{Limit: query.Limit})
+				if err != nil {
+					return nil, err
+				}
+
+				hits := make([]SearchHit, len(results))
+				for i, result := range results {
+					hits[i] = SearchHit{Kind: kind, Document: result.Entity, Score: result.Score}
+				}
+				return hits, nil
+			}
+
+			opts := options.Find()
+			if query.Limit > 0 {
+				opts.SetLimit(query.Limit)
+			}
+
+			filter := query.Filter
+			if filter == nil {
+				filter = bson.M{}
+			}
+
+			entities, err := repo.Find(filter, opts)
+			if err != nil {
+				return nil, err
+			}
+
+			hits := make([]SearchHit, len(entities))
+			for i, entity := range entities {
+				hits[i] = SearchHit{Kind: kind, Document: entity}
+			}
+			return hits, nil
+		},
+	})
+}
+
+// Search runs the query on every registered repository concurrently. Text queries are merged by descending
+// score; filter-only queries keep the registration order of the repositories.
+//
+// Parameters:
+//   - query: The query run on every repository.
+//
+// Returns:
+//   - The merged results.
+//   - The first error met by a repository, in which case no result is returned.
+func (fs *FederatedSearch) Search(query FederatedQuery) ([]SearchHit, error) {
+	partials := make([][]SearchHit, len(fs.sources))
+
+	var group errgroup.Group
+	for i, source := range fs.sources {
+		group.Go(func() error {
+			hits, err := source.search(query)
+			partials[i] = hits
+			return err
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	var hits []SearchHit
+	for _, partial := range partials {
+		hits = append(hits, partial...)
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	return hits, nil
+}
+
+// HitAs returns the document of a search hit as an entity of type `T`.
+//
+// Parameters:
+//   - hit: The search hit.
+//
+// Returns:
+//   - A pointer to the entity, and true if the document is of type `T`.
+func HitAs[T any](hit SearchHit) (*T, bool) {
+	entity, ok := hit.Document.(*T)
+	return entity, ok
+}
@@ -0,0 +1,112 @@
+package mongorepo
+
+import (
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GraphLookupOptions describes a $graphLookup traversal. Field names are BSON keys.
+type GraphLookupOptions struct {
+	StartWith               bson.M // The filter selecting the documents the traversal starts from.
+	ConnectFromField        string // The key whose value is followed to reach the next documents, e.g. "parent_id" or "follows".
+	ConnectToField          string // The key matched against the ConnectFromField values, default: "_id"
+	MaxDepth                *int64 // The maximum recursion depth (0 only reaches direct connections), default: nil (unlimited)
+	RestrictSearchWithMatch bson.M // An additional filter the traversed documents must match, default: nil
+	From                    string // The collection to traverse, default: the repository collection
+}
+
+// GraphNode is a document reached by a graph traversal along with its distance from the start document.
+type GraphNode[T any] struct {
+	Entity *T
+	Depth  int64 // 0 for documents directly connected to the start document.
+}
+
+// GraphResult holds a start document and the nodes reached from it, ordered by depth.
+type GraphResult[T any] struct {
+	Start *T
+	Nodes []GraphNode[T]
+}
+
+// GraphLookup runs a recursive $graphLookup traversal from every document matching opts.StartWith.
+// When traversing the repository collection, soft-deleted documents are not traversed. The start documents and
+// the nodes are hydrated and redacted like the entities of Find.
+//
+// Parameters:
+//   - opts: The traversal description.
+//
+// Returns:
+//   - One GraphResult per start document.
+//   - An error if the operation fails.
+func (r *Repository[T]) GraphLookup(opts GraphLookupOptions) ([]GraphResult[T], error) {
+	if opts.ConnectToField == "" {
+		opts.ConnectToField = "_id"
+	}
+
+	restrict := opts.RestrictSearchWithMatch
+	if opts.From == "" {
+		opts.From = r.config.CollectionName
+		restrict = r.scopeFilter(restrict)
+	}
+
+	lookup := bson.M{
+		"from":             opts.From,
+		"startWith":        "$" + opts.ConnectFromField,
+		"connectFromField": opts.ConnectFromField,
+		"connectToField":   opts.ConnectToField,
+		"as":               "_nodes",
+		"depthField":       "_depth",
+	}
+	if opts.MaxDepth != nil {
+		lookup["maxDepth"] = *opts.MaxDepth
+	}
+	if len(restrict) > 0 {
+		lookup["restrictSearchWithMatch"] = restrict
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: opts.StartWith}},
+		{{Key: "$graphLookup", Value: lookup}},
+	}
+
+	cursor, err := r.Aggregate(&pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(r.config.Context)
+
+	var results []GraphResult[T]
+	for cursor.Next(r.config.Context) {
+		start, err := r.decodeEntity(cursor.Current, "_nodes")
+		if err != nil {
+			return nil, err
+		}
+
+		result := GraphResult[T]{Start: start}
+
+		values, err := cursor.Current.Lookup("_nodes").Array().Values()
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			node, ok := value.DocumentOK()
+			if !ok {
+				continue
+			}
+
+			entity, err := r.decodeEntity(node, "_depth")
+			if err != nil {
+				return nil, err
+			}
+
+			depth := node.Lookup("_depth").AsInt64()
+			result.Nodes = append(result.Nodes, GraphNode[T]{Entity: entity, Depth: depth})
+		}
+
+		sort.SliceStable(result.Nodes, func(i, j int) bool { return result.Nodes[i].Depth < result.Nodes[j].Depth })
+		results = append(results, result)
+	}
+
+	return results, cursor.Err()
+}
@@ -0,0 +1,110 @@
+package mongorepo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// canonicalHash computes a stable hash of query values (filters, pipelines, options).
+// Maps are hashed with sorted keys, so two equal bson.M filters always produce the same hash,
+// while ordered documents (bson.D) keep their order since it is meaningful (e.g. sort specifications).
+//
+// Parameters:
+//   - values: The values to hash.
+//
+// Returns:
+//   - The hex encoded SHA-256 of the canonical representation.
+func canonicalHash(values ...any) string {
+	doc := make(bson.D, 0, len(values))
+	for _, value := range values {
+		doc = append(doc, bson.E{Key: "v", Value: canonical(reflect.ValueOf(value))})
+	}
+
+	raw, err := bson.MarshalExtJSON(doc, true, false)
+	if err != nil {
+		panic("Error: cannot compute the canonical hash of a query: " + err.Error())
+	}
+
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// canonical rewrites a value into an order-stable BSON representation.
+func canonical(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return canonical(v.Elem())
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+
+		doc := make(bson.D, 0, len(keys))
+		for _, key := range keys {
+			doc = append(doc, bson.E{Key: fmt.Sprint(key), Value: canonical(v.MapIndex(key))})
+		}
+		return doc
+	case reflect.Slice, reflect.Array:
+		if d, ok := v.Interface().(bson.D); ok {
+			doc := make(bson.D, 0, len(d))
+			for _, e := range d {
+				doc = append(doc, bson.E{Key: e.Key, Value: canonical(reflect.ValueOf(e.Value))})
+			}
+			return doc
+		}
+
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface() // []byte, primitive.ObjectID
+		}
+
+		list := make(bson.A, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			list = append(list, canonical(v.Index(i)))
+		}
+		return list
+	case reflect.Struct:
+		if isBSONLeaf(v) {
+			return v.Interface()
+		}
+
+		doc := bson.D{}
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if value := canonical(v.Field(i)); value != nil {
+				doc = append(doc, bson.E{Key: field.Name, Value: value})
+			}
+		}
+		return doc
+	default:
+		return v.Interface()
+	}
+}
+
+// isBSONLeaf reports whether a struct value has its own BSON representation and must not be walked field by field.
+func isBSONLeaf(v reflect.Value) bool {
+	if v.Type() == reflect.TypeOf(time.Time{}) || v.Type().PkgPath() == "go.mongodb.org/mongo-driver/bson/primitive" {
+		return true
+	}
+
+	switch v.Interface().(type) {
+	case bson.Marshaler, bson.ValueMarshaler:
+		return true
+	}
+
+	return false
+}
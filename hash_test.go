@@ -0,0 +1,54 @@
+package mongorepo
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCanonicalHash(t *testing.T) {
+	id := primitive.NewObjectID()
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	name := "jon"
+
+	type query struct {
+		Name   string
+		hidden string
+	}
+
+	tests := []struct {
+		name  string
+		a, b  []any
+		equal bool
+	}{
+		{name: "same map", a: []any{bson.M{"a": 1, "b": 2}}, b: []any{bson.M{"b": 2, "a": 1}}, equal: true},
+		{name: "map and sorted document", a: []any{bson.M{"a": 1, "b": 2}}, b: []any{bson.D{{Key: "a", Value: 1}, {Key: "b", Value: 2}}}, equal: true},
+		{name: "nested maps", a: []any{bson.M{"a": bson.M{"x": 1, "y": bson.A{bson.M{"p": 1, "q": 2}}}}}, b: []any{bson.M{"a": bson.M{"y": bson.A{bson.M{"q": 2, "p": 1}}, "x": 1}}}, equal: true},
+		{name: "document order matters", a: []any{bson.D{{Key: "a", Value: 1}, {Key: "b", Value: 2}}}, b: []any{bson.D{{Key: "b", Value: 2}, {Key: "a", Value: 1}}}},
+		{name: "array order matters", a: []any{bson.A{1, 2}}, b: []any{bson.A{2, 1}}},
+		{name: "different values", a: []any{bson.M{"a": 1}}, b: []any{bson.M{"a": 2}}},
+		{name: "different keys", a: []any{bson.M{"a": 1}}, b: []any{bson.M{"b": 1}}},
+		{name: "value order", a: []any{"Find", bson.M{}}, b: []any{bson.M{}, "Find"}},
+		{name: "pointers", a: []any{&name}, b: []any{name}, equal: true},
+		{name: "nil values", a: []any{nil, (*string)(nil)}, b: []any{nil, nil}, equal: true},
+		{name: "object ids", a: []any{bson.M{"_id": id}}, b: []any{bson.M{"_id": id}}, equal: true},
+		{name: "other object ids", a: []any{bson.M{"_id": id}}, b: []any{bson.M{"_id": primitive.NewObjectID()}}},
+		{name: "times", a: []any{at}, b: []any{at.Add(time.Second)}},
+		{name: "unexported fields", a: []any{query{Name: "a", hidden: "x"}}, b: []any{query{Name: "a", hidden: "y"}}, equal: true},
+		{name: "exported fields", a: []any{query{Name: "a"}}, b: []any{query{Name: "b"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a, b := canonicalHash(test.a...), canonicalHash(test.b...)
+			if (a == b) != test.equal {
+				t.Errorf("got equal hashes %v, expected %v", a == b, test.equal)
+			}
+			if a != canonicalHash(test.a...) {
+				t.Error("the hash is not stable")
+			}
+		})
+	}
+}
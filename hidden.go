@@ -0,0 +1,166 @@
+package mongorepo
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// regressionFactor is how many times slower than its baseline a query shape must run to be reported as regressed.
+const regressionFactor = 2
+
+// HiddenIndexReport lists the queries that regressed while an index was hidden, see HiddenIndexRegressions.
+type HiddenIndexReport struct {
+	Since       time.Time         // When the index was hidden.
+	Profiled    int               // The number of profiled operations on the collection since then.
+	Regressions []QueryRegression // The query shapes that regressed, the slowest first.
+}
+
+// QueryRegression is a query shape running worse since an index was hidden.
+type QueryRegression struct {
+	Shape          string  // The operation and its filter or pipeline with the values replaced by "?", e.g. `find {"filter":{"email":"?"}}`.
+	Operations     int     // The number of profiled operations of the shape since the index was hidden.
+	AvgMillis      float64 // Their average duration.
+	BaselineMillis float64 // The average duration of the shape before the index was hidden, 0 if it was not profiled.
+	PlanSummary    string  // The plan of the most recent operation, e.g. "COLLSCAN".
+	DocsExamined   int64   // The most documents examined by one of the operations.
+}
+
+// profileShapeKeys are the fields of a profiled command holding its query, in lookup order.
+var profileShapeKeys = []string{"filter", "q", "query", "pipeline"}
+
+// HideIndex hides an index from the query planner while it keeps being maintained, so its removal can be tried
+// without the cost of rebuilding it: if queries regress (see HiddenIndexRegressions), UnhideIndex restores it
+// instantly, otherwise it can be dropped. Requires MongoDB 4.4+.
+//
+// Parameters:
+//   - name: The name of the index, e.g. "status_1_created_at_-1".
+//
+// Returns:
+//   - ErrUnsupported if the server is older than 4.4.
+//   - An error if the index does not exist or the command fails.
+func (r *Repository[T]) HideIndex(name string) error {
+	return r.setIndexHidden(name, true)
+}
+
+// UnhideIndex makes an index hidden by HideIndex visible to the query planner again.
+//
+// Parameters:
+//   - name: The name of the index.
+//
+// Returns:
+//   - ErrUnsupported if the server is older than 4.4.
+//   - An error if the index does not exist or the command fails.
+func (r *Repository[T]) UnhideIndex(name string) error {
+	return r.setIndexHidden(name, false)
+}
+
+// HiddenIndexRegressions compares the queries profiled since an index was hidden with the ones profiled during the
+// same length of time before, grouped by query shape: a shape regressed when it runs a collection scan, or runs
+// regressionFactor times slower than before. Enable the profiler (EnableProfiling) before hiding the index, so a
+// baseline is recorded.
+//
+// Parameters:
+//   - since: When the index was hidden.
+//
+// Returns:
+//   - A pointer to a HiddenIndexReport.
+//   - An error if the profiler entries cannot be read.
+func (r *Repository[T]) HiddenIndexRegressions(since time.Time) (*HiddenIndexReport, error) {
+	window := time.Since(since)
+	entries, err := r.GetProfilerEntries(bson.M{"ts": bson.M{"$gte": since.Add(-window)}})
+	if err != nil {
+		return nil, err
+	}
+
+	type shapeStats struct {
+		regression    *QueryRegression
+		before, after float64
+		baseline      int
+	}
+
+	report := &HiddenIndexReport{Since: since, Regressions: []QueryRegression{}}
+	shapes := map[string]*shapeStats{}
+	order := []string{}
+	for _, entry := range entries {
+		shape := profileShape(entry)
+		if shape == "" {
+			continue
+		}
+
+		stats := shapes[shape]
+		if stats == nil {
+			stats = &shapeStats{regression: &QueryRegression{Shape: shape}}
+			shapes[shape] = stats
+			order = append(order, shape)
+		}
+
+		if entry.Timestamp.Before(since) {
+			stats.before += float64(entry.Millis)
+			stats.baseline++
+			continue
+		}
+
+		report.Profiled++
+		regression := stats.regression
+		if regression.Operations == 0 {
+			regression.PlanSummary = entry.PlanSummary // entries are sorted most recent first
+		}
+		regression.Operations++
+		regression.DocsExamined = max(regression.DocsExamined, entry.DocsExamined)
+		stats.after += float64(entry.Millis)
+	}
+
+	for _, shape := range order {
+		stats := shapes[shape]
+		regression := stats.regression
+		if regression.Operations == 0 {
+			continue
+		}
+
+		regression.AvgMillis = stats.after / float64(regression.Operations)
+		if stats.baseline > 0 {
+			regression.BaselineMillis = stats.before / float64(stats.baseline)
+		}
+
+		slower := stats.baseline > 0 && regression.AvgMillis > regressionFactor*max(regression.BaselineMillis, 1)
+		if slower || strings.HasPrefix(regression.PlanSummary, "COLLSCAN") {
+			report.Regressions = append(report.Regressions, *regression)
+		}
+	}
+
+	slices.SortStableFunc(report.Regressions, func(a, b QueryRegression) int {
+		return cmp.Compare(b.AvgMillis, a.AvgMillis)
+	})
+
+	return report, nil
+}
+
+// setIndexHidden hides or unhides an index with collMod.
+func (r *Repository[T]) setIndexHidden(name string, hidden bool) error {
+	if err := r.requireCapability("hidden indexes", func(c *Capabilities) bool { return c.AtLeast(4, 4, 0) }); err != nil {
+		return err
+	}
+
+	command := bson.D{
+		{Key: "collMod", Value: r.config.CollectionName},
+		{Key: "index", Value: bson.M{"name": name, "hidden": hidden}},
+	}
+
+	return r.Database().RunCommand(r.config.Context, command).Err()
+}
+
+// profileShape returns the query shape of a profiled operation, empty for the operations without a query
+// such as getMore.
+func profileShape(entry ProfileEntry) string {
+	for _, key := range profileShapeKeys {
+		if value, err := entry.Command.LookupErr(key); err == nil {
+			return entry.Op + " " + filterSummary(bson.D{{Key: key, Value: value}})
+		}
+	}
+
+	return ""
+}
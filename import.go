@@ -0,0 +1,260 @@
+package mongorepo
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ImportProgress reports the progress of ImportFrom.
+type ImportProgress struct {
+	Read      int64     `bson:"read"`       // The number of items returned by the iterator, including the ones of previous runs.
+	Imported  int64     `bson:"imported"`   // The number of documents inserted.
+	Skipped   int64     `bson:"skipped"`    // The number of items dropped by the transform or rejected as duplicates.
+	StartedAt time.Time `bson:"started_at"` // The start of the first run of a resumable import.
+}
+
+// ImportOptions holds the optional settings of ImportFrom.
+type ImportOptions struct {
+	// Checkpoint names the import to make it resumable: the progress is stored in Config.CheckpointsCollection
+	// after each batch, and a restarted import skips the items already committed. default: disabled
+	Checkpoint string
+	// OnProgress is called after each batch, default: nil
+	OnProgress func(progress ImportProgress)
+}
+
+// ImportFrom streams entities from an external source (CSV readers, API pagers...) into the collection,
+// inserting them in unordered batches. Entities are prepared like CreateMany prepares them: the ID (when zero),
+// CreatedAt, the dedupe key, CopyFieldsOnWrite and the size guard, and the region is checked.
+// Duplicate-key errors are counted as skipped. With WriteTargets, each batch and its copies are written in one
+// transaction; since a duplicate key aborts the transaction, a batch holding duplicates is then imported entity
+// by entity, or fails when the context carries a transaction of the caller.
+//
+// When resumed from a checkpoint, the iterator must yield the items in the same order: the items
+// already read by previous runs are consumed without being imported. The checkpoint is removed on completion.
+// A run interrupted between an insert and its checkpoint imports that batch again on resume. With ObjectID IDs
+// (and neither IDGenerator nor IDSequence), the zero IDs of a resumable import are derived from the checkpoint name
+// and the offset of the item in the source, so the documents already inserted are rejected as duplicates.
+// Other IDs are generated anew on every run: set them from a natural key of the source, or rely on a unique
+// index such as the one of DedupeKeyField, to keep the re-imported batch from inserting duplicates.
+//
+// Parameters:
+//   - iterator: Returns the next item, false once exhausted, or an error that aborts the import.
+//   - batchSize: The number of documents per insert, default: 500 when <= 0
+//   - transform: Maps each item before insertion, returning nil drops it; may be nil.
+//   - opts: Optional ImportOptions (checkpoint, progress callback).
+//
+// Returns:
+//   - The progress of the import, also on failure.
+//   - An error if the iterator, an insert or the checkpoint fails.
+func (r *Repository[T]) ImportFrom(iterator func() (*T, bool, error), batchSize int, transform func(*T) *T, opts ...*ImportOptions) (*ImportProgress, error) {
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	settings := ImportOptions{}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if opt.Checkpoint != "" {
+			settings.Checkpoint = opt.Checkpoint
+		}
+		if opt.OnProgress != nil {
+			settings.OnProgress = opt.OnProgress
+		}
+	}
+
+	progress := &ImportProgress{}
+	var resumeAt int64
+	if settings.Checkpoint != "" {
+		found, err := r.loadCheckpoint(settings.Checkpoint, progress)
+		if err != nil {
+			return progress, err
+		}
+		// saved before the first batch, so an interrupted first run derives the same IDs on resume
+		if !found {
+			progress.StartedAt = time.Now()
+			if err := r.saveCheckpoint(settings.Checkpoint, progress); err != nil {
+				return progress, err
+			}
+		}
+		resumeAt = progress.Read
+	}
+	derived := settings.Checkpoint != "" && r.config.IDGenerator == nil && r.config.IDSequence == ""
+
+	var read int64
+	batch := make([]*T, 0, batchSize)
+	for {
+		entity, ok, err := iterator()
+		if err != nil {
+			return progress, err
+		}
+
+		if ok {
+			read++
+			if read <= resumeAt {
+				continue
+			}
+
+			if transform != nil {
+				entity = transform(entity)
+			}
+			if entity == nil {
+				progress.Skipped++
+			} else {
+				er := NewEntityReflection(r.config, entity)
+				switch {
+				case er.HasID():
+				case derived && er.idField().Type() == reflect.TypeOf(primitive.ObjectID{}):
+					er.idField().Set(reflect.ValueOf(importID(settings.Checkpoint, progress.StartedAt, read)))
+				default:
+					if err := r.assignIDs(er); err != nil {
+						return progress, err
+					}
+				}
+				batch = append(batch, entity)
+			}
+		}
+
+		if len(batch) == batchSize || (!ok && read > resumeAt) {
+			if err := r.importBatch(batch, progress); err != nil {
+				return progress, err
+			}
+			progress.Read = read
+			batch = batch[:0]
+
+			if settings.Checkpoint != "" {
+				if err := r.saveCheckpoint(settings.Checkpoint, progress); err != nil {
+					return progress, err
+				}
+			}
+			if settings.OnProgress != nil {
+				settings.OnProgress(*progress)
+			}
+		}
+
+		if !ok {
+			break
+		}
+	}
+
+	if settings.Checkpoint != "" {
+		return progress, r.clearCheckpoint(settings.Checkpoint)
+	}
+
+	return progress, nil
+}
+
+// importID derives the ObjectID of the item at an offset of a resumable import: the start of the import
+// as timestamp, followed by a hash of the checkpoint name and the offset.
+func importID(checkpoint string, startedAt time.Time, offset int64) primitive.ObjectID {
+	sum := sha256.Sum256([]byte(checkpoint + "\x00" + strconv.FormatInt(offset, 10)))
+
+	var id primitive.ObjectID
+	binary.BigEndian.PutUint32(id[:4], uint32(startedAt.Unix()))
+	copy(id[4:], sum[:8])
+
+	return id
+}
+
+// importBatch inserts a batch of entities, counting duplicate-key failures as skipped.
+func (r *Repository[T]) importBatch(batch []*T, progress *ImportProgress) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	if err := r.guardRegion(batch...); err != nil {
+		return err
+	}
+
+	if len(r.config.WriteTargets) == 0 {
+		inserted, err := r.insertImported(batch)
+		if err != nil && !onlyDuplicates(err) {
+			return classify(err)
+		}
+		progress.Imported += int64(inserted)
+		progress.Skipped += int64(len(batch) - inserted)
+		return nil
+	}
+
+	err := r.fanOut(func(repo *Repository[T]) error {
+		_, err := repo.insertImported(batch)
+		return err
+	}, false, batch...)
+	switch {
+	case err == nil:
+		progress.Imported += int64(len(batch))
+		return nil
+	case !onlyDuplicates(err) || inTransaction(r.config.Context):
+		return classify(err)
+	case len(batch) == 1:
+		progress.Skipped++
+		return nil
+	}
+
+	// the duplicates aborted the transaction of the whole batch
+	for _, entity := range batch {
+		if err := r.importBatch([]*T{entity}, progress); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// insertImported prepares the entities of a batch like CreateMany and inserts them unordered, returning the
+// number of inserted documents; the raw driver error is returned so the duplicates can be told apart.
+func (r *Repository[T]) insertImported(batch []*T) (int, error) {
+	if err := r.copyFieldsOnWrite(batch...); err != nil {
+		return 0, err
+	}
+
+	documents := make([]any, 0, len(batch))
+	for _, entity := range batch {
+		r.prepareInsert(NewEntityReflection(r.config, entity))
+
+		document, err := r.guardSize(entity)
+		if err != nil {
+			return 0, err
+		}
+		documents = append(documents, document)
+	}
+
+	start := time.Now()
+	result, err := r.Collection().InsertMany(r.config.Context, documents, r.insertManyComment("ImportFrom"), options.InsertMany().SetOrdered(false))
+	r.observe("ImportFrom", nil, start, 0, err)
+
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) {
+		return len(batch) - len(bulkErr.WriteErrors), err
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return len(result.InsertedIDs), nil
+}
+
+// onlyDuplicates reports whether every write error of a bulk insert is a duplicate-key error.
+func onlyDuplicates(err error) bool {
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil || len(bulkErr.WriteErrors) == 0 {
+		return false
+	}
+
+	for _, writeErr := range bulkErr.WriteErrors {
+		if !mongo.IsDuplicateKeyError(writeErr) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,70 @@
+package mongorepo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+type importedContact struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	Email     string             `bson:"email"`
+	Notes     string             `bson:"notes"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+// contactsOf returns an ImportFrom iterator over contacts.
+func contactsOf(contacts ...*importedContact) func() (*importedContact, bool, error) {
+	return func() (*importedContact, bool, error) {
+		if len(contacts) == 0 {
+			return nil, false, nil
+		}
+		contact := contacts[0]
+		contacts = contacts[1:]
+		return contact, true, nil
+	}
+}
+
+func TestImportBatch(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("duplicate", func(mt *mtest.T) {
+		repo := New[importedContact](&Config{MongoClient: mt.Client, DbName: "crm", CreatedAtField: "CreatedAt", ServiceName: "crm"})
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 1, Code: 11000, Message: "E11000 duplicate key error"}))
+
+		progress, err := repo.ImportFrom(contactsOf(&importedContact{Email: "a@example.com"}, &importedContact{Email: "b@example.com"}), 0, nil)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if progress.Imported != 1 || progress.Skipped != 1 {
+			mt.Errorf("got %d imported and %d skipped, expected one of each", progress.Imported, progress.Skipped)
+		}
+
+		insert := mt.GetStartedEvent().Command
+		if ordered, _ := insert.Lookup("ordered").BooleanOK(); ordered {
+			mt.Error("the batch was inserted ordered")
+		}
+		if comment, _ := insert.Lookup("comment").StringValueOK(); !strings.Contains(comment, "ImportFrom") {
+			mt.Errorf("got the comment %q, expected the one of ImportFrom", comment)
+		}
+		if _, err := insert.LookupErr("documents", "0", "created_at"); err != nil {
+			mt.Error("the imported document has no created_at")
+		}
+	})
+
+	mt.Run("oversized", func(mt *mtest.T) {
+		repo := New[importedContact](&Config{MongoClient: mt.Client, DbName: "crm", OversizeStrategy: OversizeReject, MaxDocumentSize: 1024})
+
+		_, err := repo.ImportFrom(contactsOf(&importedContact{Email: "a@example.com", Notes: strings.Repeat("x", 2048)}), 0, nil)
+		if !errors.Is(err, ErrDocumentTooLarge) {
+			mt.Errorf("got %v, expected ErrDocumentTooLarge", err)
+		}
+		if event := mt.GetStartedEvent(); event != nil {
+			mt.Errorf("the oversized batch reached the server: %s", event.CommandName)
+		}
+	})
+}
@@ -0,0 +1,573 @@
+package mongorepo
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// textIndexGroup groups the unnamed text fields, as a collection holds at most one text index.
+const textIndexGroup = "\x00text"
+
+// indexSpec is an index declared by `index` struct tags.
+type indexSpec struct {
+	name    string
+	keys    bson.D
+	unique  bool
+	sparse  bool
+	partial bson.D // The partialFilterExpression, nil for a full index.
+}
+
+// TaggedIndexes returns the indexes declared by the `index` struct tags of `T`. The tag holds comma-separated
+// options, an empty tag declares an ascending index on the field:
+//
+//   - asc, desc, text, 2dsphere, hashed: the index type of the field, default: asc
+//   - unique, sparse: the index options
+//   - partial: only index the documents holding the field, a partialFilterExpression {<field>: {$exists: true}}
+//     that, unlike sparse, lets unique compound indexes skip the documents missing one of their fields
+//   - name=<index>: the name of the index; the fields sharing it form a compound index in declaration order
+//
+// Every unnamed text field belongs to one text index, nested and inline structs are looked into:
+//
+//	type Order struct {
+//		Number     string             `bson:"number" index:"unique"`
+//		CustomerID primitive.ObjectID `bson:"customer_id" index:"name=customer_date"`
+//		CreatedAt  time.Time          `bson:"created_at" index:"name=customer_date,desc"`
+//		Notes      string             `bson:"notes" index:"text"`
+//	}
+//
+// Returns:
+//   - The index models, named explicitly.
+//
+// Panics:
+//   - If a tag holds an unknown option, or an index is both sparse and partial.
+func (r *Repository[T]) TaggedIndexes() []mongo.IndexModel {
+	specs := []*indexSpec{}
+	groups := map[string]*indexSpec{}
+	collectIndexSpecs(reflect.TypeOf((*T)(nil)).Elem(), "", groups, &specs, map[reflect.Type]bool{})
+
+	models := make([]mongo.IndexModel, 0, len(specs))
+	for _, spec := range specs {
+		name := spec.name
+		if name == "" {
+			name = indexName(spec.keys)
+		}
+
+		opts := options.Index().SetName(name)
+		if spec.unique {
+			opts.SetUnique(true)
+		}
+		if spec.sparse {
+			opts.SetSparse(true)
+		}
+		if spec.partial != nil {
+			if spec.sparse {
+				panic(fmt.Sprintf("Configuration error: The index %s cannot be both sparse and partial.", name))
+			}
+			opts.SetPartialFilterExpression(spec.partial)
+		}
+
+		models = append(models, mongo.IndexModel{Keys: spec.keys, Options: opts})
+	}
+
+	return models
+}
+
+// EnsureIndexes creates the indexes declared by the `index` struct tags of `T` (see TaggedIndexes), the TTL index
+// of the ExpireAtField and the given indexes (e.g. built with Index) that do not exist yet, e.g. at startup. Existing indexes are matched by name and left
+// untouched, except the TTL index whose expiration is updated in place when ExpireAfter changed.
+//
+// Parameters:
+//   - indexes: Additional indexes, named explicitly.
+//
+// Returns:
+//   - The names of the created indexes.
+//   - An error if the indexes cannot be listed, created or updated.
+//
+// Panics:
+//   - If a tag holds an unknown option, an index is both sparse and partial, or an additional index has no name.
+func (r *Repository[T]) EnsureIndexes(indexes ...mongo.IndexModel) ([]string, error) {
+	ctx := r.config.Context
+
+	specs, err := r.Collection().Indexes().ListSpecifications(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := map[string]*mongo.IndexSpecification{}
+	for _, spec := range specs {
+		existing[spec.Name] = spec
+	}
+
+	models := r.TaggedIndexes()
+	for _, index := range indexes {
+		if index.Options == nil || index.Options.Name == nil {
+			panic("Configuration error: The indexes given to EnsureIndexes must be named.")
+		}
+		models = append(models, index)
+	}
+	if ttl := r.ttlIndex(); ttl != nil {
+		models = slices.DeleteFunc(models, func(model mongo.IndexModel) bool {
+			return *model.Options.Name == *ttl.Options.Name
+		})
+		models = append(models, *ttl)
+
+		spec := existing[*ttl.Options.Name]
+		if spec != nil && (spec.ExpireAfterSeconds == nil || *spec.ExpireAfterSeconds != *ttl.Options.ExpireAfterSeconds) {
+			command := bson.D{
+				{Key: "collMod", Value: r.config.CollectionName},
+				{Key: "index", Value: bson.M{"name": spec.Name, "expireAfterSeconds": *ttl.Options.ExpireAfterSeconds}},
+			}
+			if err := r.Database().RunCommand(ctx, command).Err(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	missing := []mongo.IndexModel{}
+	for _, model := range models {
+		if existing[*model.Options.Name] == nil {
+			missing = append(missing, model)
+		}
+	}
+
+	if len(missing) == 0 {
+		return []string{}, nil
+	}
+
+	return r.Collection().Indexes().CreateMany(ctx, missing)
+}
+
+// ttlIndex returns the TTL index of the ExpireAtField, nil if it is not configured.
+func (r *Repository[T]) ttlIndex() *mongo.IndexModel {
+	if r.config.ExpireAtField == "" {
+		return nil
+	}
+
+	keys := bson.D{{Key: r.fieldKey(r.config.ExpireAtField), Value: 1}}
+	opts := options.Index().
+		SetName(indexName(keys)).
+		SetExpireAfterSeconds(int32(r.config.ExpireAfter.Seconds()))
+
+	return &mongo.IndexModel{Keys: keys, Options: opts}
+}
+
+// collectIndexSpecs adds the indexes declared by the fields of a struct type, prefixing their keys with prefix.
+func collectIndexSpecs(t reflect.Type, prefix string, groups map[string]*indexSpec, specs *[]*indexSpec, visiting map[reflect.Type]bool) {
+	if visiting[t] {
+		return
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, flags, _ := strings.Cut(field.Tag.Get("bson"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+
+		fieldType := derefType(field.Type)
+		if strings.Contains(flags, "inline") && fieldType.Kind() == reflect.Struct {
+			collectIndexSpecs(fieldType, prefix, groups, specs, visiting)
+			continue
+		}
+
+		key := prefix + bsonFieldName(t, field.Name)
+		if tag, ok := field.Tag.Lookup("index"); ok {
+			addIndexField(key, field.Name, tag, groups, specs)
+		}
+
+		if fieldType.Kind() == reflect.Slice || fieldType.Kind() == reflect.Array {
+			fieldType = derefType(fieldType.Elem())
+		}
+		if fieldType.Kind() == reflect.Struct && !customDecoded(fieldType) {
+			collectIndexSpecs(fieldType, key+".", groups, specs, visiting)
+		}
+	}
+}
+
+// addIndexField adds a field to the index its tag declares.
+func addIndexField(key, fieldName, tag string, groups map[string]*indexSpec, specs *[]*indexSpec) {
+	var value any = 1
+	var name string
+	unique, sparse, partial := false, false, false
+
+	for _, option := range strings.Split(tag, ",") {
+		option = strings.TrimSpace(option)
+		switch {
+		case option == "", option == "asc":
+		case option == "desc":
+			value = -1
+		case option == "text", option == "2dsphere", option == "hashed":
+			value = option
+		case option == "unique":
+			unique = true
+		case option == "sparse":
+			sparse = true
+		case option == "partial":
+			partial = true
+		case strings.HasPrefix(option, "name="):
+			name = strings.TrimPrefix(option, "name=")
+		default:
+			panic(fmt.Sprintf("Configuration error: Unknown index option %q on the field %s.", option, fieldName))
+		}
+	}
+
+	group := name
+	if group == "" && value == "text" {
+		group = textIndexGroup
+	}
+
+	spec := groups[group]
+	if spec == nil || group == "" {
+		spec = &indexSpec{name: name}
+		*specs = append(*specs, spec)
+		if group != "" {
+			groups[group] = spec
+		}
+	}
+
+	spec.keys = append(spec.keys, bson.E{Key: key, Value: value})
+	spec.unique = spec.unique || unique
+	spec.sparse = spec.sparse || sparse
+	if partial {
+		spec.partial = append(spec.partial, bson.E{Key: key, Value: bson.M{"$exists": true}})
+	}
+}
+
+// indexName returns the name MongoDB gives to an index by default, e.g. "customer_id_1_created_at_-1".
+func indexName(keys bson.D) string {
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s_%v", key.Key, key.Value))
+	}
+
+	return strings.Join(parts, "_")
+}
+
+// IndexBuilder builds an index of a repository, with the options the `index` tags cannot express such as a
+// partialFilterExpression or a wildcard projection, validated against the fields of the entity:
+//
+//	index := users.Index().Asc("Email").Unique().PartialFilter(bson.M{"status": "active"}).Build()
+//	_, err := users.EnsureIndexes(index)
+type IndexBuilder[T any] struct {
+	repo       *Repository[T]
+	keys       bson.D
+	name       string
+	unique     bool
+	sparse     bool
+	partial    any
+	projection bson.D // The wildcardProjection, nil for every field.
+}
+
+// Index starts building an index of the repository.
+//
+// Returns:
+//   - A pointer to an IndexBuilder.
+func (r *Repository[T]) Index() *IndexBuilder[T] {
+	return &IndexBuilder[T]{repo: r}
+}
+
+// Asc adds an ascending key to the index.
+//
+// Parameters:
+//   - field: The field in the entity struct.
+//
+// Returns:
+//   - The IndexBuilder, for chaining.
+func (b *IndexBuilder[T]) Asc(field string) *IndexBuilder[T] {
+	return b.Key(field, 1)
+}
+
+// Desc adds a descending key to the index.
+//
+// Parameters:
+//   - field: The field in the entity struct.
+//
+// Returns:
+//   - The IndexBuilder, for chaining.
+func (b *IndexBuilder[T]) Desc(field string) *IndexBuilder[T] {
+	return b.Key(field, -1)
+}
+
+// Key adds a key of any index type to the index.
+//
+// Parameters:
+//   - field: The field in the entity struct.
+//   - value: The index type of the key, e.g. 1, -1, "text", "2dsphere" or "hashed".
+//
+// Returns:
+//   - The IndexBuilder, for chaining.
+//
+// Panics:
+//   - If the field does not exist in the entity struct.
+func (b *IndexBuilder[T]) Key(field string, value any) *IndexBuilder[T] {
+	b.keys = append(b.keys, bson.E{Key: b.repo.fieldKey(field), Value: value})
+	return b
+}
+
+// Wildcard adds a wildcard key to the index, indexing every field under a field of the entity, e.g. the keys of an
+// attribute bag as "attributes.$**", or every field of the documents when field is empty ("$**"). Along with
+// regular keys, it declares a compound wildcard index (MongoDB 7.0+).
+//
+// Parameters:
+//   - field: The field in the entity struct, empty for the whole document.
+//
+// Returns:
+//   - The IndexBuilder, for chaining.
+//
+// Panics:
+//   - If the field does not exist in the entity struct.
+func (b *IndexBuilder[T]) Wildcard(field string) *IndexBuilder[T] {
+	key := "$**"
+	if field != "" {
+		key = b.repo.fieldKey(field) + ".$**"
+	}
+
+	b.keys = append(b.keys, bson.E{Key: key, Value: 1})
+	return b
+}
+
+// WildcardInclude restricts a whole-document wildcard key ("$**") to the fields under the given paths.
+//
+// Parameters:
+//   - paths: The BSON paths indexed, e.g. "attributes", "specs.size".
+//
+// Returns:
+//   - The IndexBuilder, for chaining.
+func (b *IndexBuilder[T]) WildcardInclude(paths ...string) *IndexBuilder[T] {
+	for _, path := range paths {
+		b.projection = append(b.projection, bson.E{Key: path, Value: 1})
+	}
+	return b
+}
+
+// WildcardExclude excludes the fields under the given paths from a whole-document wildcard key ("$**"), e.g. the
+// regular keys of a compound wildcard index, which MongoDB requires.
+//
+// Parameters:
+//   - paths: The BSON paths not indexed, e.g. "notes".
+//
+// Returns:
+//   - The IndexBuilder, for chaining.
+func (b *IndexBuilder[T]) WildcardExclude(paths ...string) *IndexBuilder[T] {
+	for _, path := range paths {
+		b.projection = append(b.projection, bson.E{Key: path, Value: 0})
+	}
+	return b
+}
+
+// Name names the index, default: the name MongoDB would give it, e.g. "email_1".
+//
+// Parameters:
+//   - name: The name of the index.
+//
+// Returns:
+//   - The IndexBuilder, for chaining.
+func (b *IndexBuilder[T]) Name(name string) *IndexBuilder[T] {
+	b.name = name
+	return b
+}
+
+// Unique makes the index reject duplicate keys.
+//
+// Returns:
+//   - The IndexBuilder, for chaining.
+func (b *IndexBuilder[T]) Unique() *IndexBuilder[T] {
+	b.unique = true
+	return b
+}
+
+// Sparse makes the index skip the documents missing the indexed fields.
+//
+// Returns:
+//   - The IndexBuilder, for chaining.
+func (b *IndexBuilder[T]) Sparse() *IndexBuilder[T] {
+	b.sparse = true
+	return b
+}
+
+// PartialFilter only indexes the documents matching a filter, e.g. {"status": "active"} for the emails unique among
+// the active users. The filter uses the BSON keys of the entity, with the operators MongoDB accepts in a
+// partialFilterExpression: equalities, $exists, $gt, $gte, $lt, $lte, $type, $and, $or and $in.
+//
+// Parameters:
+//   - filter: The partialFilterExpression.
+//
+// Returns:
+//   - The IndexBuilder, for chaining.
+func (b *IndexBuilder[T]) PartialFilter(filter any) *IndexBuilder[T] {
+	b.partial = filter
+	return b
+}
+
+// Build returns the index model, to give to EnsureIndexes, PartitionConfig.Indexes or the driver.
+//
+// Returns:
+//   - The index model, named explicitly.
+//
+// Panics:
+//   - If the index has no key, is both sparse and partial, or its partial filter references a field that does
+//     not exist in the entity struct.
+//   - If a wildcard index has several wildcard keys or is unique, or its projection has no "$**" key, mixes
+//     included and excluded paths or references a field that does not exist in the entity struct.
+func (b *IndexBuilder[T]) Build() mongo.IndexModel {
+	if len(b.keys) == 0 {
+		panic("Configuration error: An index requires at least one key.")
+	}
+
+	name := b.name
+	if name == "" {
+		name = indexName(b.keys)
+	}
+
+	opts := options.Index().SetName(name)
+	if b.unique {
+		opts.SetUnique(true)
+	}
+	if b.sparse {
+		opts.SetSparse(true)
+	}
+	if projection := b.wildcardProjection(name); projection != nil {
+		opts.SetWildcardProjection(projection)
+	}
+
+	return b.withPartialFilter(mongo.IndexModel{Keys: b.keys, Options: opts}, name)
+}
+
+// withPartialFilter sets the validated partial filter of the builder on an index model.
+func (b *IndexBuilder[T]) withPartialFilter(model mongo.IndexModel, name string) mongo.IndexModel {
+	if b.partial == nil {
+		return model
+	}
+
+	if b.sparse {
+		panic(fmt.Sprintf("Configuration error: The index %s cannot be both sparse and partial.", name))
+	}
+
+	raw, err := bson.Marshal(b.partial)
+	if err != nil {
+		panic(fmt.Sprintf("Configuration error: The partial filter of the index %s cannot be encoded: %s", name, err.Error()))
+	}
+	if field := unknownFilterField(reflect.TypeOf((*T)(nil)).Elem(), raw); field != "" {
+		panic(fmt.Sprintf("Configuration error: The partial filter of the index %s references the unknown field %q.", name, field))
+	}
+	model.Options.SetPartialFilterExpression(raw)
+
+	return model
+}
+
+// wildcardProjection validates the wildcard keys of the index, returning its wildcardProjection, nil if it has none.
+func (b *IndexBuilder[T]) wildcardProjection(name string) bson.D {
+	wildcards, whole := 0, false
+	for _, key := range b.keys {
+		if key.Key == "$**" || strings.HasSuffix(key.Key, ".$**") {
+			wildcards++
+			whole = whole || key.Key == "$**"
+		}
+	}
+
+	if wildcards == 0 {
+		if b.projection != nil {
+			panic(fmt.Sprintf("Configuration error: The index %s has a wildcard projection but no wildcard key.", name))
+		}
+		return nil
+	}
+
+	if wildcards > 1 {
+		panic(fmt.Sprintf("Configuration error: The index %s cannot hold several wildcard keys.", name))
+	}
+	if b.unique {
+		panic(fmt.Sprintf("Configuration error: The wildcard index %s cannot be unique.", name))
+	}
+	if b.projection == nil {
+		return nil
+	}
+	if !whole {
+		panic(fmt.Sprintf("Configuration error: The wildcard projection of the index %s requires a whole-document wildcard key.", name))
+	}
+
+	entityType := reflect.TypeOf((*T)(nil)).Elem()
+	included := map[any]bool{}
+	for _, path := range b.projection {
+		if !hasDocumentPath(entityType, strings.Split(path.Key, ".")) {
+			panic(fmt.Sprintf("Configuration error: The wildcard projection of the index %s references the unknown field %q.", name, path.Key))
+		}
+		if path.Key != "_id" {
+			included[path.Value] = true
+		}
+	}
+	if len(included) > 1 {
+		panic(fmt.Sprintf("Configuration error: The wildcard projection of the index %s cannot mix included and excluded paths.", name))
+	}
+
+	return b.projection
+}
+
+// unknownFilterField returns the first field path of a filter that the type does not store, looking into the
+// $and, $or and $nor clauses, empty if every path exists.
+func unknownFilterField(t reflect.Type, filter bson.Raw) string {
+	elements, _ := filter.Elements()
+	for _, element := range elements {
+		key := element.Key()
+		if !strings.HasPrefix(key, "$") {
+			if !hasDocumentPath(t, strings.Split(key, ".")) {
+				return key
+			}
+			continue
+		}
+
+		clauses, ok := element.Value().ArrayOK()
+		if !ok {
+			continue
+		}
+		values, _ := clauses.Values()
+		for _, clause := range values {
+			if document, ok := clause.DocumentOK(); ok {
+				if field := unknownFilterField(t, document); field != "" {
+					return field
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// hasDocumentPath reports whether a type stores a BSON path, array indexes included; maps, interfaces and inline
+// maps hold any key.
+func hasDocumentPath(t reflect.Type, path []string) bool {
+	for _, segment := range path {
+		t = derefType(t)
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			t = derefType(t.Elem())
+			if _, err := strconv.Atoi(segment); err == nil {
+				continue
+			}
+		}
+
+		if t.Kind() == reflect.Map || t.Kind() == reflect.Interface {
+			return true
+		}
+
+		keys := structKeysOf(t)
+		if keys == nil {
+			return false
+		}
+
+		fieldType, ok := keys.fields[segment]
+		if !ok {
+			return keys.catchAll
+		}
+		t = fieldType
+	}
+
+	return true
+}
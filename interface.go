@@ -1,8 +1,9 @@
 package mongorepo
 
 import (
+	"context"
+
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -24,6 +25,7 @@ type IRepository[T any] interface {
 	Database() *mongo.Database
 
 	// Aggregate executes an aggregation pipeline on the MongoDB collection associated with the repository.
+	// Soft-deleted documents are excluded unless soft-delete scoping is disabled.
 	//
 	// Parameters:
 	//   - pipeline: A MongoDB aggregation pipeline represented as a slice of aggregation stages.
@@ -33,38 +35,113 @@ type IRepository[T any] interface {
 	//   - (*mongo.Cursor, error): A cursor to iterate over the aggregation result set, or an error if the operation fails.
 	Aggregate(pipeline *mongo.Pipeline, opts ...*options.AggregateOptions) (*mongo.Cursor, error)
 
-	// FindById retrieves a single entity by its unique MongoDB id.
+	// Context returns the context configured for the repository operations.
+	//
+	// Returns:
+	//   - The context of the configuration.
+	Context() context.Context
+
+	// FieldKey resolves the BSON key of a struct field of the entity `T`.
+	//
+	// Parameters:
+	//   - field: The name of the struct field.
+	//
+	// Returns:
+	//   - The BSON key of the field.
+	FieldKey(field string) string
+
+	// ScopedFilter applies the repository scopes (such as the soft-delete exclusion) to a query filter.
+	//
+	// Parameters:
+	//   - query: A BSON map defining the search criteria.
+	//
+	// Returns:
+	//   - A new filter containing the query and the scope conditions.
+	ScopedFilter(query bson.M) bson.M
+
+	// ScopedPipeline applies the repository scopes to an aggregation pipeline.
+	//
+	// Parameters:
+	//   - pipeline: The aggregation pipeline to scope.
+	//
+	// Returns:
+	//   - A new pipeline with the scope applied.
+	ScopedPipeline(pipeline mongo.Pipeline) mongo.Pipeline
+
+	// Comment returns the $comment the built-in operations set for an operation.
+	//
+	// Parameters:
+	//   - operation: The name of the operation.
+	//
+	// Returns:
+	//   - The comment, empty when tagging is disabled.
+	Comment(operation string) string
+
+	// Observe runs a custom operation, checking the region first and reporting it like the built-in operations.
+	//
+	// Parameters:
+	//   - operation: The name of the operation reported.
+	//   - filter: The filter or pipeline of the operation, may be nil.
+	//   - run: The operation, returning the number of documents it returned and its error.
+	//
+	// Returns:
+	//   - The error of the region check or of run.
+	Observe(operation string, filter any, run func() (int, error)) error
+
+	// DecodeOne decodes the result of a FindOne or FindOneAnd* operation into a new entity, hydrated and redacted.
+	//
+	// Parameters:
+	//   - result: The single result to decode.
+	//
+	// Returns:
+	//   - A pointer to the decoded entity of type `T`.
+	//   - An error if nothing matched or the decoding fails.
+	DecodeOne(result *mongo.SingleResult) (*T, error)
+
+	// DecodeAll drains a cursor into new hydrated and redacted entities and closes it.
+	//
+	// Parameters:
+	//   - cursor: The cursor of a Find or Aggregate operation.
+	//
+	// Returns:
+	//   - A slice of pointers to the decoded entities of type `T`.
+	//   - An error if the iteration or the decoding fails.
+	DecodeAll(cursor *mongo.Cursor) ([]*T, error)
+
+	// FindByHexId retrieves a single entity by the string representation of its ObjectID.
 	//
 	// Parameters:
 	//   - id: the string representation of the object id.
 	//
 	// Returns:
-	//   - A slice of pointers to entities of type `T` that match the criteria.
-	//   - An error if the operation fails.
-	FindByHexId(id string) *T
+	//   - A pointer to the entity of type `T`.
+	//   - ErrInvalidID if the id is invalid, ErrNotFound if not found, or an error if the operation fails.
+	FindByHexId(id string) (*T, error)
 
-	// FindById retrieves a single entity by its unique MongoDB ObjectID.
+	// FindById retrieves a single entity by its unique ID.
 	//
 	// Parameters:
-	//   - id: The ObjectID of the entity to retrieve.
+	//   - id: The ID of the entity to retrieve.
 	//
 	// Returns:
-	//   - A pointer to the entity of type `T`, or nil if not found.
-	//   - An error if the operation fails.
-	FindById(id primitive.ObjectID) *T
+	//   - A pointer to the entity of type `T`.
+	//   - ErrNotFound if not found, or an error if the operation fails.
+	FindById(id any) (*T, error)
 
 	// FindOne executes a query to retrieve a single entity matching the provided search criteria.
+	// Soft-deleted documents are excluded unless soft-delete scoping is disabled.
 	//
 	// Parameters:
 	//   - query: A BSON map defining the search criteria.
 	//   - opts: Optional FindOneOptions to modify the query behavior.
 	//
 	// Returns:
-	//   - A pointer to the entity of type `T`, or nil if no entity matches the criteria.
-	//   - An error if the operation fails.
-	FindOne(query bson.M, opts ...*options.FindOneOptions) *T
+	//   - A pointer to the entity of type `T`.
+	//   - ErrNotFound if no entity matches the criteria, or an error if the operation fails.
+	FindOne(query bson.M, opts ...*options.FindOneOptions) (*T, error)
 
 	// Find retrieves a list of entities that match the provided search criteria.
+	// Soft-deleted documents are excluded unless soft-delete scoping is disabled.
 	//
 	// Parameters:
 	//   - query: A BSON map defining the search criteria.
@@ -73,7 +150,38 @@ type IRepository[T any] interface {
 	// Returns:
 	//   - A slice of pointers to entities of type `T` that match the criteria.
 	//   - An error if the operation fails.
-	Find(query bson.M, opts ...*options.FindOptions) []*T
+	Find(query bson.M, opts ...*options.FindOptions) ([]*T, error)
+
+	// FindByIdsOrdered retrieves the entities with the given IDs, preserving the order of ids.
+	//
+	// Parameters:
+	//   - ids: The IDs of the entities to retrieve, in the expected order.
+	//
+	// Returns:
+	//   - A slice of pointers to the entities of type `T`, in the order of ids.
+	//   - An error if the operation fails.
+	FindByIdsOrdered(ids []any) ([]*T, error)
+
+	// Count returns the number of documents matching the query.
+	//
+	// Parameters:
+	//   - query: A BSON map defining the search criteria.
+	//   - opts: Optional CountOptions (e.g., limit, hint).
+	//
+	// Returns:
+	//   - The number of matching documents.
+	//   - An error if the operation fails.
+	Count(query bson.M, opts ...*options.CountOptions) (int64, error)
+
+	// Exists reports whether at least one document matches the query.
+	//
+	// Parameters:
+	//   - query: A BSON map defining the search criteria.
+	//
+	// Returns:
+	//   - true if a document matches the query.
+	//   - An error if the operation fails.
+	Exists(query bson.M) (bool, error)
 
 	// Create inserts a new entity into the MongoDB collection.
 	//
@@ -84,21 +192,115 @@ type IRepository[T any] interface {
 	//   - An error if the insertion fails.
 	Create(entity *T) error
 
+	// CreateMany inserts several entities in batches.
+	//
+	// Parameters:
+	//   - entities: The pointers to the entities of type `T` to be inserted.
+	//
+	// Returns:
+	//   - An error if an insertion fails.
+	CreateMany(entities []*T) error
+
 	// Update modifies an existing entity in the MongoDB collection.
 	//
 	// Parameters:
 	//   - entity: A pointer to the entity of type `T` with updated fields.
 	//
 	// Returns:
+	//   - ErrNotFound if VersionField is not configured and the document does not exist.
 	//   - An error if the update operation fails.
 	Update(entity *T) error
 
+	// Upsert updates the document matching the filter with the entity, or inserts it when nothing matches.
+	//
+	// Parameters:
+	//   - entity: A pointer to the entity of type `T` to be written.
+	//   - filter: A BSON map selecting the document to update.
+	//
+	// Returns:
+	//   - true if the entity was inserted, false if an existing document was updated.
+	//   - An error if the operation fails.
+	Upsert(entity *T, filter bson.M) (bool, error)
+
+	// UpdateFields writes only the given fields of a document, maintaining UpdatedAt.
+	//
+	// Parameters:
+	//   - id: The ID of the document to update.
+	//   - fields: A BSON map of the keys to set and their values.
+	//
+	// Returns:
+	//   - ErrNotFound if the document does not exist, or an error if the update fails.
+	UpdateFields(id any, fields bson.M) error
+
+	// UpdateMany applies an update to every document matching the filter, maintaining UpdatedAt.
+	//
+	// Parameters:
+	//   - filter: A BSON map selecting the documents to update.
+	//   - update: A BSON map of update operators.
+	//
+	// Returns:
+	//   - The number of modified documents.
+	//   - An error if the update fails.
+	UpdateMany(filter bson.M, update bson.M) (int64, error)
+
+	// DeleteMany removes (or soft-deletes) every document matching the filter.
+	//
+	// Parameters:
+	//   - filter: A BSON map selecting the documents to delete.
+	//
+	// Returns:
+	//   - The number of deleted documents.
+	//   - An error if the deletion fails.
+	DeleteMany(filter bson.M) (int64, error)
+
+	// ForceDelete permanently removes an entity, even when soft deletes are configured.
+	//
+	// Parameters:
+	//   - entity: A pointer to the entity of type `T` to be deleted.
+	//
+	// Returns:
+	//   - ErrNotFound if the document does not exist.
+	//   - An error if the deletion fails.
+	ForceDelete(entity *T) error
+
+	// Restore undoes the soft delete of an entity.
+	//
+	// Parameters:
+	//   - entity: A pointer to the soft-deleted entity of type `T`.
+	//
+	// Returns:
+	//   - ErrNotFound if the document does not exist, or an error if the update fails.
+	Restore(entity *T) error
+
+	// FindOneAndUpdate atomically updates the first document matching the filter and returns it.
+	//
+	// Parameters:
+	//   - filter: A BSON map selecting the document to update.
+	//   - update: A BSON map of update operators.
+	//   - opts: Optional FindOneAndUpdateOptions (e.g., return document before/after the update).
+	//
+	// Returns:
+	//   - A pointer to the decoded entity of type `T`.
+	//   - ErrNotFound if no document matches the filter, or an error if the operation fails.
+	FindOneAndUpdate(filter bson.M, update bson.M, opts ...*options.FindOneAndUpdateOptions) (*T, error)
+
+	// FindOneAndDelete atomically deletes (or soft-deletes) the first document matching the filter and returns it.
+	//
+	// Parameters:
+	//   - filter: A BSON map selecting the document to delete.
+	//
+	// Returns:
+	//   - A pointer to the decoded entity of type `T`.
+	//   - ErrNotFound if no document matches the filter, or an error if the operation fails.
+	FindOneAndDelete(filter bson.M) (*T, error)
+
 	// Delete removes an entity from the MongoDB collection by its ObjectID.
 	//
 	// Parameters:
 	//   - entity: A pointer to the entity of type `T` to be deleted.
 	//
 	// Returns:
+	//   - ErrNotFound if the document does not exist.
 	//   - An error if the deletion fails.
 	Delete(entity *T) error
 }
@@ -0,0 +1,101 @@
+package mongorepo
+
+import (
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// Joined pairs an entity with the entities of another repository referencing the same key.
+type Joined[L any, R any] struct {
+	Left  *L
+	Right []*R
+}
+
+// JoinInMemory joins entities with the entities of another repository on the application side, for joins across
+// databases or clusters where $lookup cannot be used. Distinct key values are looked up in batches with one $in
+// query per batch. Keys holding an array match every one of their values, so a list of references can be joined.
+//
+// Parameters:
+//   - left: The entities to join.
+//   - rightRepo: The repository of the joined entities.
+//   - leftKey: The BSON key (dotted paths allowed) of the left entities holding the join value(s).
+//   - rightKey: The BSON key (dotted paths allowed) of the right entities matched against the left values, e.g. "_id".
+//
+// Returns:
+//   - One Joined per left entity, in the order of left, with the matching right entities (possibly none).
+//   - An error if the encoding of an entity or a lookup fails.
+func JoinInMemory[L any, R any](left []*L, rightRepo *Repository[R], leftKey, rightKey string) ([]Joined[L, R], error) {
+	leftPath := strings.Split(leftKey, ".")
+	rightPath := strings.Split(rightKey, ".")
+
+	refs := make([][]bson.RawValue, len(left))
+	seen := map[string]bool{}
+	var values []bson.RawValue
+	for i, entity := range left {
+		raw, err := bson.Marshal(entity)
+		if err != nil {
+			return nil, err
+		}
+
+		refs[i] = joinValues(bson.Raw(raw).Lookup(leftPath...))
+		for _, value := range refs[i] {
+			if key := rawValueKey(value); !seen[key] {
+				seen[key] = true
+				values = append(values, value)
+			}
+		}
+	}
+
+	matches := map[string][]*R{}
+	for start := 0; start < len(values); start += defaultBatchSize {
+		end := min(start+defaultBatchSize, len(values))
+
+		found, err := rightRepo.Find(bson.M{rightKey: bson.M{"$in": values[start:end]}})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entity := range found {
+			raw, err := bson.Marshal(entity)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, value := range joinValues(bson.Raw(raw).Lookup(rightPath...)) {
+				key := rawValueKey(value)
+				matches[key] = append(matches[key], entity)
+			}
+		}
+	}
+
+	joined := make([]Joined[L, R], len(left))
+	for i, entity := range left {
+		joined[i].Left = entity
+
+		added := map[*R]bool{}
+		for _, value := range refs[i] {
+			for _, match := range matches[rawValueKey(value)] {
+				if !added[match] {
+					added[match] = true
+					joined[i].Right = append(joined[i].Right, match)
+				}
+			}
+		}
+	}
+
+	return joined, nil
+}
+
+// joinValues returns the values of a join key, flattening arrays and skipping missing and null values.
+func joinValues(value bson.RawValue) []bson.RawValue {
+	var values []bson.RawValue
+	for _, v := range referenceValues(value) {
+		if v.Type != 0 && v.Type != bsontype.Null {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
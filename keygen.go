@@ -0,0 +1,40 @@
+package mongorepo
+
+import (
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrKeyCollision is returned by CreateWithGeneratedKey when every generated key was already taken.
+var ErrKeyCollision error = &kindError{kind: ErrDuplicateKey, err: errors.New("mongorepo: generated key already taken, retries exhausted")}
+
+// CreateWithGeneratedKey creates an entity identified by a generated key, such as the 8 characters codes of
+// a URL shortener, generating a new key whenever the insertion fails with a duplicate-key error. The key
+// field must be backed by a unique index, so collisions, including concurrent ones, are detected by the server.
+//
+// Parameters:
+//   - entity: A pointer to the entity of type `T` to be inserted.
+//   - generator: Sets a new key on the entity, e.g. func(l *Link) error { l.Code = randomCode(8); return nil }.
+//   - maxRetries: The number of new keys generated after a collision.
+//
+// Returns:
+//   - ErrKeyCollision (wrapping the last duplicate-key error) if every generated key was taken.
+//   - The error returned by generator, or an error if the insertion fails.
+func (r *Repository[T]) CreateWithGeneratedKey(entity *T, generator func(entity *T) error, maxRetries int) error {
+	var err error
+
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if err := generator(entity); err != nil {
+			return err
+		}
+
+		err = r.Create(entity)
+		if !mongo.IsDuplicateKeyError(err) || errors.Is(err, ErrDuplicateContent) {
+			return err
+		}
+	}
+
+	return fmt.Errorf("%w: %w", ErrKeyCollision, err)
+}
@@ -0,0 +1,241 @@
+package mongorepo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrPipelineRejected is wrapped by the PipelineRejectedError returned by Aggregate for the pipelines the
+// AggregationGuard rejects.
+var ErrPipelineRejected = errors.New("mongorepo: aggregation pipeline rejected")
+
+// AggregationGuard holds the cost guardrails Aggregate checks pipelines against before running them, so ad-hoc
+// reports cannot overload the primary. Unguarded() lifts them for the pipelines known to be safe.
+type AggregationGuard struct {
+	MaxBlockingDocs       int64 // The number of input documents above which a $group, $bucket or a $sort without index support is rejected, default: 0 (not checked)
+	RequireAllowDiskUse   bool  // Whether pipelines with a blocking stage must set allowDiskUse, default: false
+	RejectUnboundedLookup bool  // Whether a $lookup without index on its foreignField, or with a sub-pipeline without $limit, is rejected, default: false
+}
+
+// PipelineRejectedError lists the guardrails a pipeline violates.
+type PipelineRejectedError struct {
+	Violations []string // The violations, e.g. "$group over more than 100000 documents".
+}
+
+func (e *PipelineRejectedError) Error() string {
+	return ErrPipelineRejected.Error() + ": " + strings.Join(e.Violations, "; ")
+}
+
+func (e *PipelineRejectedError) Unwrap() error {
+	return ErrPipelineRejected
+}
+
+// blockingStages are the stages holding all their input before producing any output.
+var blockingStages = map[string]bool{
+	"$group":       true,
+	"$bucket":      true,
+	"$bucketAuto":  true,
+	"$sortByCount": true,
+}
+
+// Unguarded returns a view of the repository whose Aggregate does not check the AggregationGuard, for the
+// pipelines reviewed as safe, e.g. nightly reports on a secondary. The original repository is not modified.
+//
+// Returns:
+//   - A pointer to a Repository sharing the configuration of `r` without AggregationGuard.
+func (r *Repository[T]) Unguarded() *Repository[T] {
+	view := r.view()
+	view.config.AggregationGuard = nil
+	return view
+}
+
+// lintPipeline checks a pipeline against the AggregationGuard.
+func (r *Repository[T]) lintPipeline(pipeline mongo.Pipeline, opts []*options.AggregateOptions) error {
+	guard := r.config.AggregationGuard
+	if guard == nil {
+		return nil
+	}
+
+	violations := []string{}
+	blocking := []string{}
+	filters := bson.A{}
+	leading := true
+
+	for _, stage := range pipeline {
+		raw, err := bson.Marshal(stage)
+		if err != nil {
+			return err
+		}
+		element, err := bson.Raw(raw).IndexErr(0)
+		if err != nil {
+			continue
+		}
+		name := element.Key()
+		document, _ := element.Value().DocumentOK()
+
+		switch {
+		case name == "$match" && leading:
+			filters = append(filters, document)
+			continue
+		case name == "$sort":
+			supported, err := r.indexSupportsSort(document, leading)
+			if err != nil {
+				return err
+			}
+			if !supported {
+				blocking = append(blocking, "$sort without index support")
+			}
+		case name == "$lookup" && guard.RejectUnboundedLookup:
+			violation, err := r.lintLookup(document)
+			if err != nil {
+				return err
+			}
+			if violation != "" {
+				violations = append(violations, violation)
+			}
+		case blockingStages[name]:
+			blocking = append(blocking, name)
+		}
+		leading = false
+	}
+
+	if len(blocking) > 0 && guard.MaxBlockingDocs > 0 {
+		count, err := r.countPipelineInput(filters, guard.MaxBlockingDocs)
+		if err != nil {
+			return err
+		}
+		if count > guard.MaxBlockingDocs {
+			for _, stage := range blocking {
+				violations = append(violations, fmt.Sprintf("%s over more than %d documents", stage, guard.MaxBlockingDocs))
+			}
+		}
+	}
+
+	if len(blocking) > 0 && guard.RequireAllowDiskUse && !allowsDiskUse(opts) {
+		violations = append(violations, "blocking stage without allowDiskUse")
+	}
+
+	if len(violations) > 0 {
+		return &PipelineRejectedError{Violations: violations}
+	}
+
+	return nil
+}
+
+// indexSupportsSort reports whether a $sort can use an index of the collection: it must follow the leading
+// $match stages only, and its keys must prefix an index in the same or the reverse directions.
+func (r *Repository[T]) indexSupportsSort(sort bson.Raw, leading bool) (bool, error) {
+	if !leading {
+		return false, nil
+	}
+
+	keys, err := sort.Elements()
+	if err != nil {
+		return false, err
+	}
+
+	specs, err := r.Collection().Indexes().ListSpecifications(r.config.Context)
+	if err != nil {
+		return false, err
+	}
+
+	for _, spec := range specs {
+		if indexPrefixedBy(spec.KeysDocument, keys, 1) || indexPrefixedBy(spec.KeysDocument, keys, -1) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// lintLookup returns the violation of a $lookup stage, empty when it is bounded: a sub-pipeline must contain
+// a $limit, and an equality match needs an index on the foreignField of the joined collection.
+func (r *Repository[T]) lintLookup(lookup bson.Raw) (string, error) {
+	from, _ := lookup.Lookup("from").StringValueOK()
+
+	if pipeline, ok := lookup.Lookup("pipeline").ArrayOK(); ok {
+		stages, _ := pipeline.Values()
+		for _, stage := range stages {
+			if document, ok := stage.DocumentOK(); ok && document.Lookup("$limit").Type != 0 {
+				return "", nil
+			}
+		}
+		return fmt.Sprintf("$lookup from %s with a sub-pipeline without $limit", from), nil
+	}
+
+	foreignField, ok := lookup.Lookup("foreignField").StringValueOK()
+	if !ok {
+		return "", nil
+	}
+
+	specs, err := r.Database().Collection(from).Indexes().ListSpecifications(r.config.Context)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := bson.Marshal(bson.D{{Key: foreignField, Value: 1}})
+	if err != nil {
+		return "", err
+	}
+	keys, _ := bson.Raw(key).Elements()
+	for _, spec := range specs {
+		if indexPrefixedBy(spec.KeysDocument, keys, 1) || indexPrefixedBy(spec.KeysDocument, keys, -1) {
+			return "", nil
+		}
+	}
+
+	return fmt.Sprintf("$lookup from %s without index on %s", from, foreignField), nil
+}
+
+// countPipelineInput counts the documents matching the leading $match stages, up to limit+1.
+func (r *Repository[T]) countPipelineInput(filters bson.A, limit int64) (int64, error) {
+	ctx := r.config.Context
+
+	if len(filters) == 0 {
+		return r.Collection().EstimatedDocumentCount(ctx)
+	}
+
+	return r.Collection().CountDocuments(ctx, bson.M{"$and": filters}, options.Count().SetLimit(limit+1), r.countComment("lintPipeline"))
+}
+
+// indexPrefixedBy reports whether the keys of an index start with the sort keys, with their directions
+// multiplied by direction (1 for the same directions, -1 for the reverse ones).
+func indexPrefixedBy(index bson.Raw, keys []bson.RawElement, direction int) bool {
+	indexKeys, err := index.Elements()
+	if err != nil || len(indexKeys) < len(keys) {
+		return false
+	}
+
+	for i, key := range keys {
+		if indexKeys[i].Key() != key.Key() {
+			return false
+		}
+
+		want, ok := key.Value().AsInt64OK()
+		if !ok {
+			return false
+		}
+		got, ok := indexKeys[i].Value().AsInt64OK()
+		if !ok || got != want*int64(direction) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// allowsDiskUse reports whether the aggregate options enable allowDiskUse.
+func allowsDiskUse(opts []*options.AggregateOptions) bool {
+	for _, opt := range opts {
+		if opt != nil && opt.AllowDiskUse != nil && *opt.AllowDiskUse {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,119 @@
+package mongorepo
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// lockKey is the BSON key of the sub-document holding the owner and the expiration of a document lock.
+const lockKey = "_mongorepo_lock"
+
+var (
+	// ErrDocumentLocked is returned by LockDocument when another owner holds an unexpired lock on the document.
+	ErrDocumentLocked = errors.New("mongorepo: document locked by another owner")
+
+	// ErrLockNotHeld is returned by UnlockDocument when the owner does not hold the lock (anymore).
+	ErrLockNotHeld = errors.New("mongorepo: document lock not held")
+)
+
+// LockDocument acquires an exclusive lock on a document for owner, stored in an embedded sub-document
+// and taken with an atomic compare-and-swap. The lock expires after ttl, so a crashed owner cannot hold
+// it forever; locking again as the same owner renews it. Locks are advisory: only the writers that
+// take the lock are excluded.
+//
+// Parameters:
+//   - id: The ID of the document to lock.
+//   - owner: An identifier of the lock owner (e.g., a worker or request id).
+//   - ttl: How long the lock is held unless renewed or released.
+//
+// Returns:
+//   - ErrDocumentLocked if another owner holds the lock.
+//   - ErrNotFound if the document does not exist.
+//   - An error if the operation fails.
+func (r *Repository[T]) LockDocument(id any, owner string, ttl time.Duration) error {
+	ctx := r.config.Context
+	now := time.Now()
+
+	filter := bson.M{
+		"_id": id,
+		"$or": bson.A{
+			bson.M{lockKey: bson.M{"$exists": false}},
+			bson.M{lockKey + ".expires_at": bson.M{"$lte": now}},
+			bson.M{lockKey + ".owner": owner},
+		},
+	}
+
+	update := bson.M{"$set": bson.M{lockKey: bson.M{"owner": owner, "expires_at": now.Add(ttl)}}}
+
+	result, err := r.Collection().UpdateOne(ctx, r.scopeFilter(filter), update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	exists, err := r.Exists(bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return errNoDocuments
+	}
+
+	return ErrDocumentLocked
+}
+
+// UnlockDocument releases the lock held by owner on a document.
+//
+// Parameters:
+//   - id: The ID of the locked document.
+//   - owner: The identifier used to acquire the lock.
+//
+// Returns:
+//   - ErrLockNotHeld if owner does not hold the lock, e.g. because it expired and was taken by another owner.
+//   - An error if the operation fails.
+func (r *Repository[T]) UnlockDocument(id any, owner string) error {
+	filter := bson.M{"_id": id, lockKey + ".owner": owner}
+
+	result, err := r.Collection().UpdateOne(r.config.Context, filter, bson.M{"$unset": bson.M{lockKey: ""}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
+
+// WithDocumentLock runs fn while holding the lock of a document, for Config.LockTTL, and releases it afterwards.
+// fn should complete well within the TTL, as nothing prevents another owner from taking an expired lock.
+//
+// Parameters:
+//   - id: The ID of the document to lock.
+//   - fn: The function run while the lock is held.
+//
+// Returns:
+//   - ErrDocumentLocked if another owner holds the lock, in which case fn is not run.
+//   - The error returned by fn, or an error if locking or unlocking fails.
+func (r *Repository[T]) WithDocumentLock(id any, fn func() error) error {
+	owner := primitive.NewObjectID().Hex()
+
+	if err := r.LockDocument(id, owner, r.config.LockTTL); err != nil {
+		return err
+	}
+
+	if err := fn(); err != nil {
+		r.UnlockDocument(id, owner)
+		return err
+	}
+
+	return r.UnlockDocument(id, owner)
+}
@@ -0,0 +1,195 @@
+package mongorepo
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxFilterSummary is the length above which the filter summaries of the logs are truncated.
+const maxFilterSummary = 256
+
+// logger returns the logger of the repository, Config.Logger or slog.Default().
+func (r *Repository[T]) logger() *slog.Logger {
+	if r.config.Logger != nil {
+		return r.config.Logger
+	}
+
+	return slog.Default()
+}
+
+// SlowOperation describes an operation that took at least Config.SlowOperationThreshold.
+type SlowOperation struct {
+	Collection string        // The collection of the repository.
+	Operation  string        // The repository method, e.g. "Find".
+	Duration   time.Duration // The time spent in the driver.
+	Filter     string        // The summary of the filter or pipeline with its values replaced by "?", empty if none.
+	Err        error         // The error of the operation, nil on success.
+}
+
+// observe reports an operation of the repository once the driver call returned: its measurements to Config.Metrics,
+// its query shape to Config.QueryShapes, a warning and Config.OnSlowOperation when it is slow, and its collection, duration, filter summary and error to
+// the logger at the debug level.
+func (r *Repository[T]) observe(operation string, filter any, start time.Time, documents int, err error) {
+	duration := time.Since(start)
+
+	if r.config.Metrics != nil {
+		r.config.Metrics.ObserveOperation(OperationMetrics{
+			Name:      r.MetricName(operation),
+			Labels:    r.MetricLabels(operation),
+			Duration:  duration,
+			Documents: documents,
+			Err:       err,
+		})
+	}
+
+	if r.config.QueryShapes != nil && filter != nil {
+		r.config.QueryShapes.record(r.config.CollectionName, operation, queryShape(filter), duration, err)
+	}
+
+	logger := r.logger()
+	if r.config.SlowOperationThreshold > 0 && duration >= r.config.SlowOperationThreshold {
+		r.reportSlow(logger, SlowOperation{
+			Collection: r.config.CollectionName,
+			Operation:  operation,
+			Duration:   duration,
+			Filter:     summarize(filter),
+			Err:        err,
+		})
+	}
+
+	if !logger.Enabled(r.config.Context, slog.LevelDebug) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("collection", r.config.CollectionName),
+		slog.String("operation", operation),
+		slog.Duration("duration", duration),
+	}
+	if summary := summarize(filter); summary != "" {
+		attrs = append(attrs, slog.String("filter", summary))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	logger.LogAttrs(r.config.Context, slog.LevelDebug, "mongorepo operation", attrs...)
+}
+
+// reportSlow logs a slow operation as a warning and passes it to Config.OnSlowOperation.
+func (r *Repository[T]) reportSlow(logger *slog.Logger, slow SlowOperation) {
+	attrs := []slog.Attr{
+		slog.String("collection", slow.Collection),
+		slog.String("operation", slow.Operation),
+		slog.Duration("duration", slow.Duration),
+		slog.Duration("threshold", r.config.SlowOperationThreshold),
+	}
+	if slow.Filter != "" {
+		attrs = append(attrs, slog.String("filter", slow.Filter))
+	}
+	if slow.Err != nil {
+		attrs = append(attrs, slog.String("error", slow.Err.Error()))
+	}
+	logger.LogAttrs(r.config.Context, slog.LevelWarn, "mongorepo slow operation", attrs...)
+
+	if r.config.OnSlowOperation != nil {
+		r.config.OnSlowOperation(slow)
+	}
+}
+
+// summarize returns the summary of a filter or pipeline, empty if there is none.
+func summarize(filter any) string {
+	if filter == nil {
+		return ""
+	}
+
+	return filterSummary(filter)
+}
+
+// singleResult returns the number of documents returned by a single-document read.
+func singleResult(err error) int {
+	if err != nil {
+		return 0
+	}
+
+	return 1
+}
+
+// filterSummary describes a filter without its values, which may hold personal data: every value is replaced by "?",
+// e.g. {"age":{"$gte":"?"},"status":"?"}, truncated to maxFilterSummary bytes. A pipeline is described as
+// {"pipeline":[...]}, with the values of its stages replaced.
+func filterSummary(filter any) string {
+	summary := queryShape(filter)
+	if len(summary) > maxFilterSummary {
+		return summary[:maxFilterSummary] + "..."
+	}
+
+	return summary
+}
+
+// queryShape returns the untruncated summary of a filter or pipeline, identical for the queries differing only by
+// their values or by the order of their keys.
+func queryShape(filter any) string {
+	if pipeline, ok := filter.(mongo.Pipeline); ok {
+		filter = bson.D{{Key: "pipeline", Value: pipeline}}
+	}
+
+	raw, err := bson.Marshal(filter)
+	if err != nil {
+		return "?"
+	}
+
+	shape, err := bson.MarshalExtJSON(filterShape(raw), false, false)
+	if err != nil {
+		return "?"
+	}
+
+	return string(shape)
+}
+
+// filterShape replaces the values of a filter document by "?", keeping its keys, operators and nested documents.
+// The keys are sorted, as the order of the keys of a bson.M filter changes from one call to the next.
+func filterShape(raw bson.Raw) bson.D {
+	elements, err := raw.Elements()
+	if err != nil {
+		return bson.D{}
+	}
+
+	shape := make(bson.D, 0, len(elements))
+	for _, element := range elements {
+		shape = append(shape, bson.E{Key: element.Key(), Value: valueShape(element.Value())})
+	}
+	slices.SortStableFunc(shape, func(a, b bson.E) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	return shape
+}
+
+// valueShape returns the shape of a filter value: documents and arrays of documents ($and, $or...) are walked,
+// other values become "?".
+func valueShape(value bson.RawValue) any {
+	if document, ok := value.DocumentOK(); ok {
+		return filterShape(document)
+	}
+
+	if array, ok := value.ArrayOK(); ok {
+		values, _ := array.Values()
+		shapes := bson.A{}
+		for _, item := range values {
+			if document, ok := item.DocumentOK(); ok {
+				shapes = append(shapes, filterShape(document))
+			}
+		}
+		if len(shapes) == len(values) && len(shapes) > 0 {
+			return shapes
+		}
+	}
+
+	return "?"
+}
@@ -0,0 +1,163 @@
+package mongorepo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/iancoleman/strcase"
+)
+
+// MetricNameFunc names the metrics and trace spans of an operation from the service (Config.ServiceName, may be
+// empty), the entity type name and the repository method running the operation (e.g. "FindById").
+type MetricNameFunc func(service, entity, operation string) string
+
+// LabelFunc maps a label value to the value reported, e.g. to bound the number of distinct collection labels when
+// hundreds of repositories, partitions or hash-routed collections report to the same metrics backend.
+type LabelFunc func(value string) string
+
+// Metrics receives the measurements of the operations of the repositories configured with it (Config.Metrics),
+// e.g. to export them to Prometheus (see NewPrometheusMetrics) or OpenTelemetry. Implementations must be safe for
+// concurrent use and fast, since they run on the path of every operation.
+type Metrics interface {
+	ObserveOperation(operation OperationMetrics)
+}
+
+// MetricsFunc adapts a function to the Metrics interface.
+type MetricsFunc func(operation OperationMetrics)
+
+// ObserveOperation calls f.
+func (f MetricsFunc) ObserveOperation(operation OperationMetrics) {
+	f(operation)
+}
+
+// OperationMetrics holds the measurements of an operation of a repository.
+type OperationMetrics struct {
+	Name      string            // The name of the operation metrics, see Repository.MetricName.
+	Labels    map[string]string // The service, entity, operation and collection labels, see Repository.MetricLabels.
+	Duration  time.Duration     // The time spent in the driver, including the decoding of the reads.
+	Documents int               // The number of documents returned by a read, 0 for the writes and Aggregate.
+	Err       error             // The error of the operation, nil on success.
+}
+
+// DefaultMetricName is the MetricNameFunc used when Config.MetricName is not set: the service, the entity and the
+// operation in snake case joined with dots, e.g. "billing.order.find_by_id", or "order.find_by_id" without service.
+//
+// Parameters:
+//   - service: The service name, may be empty.
+//   - entity: The entity type name, e.g. "Order".
+//   - operation: The repository method, e.g. "FindById".
+//
+// Returns:
+//   - The metric name.
+func DefaultMetricName(service, entity, operation string) string {
+	parts := []string{}
+	if service != "" {
+		parts = append(parts, service)
+	}
+
+	return strings.Join(append(parts, strcase.ToSnake(entity), strcase.ToSnake(operation)), ".")
+}
+
+// HashLabel bounds a label to a fixed number of values by hashing it into buckets, e.g. "bucket_07".
+//
+// Parameters:
+//   - buckets: The number of distinct values reported.
+//
+// Returns:
+//   - The LabelFunc.
+//
+// Panics:
+//   - If buckets is not positive.
+func HashLabel(buckets int) LabelFunc {
+	if buckets <= 0 {
+		panic("Configuration error: HashLabel requires a positive number of buckets.")
+	}
+
+	width := len(fmt.Sprint(buckets - 1))
+	return func(value string) string {
+		return fmt.Sprintf("bucket_%0*d", width, ringHash(value)%uint64(buckets))
+	}
+}
+
+// PrefixLabel reports the part of a label before the first separator, grouping the collections of a family
+// such as the partitions "events_2024_01" and "events_2024_02" under "events".
+//
+// Parameters:
+//   - separator: The separator of the family name, e.g. "_".
+//
+// Returns:
+//   - The LabelFunc.
+func PrefixLabel(separator string) LabelFunc {
+	return func(value string) string {
+		prefix, _, _ := strings.Cut(value, separator)
+		return prefix
+	}
+}
+
+// AllowLabel reports the allowed values as is and every other one as "other", keeping the labels of the
+// collections worth a dedicated series.
+//
+// Parameters:
+//   - allowed: The values reported as is.
+//
+// Returns:
+//   - The LabelFunc.
+func AllowLabel(allowed ...string) LabelFunc {
+	set := make(map[string]bool, len(allowed))
+	for _, value := range allowed {
+		set[value] = true
+	}
+
+	return func(value string) string {
+		if set[value] {
+			return value
+		}
+		return "other"
+	}
+}
+
+// MetricName returns the name of the metrics and trace spans of an operation of the repository, built by
+// Config.MetricName, or DefaultMetricName when it is not set.
+//
+// Parameters:
+//   - operation: The repository method, e.g. "FindById".
+//
+// Returns:
+//   - The metric name.
+func (r *Repository[T]) MetricName(operation string) string {
+	name := r.config.MetricName
+	if name == nil {
+		name = DefaultMetricName
+	}
+
+	return name(r.config.ServiceName, r.entityName(), operation)
+}
+
+// MetricLabels returns the labels of the metrics and trace spans of an operation of the repository: the service,
+// the entity, the operation and the collection, mapped by Config.CollectionLabel when set.
+//
+// Parameters:
+//   - operation: The repository method, e.g. "FindById".
+//
+// Returns:
+//   - The labels, keyed by "service", "entity", "operation" and "collection".
+func (r *Repository[T]) MetricLabels(operation string) map[string]string {
+	collection := r.config.CollectionName
+	if r.config.CollectionLabel != nil {
+		collection = r.config.CollectionLabel(collection)
+	}
+
+	return map[string]string{
+		"service":    r.config.ServiceName,
+		"entity":     r.entityName(),
+		"operation":  operation,
+		"collection": collection,
+	}
+}
+
+// entityName returns the name of the entity type.
+func (r *Repository[T]) entityName() string {
+	return reflect.TypeOf((*T)(nil)).Elem().Name()
+}
@@ -0,0 +1,309 @@
+// Package migrate evolves the collections of a database with ordered, versioned migrations: Go functions
+// registered with a version, applied in version order by Up, rolled back by Down and tracked in a
+// schema_migrations collection, so every environment converges to the same schema.
+//
+//	migrator := migrate.New(migrate.Config{Database: client.Database("shop")})
+//	migrator.Register(migrate.Migration{
+//		Version: 20241001, Name: "index orders by customer",
+//		Up: func(ctx context.Context, db *mongo.Database) error {
+//			_, err := db.Collection("orders").Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "customer_id", Value: 1}}})
+//			return err
+//		},
+//		Down: func(ctx context.Context, db *mongo.Database) error {
+//			_, err := db.Collection("orders").Indexes().DropOne(ctx, "customer_id_1")
+//			return err
+//		},
+//	})
+//	applied, err := migrator.Up(ctx)
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// lockID is the _id of the document locking the migrations collection while migrations run.
+const lockID = "lock"
+
+var (
+	// ErrLocked is returned when another process is running migrations on the database.
+	ErrLocked = errors.New("migrate: migrations locked by another process")
+
+	// ErrIrreversible is returned by Down for a migration without Down function.
+	ErrIrreversible = errors.New("migrate: migration cannot be rolled back")
+)
+
+// Migration is a versioned change of the database.
+type Migration struct {
+	Version int64                                               // The version, unique and increasing, e.g. a date 20241001 or a sequence number.
+	Name    string                                              // A short description shown in the status.
+	Up      func(ctx context.Context, db *mongo.Database) error // Applies the change.
+	Down    func(ctx context.Context, db *mongo.Database) error // Reverts the change, may be nil for irreversible migrations.
+}
+
+// Config holds the configuration of a Migrator.
+type Config struct {
+	Database   *mongo.Database // The migrated database.
+	Collection string          // The collection tracking the applied versions, default: schema_migrations
+	DryRun     bool            // Whether Up and Down only report the migrations they would run, default: false
+	LockTTL    time.Duration   // After how long a lock left by a crashed process is ignored, default: 10 minutes
+}
+
+// Status describes a registered migration.
+type Status struct {
+	Version   int64     // The version of the migration.
+	Name      string    // The name of the migration.
+	Applied   bool      // Whether the migration is applied.
+	AppliedAt time.Time // When the migration was applied, zero if it is pending.
+}
+
+// applied is the document tracking an applied migration.
+type applied struct {
+	Version   int64     `bson:"_id"`
+	Name      string    `bson:"name"`
+	AppliedAt time.Time `bson:"applied_at"`
+}
+
+// Migrator applies and rolls back the registered migrations.
+type Migrator struct {
+	config     Config
+	migrations []Migration
+}
+
+// New creates a Migrator.
+//
+// Parameters:
+//   - config: The migrator configuration.
+//
+// Returns:
+//   - A pointer to a Migrator.
+//
+// Panics:
+//   - If config.Database is not set.
+func New(config Config) *Migrator {
+	if config.Database == nil {
+		panic("Configuration error: The migrate.Config.Database is not set.")
+	}
+
+	if config.Collection == "" {
+		config.Collection = "schema_migrations"
+	}
+
+	if config.LockTTL <= 0 {
+		config.LockTTL = 10 * time.Minute
+	}
+
+	return &Migrator{config: config}
+}
+
+// Register adds migrations; they run in version order whatever the order of registration.
+//
+// Parameters:
+//   - migrations: The migrations to add.
+//
+// Returns:
+//   - The migrator, for chaining.
+//
+// Panics:
+//   - If a migration has no Up function or its version is already registered.
+func (m *Migrator) Register(migrations ...Migration) *Migrator {
+	for _, migration := range migrations {
+		if migration.Up == nil {
+			panic(fmt.Sprintf("Configuration error: The migration %d has no Up function.", migration.Version))
+		}
+
+		for _, registered := range m.migrations {
+			if registered.Version == migration.Version {
+				panic(fmt.Sprintf("Configuration error: The migration version %d is already registered.", migration.Version))
+			}
+		}
+
+		m.migrations = append(m.migrations, migration)
+	}
+
+	sort.Slice(m.migrations, func(i, j int) bool { return m.migrations[i].Version < m.migrations[j].Version })
+	return m
+}
+
+// Status lists the registered migrations, in version order, with their state.
+//
+// Parameters:
+//   - ctx: The context of the operation.
+//
+// Returns:
+//   - The status of each migration.
+//   - An error if the applied versions cannot be read.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	done, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if record, ok := done[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = record.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// Up applies the pending migrations in version order, recording each one once it succeeded;
+// a failing migration stops the run and is not recorded.
+//
+// Parameters:
+//   - ctx: The context of the operation.
+//
+// Returns:
+//   - The migrations applied (or that would be, in DryRun mode).
+//   - ErrLocked if another process is migrating, or the error of the failing migration.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	return m.UpTo(ctx, 0)
+}
+
+// UpTo applies the pending migrations up to a version included, see Up.
+//
+// Parameters:
+//   - ctx: The context of the operation.
+//   - version: The last version to apply, 0 for every pending migration.
+//
+// Returns:
+//   - The migrations applied (or that would be, in DryRun mode).
+//   - ErrLocked if another process is migrating, or the error of the failing migration.
+func (m *Migrator) UpTo(ctx context.Context, version int64) ([]Migration, error) {
+	ran := []Migration{}
+
+	err := m.locked(ctx, func() error {
+		done, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if version > 0 && migration.Version > version {
+				break
+			}
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+
+			if !m.config.DryRun {
+				if err := migration.Up(ctx, m.config.Database); err != nil {
+					return fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Name, err)
+				}
+
+				record := applied{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}
+				if _, err := m.collection().InsertOne(ctx, record); err != nil {
+					return err
+				}
+			}
+			ran = append(ran, migration)
+		}
+
+		return nil
+	})
+
+	return ran, err
+}
+
+// Down rolls back the last applied migrations, most recent first, removing their record once they succeeded.
+//
+// Parameters:
+//   - ctx: The context of the operation.
+//   - steps: The number of migrations to roll back.
+//
+// Returns:
+//   - The migrations rolled back (or that would be, in DryRun mode).
+//   - ErrIrreversible if a migration has no Down function, ErrLocked if another process is migrating,
+//     or the error of the failing migration.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	ran := []Migration{}
+
+	err := m.locked(ctx, func() error {
+		done, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(ran) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+
+			if migration.Down == nil {
+				return fmt.Errorf("%w: %d (%s)", ErrIrreversible, migration.Version, migration.Name)
+			}
+
+			if !m.config.DryRun {
+				if err := migration.Down(ctx, m.config.Database); err != nil {
+					return fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Name, err)
+				}
+
+				if _, err := m.collection().DeleteOne(ctx, bson.M{"_id": migration.Version}); err != nil {
+					return err
+				}
+			}
+			ran = append(ran, migration)
+		}
+
+		return nil
+	})
+
+	return ran, err
+}
+
+// applied returns the records of the applied migrations, keyed by version.
+func (m *Migrator) applied(ctx context.Context) (map[int64]applied, error) {
+	cursor, err := m.collection().Find(ctx, bson.M{"_id": bson.M{"$type": "number"}})
+	if err != nil {
+		return nil, err
+	}
+
+	var records []applied
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	done := make(map[int64]applied, len(records))
+	for _, record := range records {
+		done[record.Version] = record
+	}
+
+	return done, nil
+}
+
+// locked runs fn while holding the lock of the migrations collection. A lock older than LockTTL,
+// left by a crashed process, is taken over.
+func (m *Migrator) locked(ctx context.Context, fn func() error) error {
+	now := time.Now()
+	filter := bson.M{"_id": lockID, "locked_at": bson.M{"$lt": now.Add(-m.config.LockTTL)}}
+	update := bson.M{"$set": bson.M{"locked_at": now}}
+
+	_, err := m.collection().UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrLocked
+	}
+	if err != nil {
+		return err
+	}
+
+	defer m.collection().DeleteOne(ctx, bson.M{"_id": lockID, "locked_at": now})
+	return fn()
+}
+
+// collection returns the collection tracking the applied migrations.
+func (m *Migrator) collection() *mongo.Collection {
+	return m.config.Database.Collection(m.config.Collection)
+}
@@ -0,0 +1,127 @@
+package mongorepo
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var (
+	// ErrModifyConflict is returned by Modify when the document kept changing concurrently for every attempt.
+	ErrModifyConflict error = &kindError{kind: ErrStale, err: errors.New("mongorepo: document modified concurrently, attempts exhausted")}
+
+	// ErrStaleDocument is returned by Update when VersionField is configured and the stored version
+	// differs from the one of the entity, i.e. the document was changed by another writer since it was loaded.
+	ErrStaleDocument error = &kindError{kind: ErrStale, err: errors.New("mongorepo: stale document, the stored version changed")}
+)
+
+// Modify loads a document, applies fn to it and saves it, only if the stored document did not change in between.
+// On conflict the whole cycle is retried, up to Config.ModifyMaxAttempts times, so fn may run several times
+// and must not have side effects outside the entity. When VersionField is configured, the stored version is
+// compared instead of the whole document.
+// The entity is loaded through hydrate, unredacted since it is written back, and saved like Update saves it:
+// UpdatedAt, CopyFieldsOnWrite, the size guard, the shard key filter and the copies of the WriteTargets.
+//
+// Parameters:
+//   - id: The ID of the document to modify.
+//   - fn: The modification applied to the loaded entity; returning an error aborts Modify with that error.
+//
+// Returns:
+//   - ErrNotFound if the document does not exist.
+//   - ErrModifyConflict if every attempt lost against a concurrent write.
+//   - The error returned by fn, or an error if a read or the write fails.
+func (r *Repository[T]) Modify(id any, fn func(entity *T) error) error {
+	if err := r.guardRegion(); err != nil {
+		return err
+	}
+
+	for attempt := 0; attempt < r.config.ModifyMaxAttempts; attempt++ {
+		filter := r.scopeFilter(bson.M{"_id": id})
+		start := time.Now()
+		original, err := r.Collection().FindOne(r.config.Context, filter, r.findOneComment("Modify")).Raw()
+		r.observe("Modify", filter, start, singleResult(err), err)
+		if err != nil {
+			return classify(err)
+		}
+
+		entity := new(T)
+		if err := r.hydrate(original, entity); err != nil {
+			return err
+		}
+
+		er := NewEntityReflection(r.config, entity)
+		var version int64
+		if r.config.VersionField != "" {
+			version = er.GetVersion()
+		}
+
+		if err := fn(entity); err != nil {
+			return err
+		}
+
+		if r.config.VersionField != "" {
+			er.SetVersion(version)
+		}
+
+		if len(r.config.WriteTargets) > 0 {
+			err = r.fanOut(func(repo *Repository[T]) error { return repo.saveModified(entity, original) }, false, entity)
+		} else {
+			err = r.saveModified(entity, original)
+		}
+		if !errors.Is(err, ErrStaleDocument) {
+			return err
+		}
+	}
+
+	return ErrModifyConflict
+}
+
+// saveModified writes the entity modified by Modify, only if the stored document is still the original one
+// (or has its version when VersionField is configured), and reports ErrStaleDocument otherwise.
+func (r *Repository[T]) saveModified(entity *T, original bson.Raw) error {
+	if err := r.guardRegion(entity); err != nil {
+		return err
+	}
+
+	er := NewEntityReflection(r.config, entity)
+	if r.config.UpdatedAtField != "" {
+		er.SetUpdateAt()
+	}
+	r.setDedupeKey(er)
+
+	if err := r.copyFieldsOnWrite(entity); err != nil {
+		return err
+	}
+
+	document, err := r.guardSize(entity)
+	if err != nil {
+		return err
+	}
+
+	r.measureAmplification(er.GetID(), document)
+
+	if r.config.VersionField != "" {
+		if err := r.versionedUpdate("Modify", er, document); err != nil {
+			return err
+		}
+		return r.releaseOffloaded(original, document)
+	}
+
+	// compare-and-swap: the update only matches if the stored document is still the one we read
+	filter := r.entityFilter(er)
+	filter["$expr"] = bson.M{"$eq": bson.A{"$$ROOT", bson.M{"$literal": original}}}
+
+	start := time.Now()
+	result, err := r.Collection().UpdateOne(r.config.Context, filter, bson.M{"$set": document}, r.updateComment("Modify"))
+	r.observe("Modify", filter, start, 0, err)
+	if err != nil {
+		return classify(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrStaleDocument
+	}
+
+	return r.releaseOffloaded(original, document)
+}
@@ -0,0 +1,152 @@
+package mongorepo
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// Null is an optional value of type `T`, telling a stored zero value (Valid, e.g. a quantity of 0) apart from
+// a null or missing field (not Valid), without resorting to pointers. It is encoded as the plain value or as
+// BSON null, and as the plain value or JSON null. With `bson:",omitempty"`, invalid values are not stored.
+// The BSON methods are picked up by the default registry of the driver, no codec has to be registered.
+//
+//	type Product struct {
+//		Discount mongorepo.Null[float64] `bson:"discount"`
+//	}
+type Null[T any] struct {
+	Value T    // The value, the zero value of `T` when not Valid.
+	Valid bool // Whether the value is set.
+}
+
+// nullValue is implemented by every Null, exposing the type of its value to the schema export.
+type nullValue interface {
+	valueType() reflect.Type
+}
+
+// NullOf returns a valid Null holding value.
+//
+// Parameters:
+//   - value: The value.
+//
+// Returns:
+//   - The valid Null.
+func NullOf[T any](value T) Null[T] {
+	return Null[T]{Value: value, Valid: true}
+}
+
+// NullFromPtr returns a Null holding the value pointed to, invalid for a nil pointer.
+//
+// Parameters:
+//   - value: A pointer to the value, may be nil.
+//
+// Returns:
+//   - The Null.
+func NullFromPtr[T any](value *T) Null[T] {
+	if value == nil {
+		return Null[T]{}
+	}
+
+	return NullOf(*value)
+}
+
+// Ptr returns a pointer to a copy of the value, nil when not Valid.
+func (n Null[T]) Ptr() *T {
+	if !n.Valid {
+		return nil
+	}
+
+	value := n.Value
+	return &value
+}
+
+// ValueOr returns the value, or fallback when not Valid.
+func (n Null[T]) ValueOr(fallback T) T {
+	if !n.Valid {
+		return fallback
+	}
+
+	return n.Value
+}
+
+func (n Null[T]) valueType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// IsZero reports whether the value is not Valid, so omitempty omits it.
+func (n Null[T]) IsZero() bool {
+	return !n.Valid
+}
+
+// MarshalBSONValue encodes the value, or BSON null when not Valid.
+func (n Null[T]) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	if !n.Valid {
+		return bsontype.Null, nil, nil
+	}
+
+	return bson.MarshalValue(n.Value)
+}
+
+// UnmarshalBSONValue decodes a value; BSON null and undefined decode as not Valid.
+func (n *Null[T]) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	*n = Null[T]{}
+	if t == bsontype.Null || t == bsontype.Undefined {
+		return nil
+	}
+
+	if err := bson.UnmarshalValue(t, data, &n.Value); err != nil {
+		return err
+	}
+
+	n.Valid = true
+	return nil
+}
+
+// MarshalJSON encodes the value, or JSON null when not Valid.
+func (n Null[T]) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(n.Value)
+}
+
+// UnmarshalJSON decodes a value; JSON null decodes as not Valid.
+func (n *Null[T]) UnmarshalJSON(data []byte) error {
+	*n = Null[T]{}
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil
+	}
+
+	if err := json.Unmarshal(data, &n.Value); err != nil {
+		return err
+	}
+
+	n.Valid = true
+	return nil
+}
+
+// IsNull returns a filter matching the documents where a field is null or missing, i.e. an invalid Null.
+//
+// Parameters:
+//   - key: The BSON key of the field (dotted paths allowed).
+//
+// Returns:
+//   - The filter.
+func IsNull(key string) bson.M {
+	return bson.M{key: nil}
+}
+
+// IsNotNull returns a filter matching the documents where a field holds a value, i.e. a valid Null.
+//
+// Parameters:
+//   - key: The BSON key of the field (dotted paths allowed).
+//
+// Returns:
+//   - The filter.
+func IsNotNull(key string) bson.M {
+	return bson.M{key: bson.M{"$ne": nil}}
+}
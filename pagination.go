@@ -0,0 +1,236 @@
+package mongorepo
+
+import (
+	"fmt"
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// defaultPerPage is the page size used when a pagination helper receives a non-positive perPage.
+const defaultPerPage = 20
+
+// Page holds one page of results along with the pagination metadata.
+type Page[T any] struct {
+	Items      []*T  // The entities of the page.
+	TotalCount int64 // The number of entities matching the query across all pages.
+	TotalPages int   // The number of pages.
+	Page       int   // The 1-based page number.
+	PerPage    int   // The maximum number of entities per page.
+}
+
+// newPage builds a Page computing the number of pages from the total count.
+func newPage[T any](items []*T, totalCount int64, page, perPage int) *Page[T] {
+	return &Page[T]{
+		Items:      items,
+		TotalCount: totalCount,
+		TotalPages: int((totalCount + int64(perPage) - 1) / int64(perPage)),
+		Page:       page,
+		PerPage:    perPage,
+	}
+}
+
+// normalizePage clamps the page number to 1 and applies the default page size.
+func normalizePage(page, perPage int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+
+	if perPage < 1 {
+		perPage = defaultPerPage
+	}
+
+	return page, perPage
+}
+
+// StableSort returns a sort specification ending with an _id tiebreaker, so documents sharing the same
+// sort keys always come back in the same order and paginated queries never duplicate or skip rows.
+// The sort is returned unchanged (as bson.D) when it already contains _id. Sorts on several keys must be
+// a bson.D: the keys of a map, such as a bson.M, have no order.
+//
+// Parameters:
+//   - sort: The sort specification (bson.D, a bson.M of a single key, or any document), may be nil.
+//
+// Returns:
+//   - The stable sort specification.
+//   - An error if the sort specification is not a document, or is a map of several keys.
+func StableSort(sort any) (bson.D, error) {
+	if value := reflect.ValueOf(sort); value.Kind() == reflect.Map && value.Len() > 1 {
+		return nil, fmt.Errorf("mongorepo: the sort specification %v has several keys in a map, whose order is random; use a bson.D", sort)
+	}
+
+	var stable bson.D
+
+	switch s := sort.(type) {
+	case nil:
+	case bson.D:
+		stable = append(stable, s...)
+	default:
+		raw, err := bson.Marshal(sort)
+		if err == nil {
+			err = bson.Unmarshal(raw, &stable)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("mongorepo: the sort specification is not a document: %w", err)
+		}
+	}
+
+	if !hasKey(stable, "_id") {
+		stable = append(stable, bson.E{Key: "_id", Value: 1})
+	}
+
+	return stable, nil
+}
+
+// stableFindOptions enforces a stable sort on paginated finds: queries with a skip, or with a limit and a sort.
+// The stable sort is appended as a last FindOptions so it overrides the caller's sort.
+func stableFindOptions(opts []*options.FindOptions) ([]*options.FindOptions, error) {
+	var sort any
+	paginated, limited := false, false
+
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if opt.Sort != nil {
+			sort = opt.Sort
+		}
+		paginated = paginated || opt.Skip != nil
+		limited = limited || opt.Limit != nil
+	}
+
+	if !paginated && !(limited && sort != nil) {
+		return opts, nil
+	}
+
+	stable, err := StableSort(sort)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(append([]*options.FindOptions{}, opts...), options.Find().SetSort(stable)), nil
+}
+
+// stablePipeline makes the last $sort of a pipeline stable, appending a $sort on _id when there is none.
+func stablePipeline(pipeline mongo.Pipeline) (mongo.Pipeline, error) {
+	stable := append(mongo.Pipeline{}, pipeline...)
+
+	for i := len(stable) - 1; i >= 0; i-- {
+		if stageName(stable[i]) == "$sort" {
+			sort, err := StableSort(stable[i][0].Value)
+			if err != nil {
+				return nil, err
+			}
+			stable[i] = bson.D{{Key: "$sort", Value: sort}}
+			return stable, nil
+		}
+	}
+
+	return append(stable, bson.D{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}}), nil
+}
+
+// windowOptions returns a copy of opts ending with the skip and limit of a page. Appending to opts directly
+// could write into the backing array of the caller's slice.
+func windowOptions(opts []*options.FindOptions, skip, limit int64) []*options.FindOptions {
+	return append(append(make([]*options.FindOptions, 0, len(opts)+1), opts...), options.Find().SetSkip(skip).SetLimit(limit))
+}
+
+// FindPaginated retrieves one page of the entities matching the query along with the pagination metadata,
+// counting the matches with CountDocuments. The sort gets an _id tiebreaker (see StableSort).
+//
+// Parameters:
+//   - query: A BSON map defining the search criteria.
+//   - page: The 1-based page number.
+//   - perPage: The maximum number of entities per page.
+//   - opts: Optional FindOptions (e.g., sort, projection); their skip and limit are replaced by the ones of the page.
+//
+// Returns:
+//   - The requested page.
+//   - An error if the count or the query fails.
+func (r *Repository[T]) FindPaginated(query bson.M, page, perPage int, opts ...*options.FindOptions) (*Page[T], error) {
+	page, perPage = normalizePage(page, perPage)
+
+	total, err := r.Count(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*T
+	if skip := int64((page - 1) * perPage); skip < total {
+		if items, err = r.Find(query, windowOptions(opts, skip, int64(perPage))...); err != nil {
+			return nil, err
+		}
+	}
+
+	return newPage(items, total, page, perPage), nil
+}
+
+// AggregatePage runs an aggregation pipeline and returns one page of its results along with the total count,
+// both computed in a single round trip with $facet. The pipeline results must decode into `T`, and are hydrated
+// and redacted like the entities of Find.
+// The last $sort of the pipeline gets an _id tiebreaker (see StableSort); without $sort, results are sorted by _id.
+//
+// Parameters:
+//   - pipeline: A MongoDB aggregation pipeline.
+//   - page: The 1-based page number.
+//   - perPage: The maximum number of results per page.
+//   - opts: Optional aggregation options such as allowDiskUse or max time.
+//
+// Returns:
+//   - The requested page.
+//   - An error if the operation fails.
+func (r *Repository[T]) AggregatePage(pipeline *mongo.Pipeline, page, perPage int, opts ...*options.AggregateOptions) (*Page[T], error) {
+	page, perPage = normalizePage(page, perPage)
+
+	paged, err := stablePipeline(*pipeline)
+	if err != nil {
+		return nil, err
+	}
+	paged = append(paged, bson.D{{Key: "$facet", Value: bson.M{
+		"items": bson.A{
+			bson.M{"$skip": int64((page - 1) * perPage)},
+			bson.M{"$limit": int64(perPage)},
+		},
+		"total": bson.A{bson.M{"$count": "count"}},
+	}}})
+
+	cursor, err := r.Aggregate(&paged, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(r.config.Context)
+
+	var facet struct {
+		Items []bson.Raw `bson:"items"`
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+	}
+
+	if cursor.Next(r.config.Context) {
+		if err := cursor.Decode(&facet); err != nil {
+			return nil, err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	items := make([]*T, 0, len(facet.Items))
+	for _, raw := range facet.Items {
+		entity, err := r.decodeEntity(raw)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, entity)
+	}
+
+	var total int64
+	if len(facet.Total) > 0 {
+		total = facet.Total[0].Count
+	}
+
+	return newPage(items, total, page, perPage), nil
+}
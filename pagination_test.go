@@ -0,0 +1,164 @@
+package mongorepo
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestStableSort(t *testing.T) {
+	tests := []struct {
+		name     string
+		sort     any
+		expected bson.D
+		err      bool
+	}{
+		{name: "nil", sort: nil, expected: bson.D{{Key: "_id", Value: 1}}},
+		{name: "bson.D", sort: bson.D{{Key: "created_at", Value: -1}}, expected: bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: 1}}},
+		{name: "with _id", sort: bson.D{{Key: "_id", Value: -1}, {Key: "name", Value: 1}}, expected: bson.D{{Key: "_id", Value: -1}, {Key: "name", Value: 1}}},
+		{name: "bson.M", sort: bson.M{"name": 1}, expected: bson.D{{Key: "name", Value: int32(1)}, {Key: "_id", Value: 1}}},
+		{name: "struct", sort: struct {
+			Name int `bson:"name"`
+		}{Name: -1}, expected: bson.D{{Key: "name", Value: int32(-1)}, {Key: "_id", Value: 1}}},
+		{name: "bson.M of several keys", sort: bson.M{"name": 1, "age": -1}, err: true},
+		{name: "map of several keys", sort: map[string]int{"name": 1, "age": -1}, err: true},
+		{name: "number", sort: 1, err: true},
+		{name: "string", sort: "name", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stable, err := StableSort(test.sort)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", stable)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(stable, test.expected) {
+				t.Errorf("got %v, expected %v", stable, test.expected)
+			}
+		})
+	}
+}
+
+func TestStableSortKeepsTheSort(t *testing.T) {
+	sort := make(bson.D, 1, 2)
+	sort[0] = bson.E{Key: "name", Value: 1}
+
+	if _, err := StableSort(sort); err != nil {
+		t.Fatal(err)
+	}
+	if extended := sort[:2]; extended[1].Key == "_id" {
+		t.Error("StableSort wrote the tiebreaker into the array of the caller's sort")
+	}
+}
+
+func TestStableFindOptions(t *testing.T) {
+	sorted := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+	tests := []struct {
+		name     string
+		opts     []*options.FindOptions
+		expected any // the sort of the appended options, nil when the options are returned unchanged
+		err      bool
+	}{
+		{name: "no options"},
+		{name: "sort only", opts: []*options.FindOptions{sorted}},
+		{name: "limit only", opts: []*options.FindOptions{options.Find().SetLimit(10)}},
+		{name: "skip", opts: []*options.FindOptions{nil, options.Find().SetSkip(10)}, expected: bson.D{{Key: "_id", Value: 1}}},
+		{name: "sort and limit", opts: []*options.FindOptions{sorted, options.Find().SetLimit(10)}, expected: bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}},
+		{name: "invalid sort", opts: []*options.FindOptions{options.Find().SetSort("name").SetSkip(1)}, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stable, err := stableFindOptions(test.opts)
+			if test.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if test.expected == nil {
+				if len(stable) != len(test.opts) {
+					t.Errorf("got %d options, expected the %d given", len(stable), len(test.opts))
+				}
+				return
+			}
+			if len(stable) != len(test.opts)+1 || !reflect.DeepEqual(stable[len(stable)-1].Sort, test.expected) {
+				t.Errorf("got %d options, expected the sort %v appended", len(stable), test.expected)
+			}
+		})
+	}
+}
+
+func TestStablePipeline(t *testing.T) {
+	match := bson.D{{Key: "$match", Value: bson.M{"status": "open"}}}
+
+	tests := []struct {
+		name     string
+		pipeline mongo.Pipeline
+		expected mongo.Pipeline
+		err      bool
+	}{
+		{
+			name:     "without $sort",
+			pipeline: mongo.Pipeline{match},
+			expected: mongo.Pipeline{match, {{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}}},
+		},
+		{
+			name:     "last $sort",
+			pipeline: mongo.Pipeline{{{Key: "$sort", Value: bson.D{{Key: "a", Value: 1}}}}, match, {{Key: "$sort", Value: bson.D{{Key: "b", Value: -1}}}}},
+			expected: mongo.Pipeline{{{Key: "$sort", Value: bson.D{{Key: "a", Value: 1}}}}, match, {{Key: "$sort", Value: bson.D{{Key: "b", Value: -1}, {Key: "_id", Value: 1}}}}},
+		},
+		{name: "invalid $sort", pipeline: mongo.Pipeline{{{Key: "$sort", Value: "b"}}}, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stable, err := stablePipeline(test.pipeline)
+			if test.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(stable, test.expected) {
+				t.Errorf("got %v, expected %v", stable, test.expected)
+			}
+		})
+	}
+}
+
+func TestWindowOptions(t *testing.T) {
+	sorted := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	caller := make([]*options.FindOptions, 1, 2)
+	caller[0] = sorted
+
+	first := windowOptions(caller, 0, 20)
+	second := windowOptions(caller, 20, 20)
+
+	if extended := caller[:2]; extended[1] != nil {
+		t.Error("windowOptions wrote into the array of the caller's options")
+	}
+	if len(first) != 2 || first[0] != sorted || *first[1].Skip != 0 || *first[1].Limit != 20 {
+		t.Errorf("unexpected options of the first page: %v", first)
+	}
+	if *first[1].Skip == *second[1].Skip {
+		t.Error("the options of two pages share their window")
+	}
+}
@@ -0,0 +1,269 @@
+package mongorepo
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PartitionInterval defines the time span covered by each partition collection.
+type PartitionInterval int
+
+const (
+	PartitionDaily   PartitionInterval = iota // One collection per day, e.g. events_2024_05_14.
+	PartitionMonthly                          // One collection per month, e.g. events_2024_05.
+)
+
+// PartitionConfig holds the configuration of a PartitionManager.
+type PartitionConfig struct {
+	TimeField string             // The field in the entity struct used to route documents to a partition; must be of type time.Time.
+	Interval  PartitionInterval  // The time span of each partition, default: PartitionDaily
+	Prefix    string             // The base name of the partition collections, default: the repository collection name.
+	Lookahead int                // The number of upcoming partitions created ahead of time by EnsurePartitions, default: 0
+	Retention time.Duration      // Partitions ending before now - Retention are dropped by DropExpired, default: 0 (keep forever)
+	Indexes   []mongo.IndexModel // The indexes created on every partition by EnsurePartitions.
+}
+
+// PartitionManager routes an entity to time-bucketed collections and manages their lifecycle.
+type PartitionManager[T any] struct {
+	repo   *Repository[T]
+	config PartitionConfig
+}
+
+// NewPartitionManager creates a PartitionManager on top of a repository.
+//
+// Parameters:
+//   - repo: The repository whose configuration is shared by every partition.
+//   - config: The partitioning configuration.
+//
+// Returns:
+//   - A pointer to a PartitionManager.
+//
+// Panics:
+//   - If config.TimeField is not set.
+func NewPartitionManager[T any](repo *Repository[T], config PartitionConfig) *PartitionManager[T] {
+	if config.TimeField == "" {
+		panic("Configuration error: The PartitionConfig.TimeField is not set.")
+	}
+
+	if config.Prefix == "" {
+		config.Prefix = repo.config.CollectionName
+	}
+
+	return &PartitionManager[T]{repo: repo, config: config}
+}
+
+// CollectionName returns the name of the partition collection covering the given time.
+//
+// Parameters:
+//   - t: The time to locate.
+//
+// Returns:
+//   - The partition collection name.
+func (pm *PartitionManager[T]) CollectionName(t time.Time) string {
+	return pm.config.Prefix + "_" + pm.start(t).Format(pm.layout())
+}
+
+// For returns a repository view bound to the partition covering the given time.
+//
+// Parameters:
+//   - t: The time to locate.
+//
+// Returns:
+//   - A pointer to a Repository operating on the partition collection.
+func (pm *PartitionManager[T]) For(t time.Time) *Repository[T] {
+	return pm.repo.OnCollection(pm.CollectionName(t))
+}
+
+// Create inserts the entity into the partition matching its TimeField.
+// When the TimeField is zero, it is set to the current time, so the entity can be found in its partition later.
+//
+// Parameters:
+//   - entity: A pointer to the entity of type `T` to be inserted.
+//
+// Returns:
+//   - An error if the insertion fails.
+func (pm *PartitionManager[T]) Create(entity *T) error {
+	er := NewEntityReflection(pm.repo.config, entity)
+	t := er.GetTimeField(pm.config.TimeField)
+	if t.IsZero() {
+		t = time.Now()
+		er.SetField(pm.config.TimeField, t)
+	}
+
+	return pm.For(t).Create(entity)
+}
+
+// EnsurePartitions creates the current partition and the configured upcoming ones, along with their indexes.
+//
+// Returns:
+//   - An error if a collection or an index cannot be created.
+func (pm *PartitionManager[T]) EnsurePartitions() error {
+	ctx := pm.repo.config.Context
+	current := pm.start(time.Now())
+
+	for i := 0; i <= pm.config.Lookahead; i++ {
+		name := pm.CollectionName(pm.next(current, i))
+
+		err := pm.repo.Database().CreateCollection(ctx, name)
+		var cmdErr mongo.CommandError
+		if err != nil && !(errors.As(err, &cmdErr) && cmdErr.Name == "NamespaceExists") {
+			return err
+		}
+
+		if len(pm.config.Indexes) > 0 {
+			if _, err := pm.repo.OnCollection(name).Collection().Indexes().CreateMany(ctx, pm.config.Indexes); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Partitions lists the existing partition collections in chronological order.
+//
+// Returns:
+//   - The names of the partition collections.
+//   - An error if the collections cannot be listed.
+func (pm *PartitionManager[T]) Partitions() ([]string, error) {
+	names, err := pm.repo.Database().ListCollectionNames(pm.repo.config.Context, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	var partitions []string
+	for _, name := range names {
+		if _, ok := pm.parse(name); ok {
+			partitions = append(partitions, name)
+		}
+	}
+
+	// the layouts are zero padded, so lexical order is chronological
+	sort.Strings(partitions)
+	return partitions, nil
+}
+
+// Find fans out a query across the existing partitions overlapping [from, to) and concatenates
+// the results in chronological order. The TimeField range is added to the query.
+// Options such as limit or sort apply to each partition individually.
+//
+// Parameters:
+//   - from: The inclusive lower bound of the time range.
+//   - to: The exclusive upper bound of the time range.
+//   - query: A BSON map defining the search criteria.
+//   - opts: Optional FindOptions applied to each partition query.
+//
+// Returns:
+//   - A slice of pointers to entities of type `T` that match the query.
+//   - An error if any partition query fails.
+func (pm *PartitionManager[T]) Find(from, to time.Time, query bson.M, opts ...*options.FindOptions) ([]*T, error) {
+	partitions, err := pm.Partitions()
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{}
+	for k, v := range query {
+		filter[k] = v
+	}
+	filter[pm.repo.fieldKey(pm.config.TimeField)] = bson.M{"$gte": from, "$lt": to}
+
+	var entities []*T
+	for _, name := range partitions {
+		start, _ := pm.parse(name)
+		if !start.Before(to) || !pm.next(start, 1).After(from) {
+			continue
+		}
+
+		partial, err := pm.repo.OnCollection(name).Find(filter, opts...)
+		if err != nil {
+			return nil, err
+		}
+		entities = append(entities, partial...)
+	}
+
+	return entities, nil
+}
+
+// DropExpired drops the partitions that ended before now - Retention.
+// It does nothing when no Retention is configured.
+//
+// Returns:
+//   - The names of the dropped partitions.
+//   - An error if a partition cannot be listed or dropped.
+func (pm *PartitionManager[T]) DropExpired() ([]string, error) {
+	if pm.config.Retention <= 0 {
+		return nil, nil
+	}
+
+	partitions, err := pm.Partitions()
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-pm.config.Retention)
+	var dropped []string
+	for _, name := range partitions {
+		start, _ := pm.parse(name)
+		if pm.next(start, 1).After(cutoff) {
+			continue
+		}
+
+		if err := pm.repo.OnCollection(name).Collection().Drop(pm.repo.config.Context); err != nil {
+			return dropped, err
+		}
+		dropped = append(dropped, name)
+	}
+
+	return dropped, nil
+}
+
+// layout returns the time layout used as collection name suffix.
+func (pm *PartitionManager[T]) layout() string {
+	if pm.config.Interval == PartitionMonthly {
+		return "2006_01"
+	}
+
+	return "2006_01_02"
+}
+
+// start truncates a time to the beginning of its partition, in UTC.
+func (pm *PartitionManager[T]) start(t time.Time) time.Time {
+	t = t.UTC()
+	if pm.config.Interval == PartitionMonthly {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// next returns the start of the n-th partition after the one starting at start.
+func (pm *PartitionManager[T]) next(start time.Time, n int) time.Time {
+	if pm.config.Interval == PartitionMonthly {
+		return start.AddDate(0, n, 0)
+	}
+
+	return start.AddDate(0, 0, n)
+}
+
+// parse extracts the partition start from a collection name.
+// It reports false when the name is not a partition of this manager.
+func (pm *PartitionManager[T]) parse(name string) (time.Time, bool) {
+	suffix, ok := strings.CutPrefix(name, pm.config.Prefix+"_")
+	if !ok {
+		return time.Time{}, false
+	}
+
+	start, err := time.Parse(pm.layout(), suffix)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return start, true
+}
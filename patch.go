@@ -0,0 +1,495 @@
+package mongorepo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PatchFormat identifies the format of a patch document.
+type PatchFormat int
+
+const (
+	MergePatch PatchFormat = iota // JSON Merge Patch (RFC 7386): an object of values to set, null removes a field.
+	JSONPatch                     // JSON Patch (RFC 6902): an array of add, replace, remove and test operations.
+)
+
+var (
+	// ErrInvalidPatch is returned by ApplyJSONPatch when the patch is malformed or does not match the entity schema.
+	ErrInvalidPatch = errors.New("mongorepo: invalid patch")
+
+	// ErrPatchTestFailed is returned by ApplyJSONPatch when a JSON Patch "test" operation does not hold.
+	ErrPatchTestFailed = errors.New("mongorepo: patch test operation failed")
+)
+
+// ApplyJSONPatch validates a patch against the schema of `T` (paths use the BSON keys, values must decode into
+// the field types), converts it into $set/$unset operations and applies them in a single atomic update.
+// JSON Patch "test" operations become conditions of the update filter; "move" and "copy" are not supported.
+// UpdatedAt and the VersionField are maintained when configured. The fields maintained by the repository (the ID,
+// CreatedAt, DeletedAt, the VersionField, the region field and the dedupe key) cannot be patched. When the patch
+// changes DedupeFields, the document is loaded to recompute its dedupe key, and the update only applies if the
+// stored dedupe key did not change in between.
+// The document is selected by ID, so on sharded collections add "test" operations on the shard key fields
+// to route the update to a single shard.
+//
+// Parameters:
+//   - id: The ID of the document to patch.
+//   - patch: The patch document.
+//   - format: The format of the patch, MergePatch or JSONPatch.
+//
+// Returns:
+//   - ErrInvalidPatch if the patch is malformed or does not match the schema.
+//   - ErrPatchTestFailed if a test operation does not hold.
+//   - ErrNotFound if no document has the given id.
+//   - ErrStaleDocument if the patch changes DedupeFields and the document changed since it was loaded.
+//   - An error if the update fails.
+func (r *Repository[T]) ApplyJSONPatch(id any, patch []byte, format PatchFormat) error {
+	entityType := reflect.TypeOf((*T)(nil)).Elem()
+	set, unset, push, tests := bson.M{}, bson.M{}, bson.M{}, bson.M{}
+
+	var err error
+	switch format {
+	case MergePatch:
+		err = mergePatchOperations(entityType, nil, patch, set, unset)
+	case JSONPatch:
+		err = jsonPatchOperations(entityType, patch, set, unset, push, tests)
+	default:
+		err = fmt.Errorf("%w: unknown format %d", ErrInvalidPatch, format)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, key := range r.maintainedKeys() {
+		for _, operations := range []bson.M{set, unset, push} {
+			for path := range operations {
+				if overlaps(path, key) {
+					return fmt.Errorf("%w: %q is maintained by the repository", ErrInvalidPatch, key)
+				}
+			}
+		}
+	}
+
+	if err := r.guardRegion(); err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": id}
+	for path, value := range tests {
+		filter[path] = value
+	}
+	scoped := r.scopeFilter(filter)
+
+	dedupe := r.config.DedupeKeyField != "" && r.patchesDedupeFields(set, unset, push)
+	if dedupe {
+		start := time.Now()
+		raw, err := r.Collection().FindOne(r.config.Context, scoped, r.findOneComment("ApplyJSONPatch")).Raw()
+		r.observe("ApplyJSONPatch", scoped, start, singleResult(err), err)
+		if errors.Is(err, mongo.ErrNoDocuments) && len(tests) > 0 {
+			return ErrPatchTestFailed
+		}
+		if err != nil {
+			return classify(err)
+		}
+
+		patched, err := r.patchedDedupeKey(raw, set, unset, push)
+		if err != nil {
+			return err
+		}
+		key := r.fieldKey(r.config.DedupeKeyField)
+
+		stored := bson.M{key: bson.M{"$exists": false}}
+		if value, err := raw.LookupErr(key); err == nil {
+			stored = bson.M{key: value}
+		}
+		set[key] = patched
+		scoped = r.scopeFilter(bson.M{"$and": bson.A{filter, stored}})
+	}
+
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	if len(push) > 0 {
+		update["$push"] = push
+	}
+
+	start := time.Now()
+	result, err := r.Collection().UpdateOne(r.config.Context, scoped, r.stampUpdate(update), r.updateComment("ApplyJSONPatch"))
+	r.observe("ApplyJSONPatch", scoped, start, 0, err)
+	if err != nil {
+		return classify(err)
+	}
+
+	if result.MatchedCount == 0 {
+		switch {
+		case dedupe:
+			return ErrStaleDocument
+		case len(tests) > 0:
+			return ErrPatchTestFailed
+		}
+		return errNoDocuments
+	}
+
+	return nil
+}
+
+// maintainedKeys returns the BSON keys of the fields maintained by the repository, which patches cannot change.
+func (r *Repository[T]) maintainedKeys() []string {
+	keys := []string{"_id"}
+	for _, field := range []string{r.config.IdField, r.config.CreatedAtField, r.config.DeletedAtField, r.config.VersionField, r.config.DedupeKeyField} {
+		if field != "" {
+			keys = append(keys, r.fieldKey(field))
+		}
+	}
+	if r.region != nil {
+		keys = append(keys, r.region.key)
+	}
+
+	return keys
+}
+
+// patchesDedupeFields reports whether a patch changes one of the DedupeFields.
+func (r *Repository[T]) patchesDedupeFields(operations ...bson.M) bool {
+	for _, field := range r.config.DedupeFields {
+		key := r.fieldKey(field)
+		for _, paths := range operations {
+			for path := range paths {
+				if overlaps(path, key) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// patchedDedupeKey applies the operations of a patch to a copy of the stored document and computes the dedupe key
+// of the result, the way setDedupeKey computes it for a whole entity.
+func (r *Repository[T]) patchedDedupeKey(raw bson.Raw, set, unset, push bson.M) (string, error) {
+	decoder, err := bson.NewDecoder(bsonrw.NewBSONDocumentReader(raw))
+	if err != nil {
+		return "", err
+	}
+	decoder.DefaultDocumentM()
+
+	var stored bson.M
+	if err := decoder.Decode(&stored); err != nil {
+		return "", err
+	}
+
+	var doc any = stored
+	for path, value := range set {
+		doc = patchValue(doc, strings.Split(path, "."), true, func(any) (any, bool) { return value, true })
+	}
+	for path := range unset {
+		doc = patchValue(doc, strings.Split(path, "."), false, func(any) (any, bool) { return nil, false })
+	}
+	for path, each := range push {
+		doc = patchValue(doc, strings.Split(path, "."), true, func(current any) (any, bool) {
+			values, _ := current.(bson.A)
+			return append(values, each.(bson.M)["$each"].(bson.A)...), true
+		})
+	}
+
+	patched, err := bson.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+
+	var entity T
+	if err := bson.Unmarshal(patched, &entity); err != nil {
+		return "", err
+	}
+
+	return r.dedupeKey(NewEntityReflection(r.config, &entity)), nil
+}
+
+// patchValue applies fn to the value at a path of a decoded document, the way the update operators do: missing
+// documents are created when create is set, arrays are padded with nulls, and a removed array element becomes null.
+// fn gets the current value (nil when missing) and returns the new one, or false to remove it.
+func patchValue(node any, path []string, create bool, fn func(current any) (any, bool)) any {
+	if array, ok := node.(bson.A); ok {
+		i, err := strconv.Atoi(path[0])
+		if err != nil || i < 0 || (!create && i >= len(array)) {
+			return array
+		}
+		for len(array) <= i {
+			array = append(array, nil)
+		}
+
+		if len(path) == 1 {
+			value, _ := fn(array[i])
+			array[i] = value
+		} else {
+			array[i] = patchValue(array[i], path[1:], create, fn)
+		}
+		return array
+	}
+
+	doc, ok := node.(bson.M)
+	if !ok {
+		if !create {
+			return node
+		}
+		doc = bson.M{}
+	}
+
+	current, exists := doc[path[0]]
+	switch {
+	case len(path) == 1:
+		if value, keep := fn(current); keep {
+			doc[path[0]] = value
+		} else {
+			delete(doc, path[0])
+		}
+	case exists || create:
+		doc[path[0]] = patchValue(current, path[1:], create, fn)
+	}
+
+	return doc
+}
+
+// overlaps reports whether two dotted paths designate the same field, or one is inside the other.
+func overlaps(path, key string) bool {
+	return path == key || strings.HasPrefix(path, key+".") || strings.HasPrefix(key, path+".")
+}
+
+// mergePatchOperations converts a JSON Merge Patch object into $set/$unset operations.
+// Nested objects targeting structs or maps are merged field by field.
+func mergePatchOperations(t reflect.Type, path []string, patch []byte, set, unset bson.M) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &fields); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidPatch, err.Error())
+	}
+
+	for key, raw := range fields {
+		fieldPath := append(append([]string{}, path...), key)
+		fieldType, err := patchFieldType(t, fieldPath)
+		if err != nil {
+			return err
+		}
+
+		dotted := strings.Join(fieldPath, ".")
+		switch {
+		case bytes.Equal(bytes.TrimSpace(raw), []byte("null")):
+			unset[dotted] = ""
+		case isMergeable(fieldType) && bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")):
+			if err := mergePatchOperations(t, fieldPath, raw, set, unset); err != nil {
+				return err
+			}
+		default:
+			value, err := decodePatchValue(fieldType, raw, dotted)
+			if err != nil {
+				return err
+			}
+			set[dotted] = value
+		}
+	}
+
+	return nil
+}
+
+// jsonPatchOperation is an operation of a JSON Patch document.
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// jsonPatchOperations converts a JSON Patch document into $set/$unset/$push operations and test conditions.
+func jsonPatchOperations(t reflect.Type, patch []byte, set, unset, push, tests bson.M) error {
+	var operations []jsonPatchOperation
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidPatch, err.Error())
+	}
+
+	for _, operation := range operations {
+		path, err := parseJSONPointer(operation.Path)
+		if err != nil {
+			return err
+		}
+
+		// "add" to the "-" index appends to an array
+		if operation.Op == "add" && path[len(path)-1] == "-" {
+			parent := path[:len(path)-1]
+			parentType, err := patchFieldType(t, parent)
+			if err != nil {
+				return err
+			}
+			if !isSequence(parentType) {
+				return fmt.Errorf("%w: %q is not an array", ErrInvalidPatch, operation.Path)
+			}
+
+			dotted := strings.Join(parent, ".")
+			value, err := decodePatchValue(derefType(parentType).Elem(), operation.Value, dotted)
+			if err != nil {
+				return err
+			}
+
+			each, _ := push[dotted].(bson.M)
+			if each == nil {
+				each = bson.M{"$each": bson.A{}}
+				push[dotted] = each
+			}
+			each["$each"] = append(each["$each"].(bson.A), value)
+			continue
+		}
+
+		fieldType, err := patchFieldType(t, path)
+		if err != nil {
+			return err
+		}
+		dotted := strings.Join(path, ".")
+
+		switch operation.Op {
+		case "add", "replace", "test":
+			value, err := decodePatchValue(fieldType, operation.Value, dotted)
+			if err != nil {
+				return err
+			}
+			if operation.Op == "test" {
+				tests[dotted] = value
+			} else {
+				set[dotted] = value
+			}
+		case "remove":
+			if parentType, _ := patchFieldType(t, path[:len(path)-1]); parentType != nil && isSequence(parentType) {
+				return fmt.Errorf("%w: removing array elements is not supported (%s)", ErrInvalidPatch, operation.Path)
+			}
+			unset[dotted] = ""
+		default:
+			return fmt.Errorf("%w: unsupported operation %q", ErrInvalidPatch, operation.Op)
+		}
+	}
+
+	return nil
+}
+
+// parseJSONPointer splits a JSON Pointer (RFC 6901) into path segments.
+func parseJSONPointer(pointer string) ([]string, error) {
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: invalid path %q", ErrInvalidPatch, pointer)
+	}
+
+	segments := strings.Split(pointer[1:], "/")
+	for i, segment := range segments {
+		segments[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(segment)
+	}
+
+	return segments, nil
+}
+
+// patchFieldType resolves the Go type stored at a BSON path of the entity, rejecting unknown and immutable fields.
+func patchFieldType(t reflect.Type, path []string) (reflect.Type, error) {
+	if len(path) > 0 && path[0] == "_id" {
+		return nil, fmt.Errorf("%w: _id cannot be patched", ErrInvalidPatch)
+	}
+
+	current := t
+	for i, segment := range path {
+		for current.Kind() == reflect.Pointer {
+			current = current.Elem()
+		}
+
+		switch current.Kind() {
+		case reflect.Struct:
+			field, ok := structFieldByKey(current, segment)
+			if !ok {
+				return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidPatch, strings.Join(path[:i+1], "."))
+			}
+			current = field.Type
+		case reflect.Slice, reflect.Array:
+			if _, err := strconv.Atoi(segment); err != nil {
+				return nil, fmt.Errorf("%w: %q is not an array index", ErrInvalidPatch, strings.Join(path[:i+1], "."))
+			}
+			current = current.Elem()
+		case reflect.Map:
+			current = current.Elem()
+		case reflect.Interface:
+			return current, nil
+		default:
+			return nil, fmt.Errorf("%w: %q is not a document", ErrInvalidPatch, strings.Join(path[:i], "."))
+		}
+	}
+
+	return current, nil
+}
+
+// structFieldByKey finds the struct field stored under a BSON key, looking into inlined structs.
+func structFieldByKey(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("bson")
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+
+		if strings.Contains(tag, ",inline") && field.Type.Kind() == reflect.Struct {
+			if nested, ok := structFieldByKey(field.Type, key); ok {
+				return nested, true
+			}
+			continue
+		}
+
+		if bsonFieldName(t, field.Name) == key {
+			return field, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+// decodePatchValue decodes a JSON value into the Go type of the patched field.
+func decodePatchValue(t reflect.Type, raw json.RawMessage, path string) (any, error) {
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("%w: missing value for %q", ErrInvalidPatch, path)
+	}
+
+	value := reflect.New(t)
+	if err := json.Unmarshal(raw, value.Interface()); err != nil {
+		return nil, fmt.Errorf("%w: invalid value for %q: %s", ErrInvalidPatch, path, err.Error())
+	}
+
+	return value.Elem().Interface(), nil
+}
+
+// derefType returns the type pointed to by pointer types.
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+// isMergeable reports whether a merge patch object targeting the type is merged key by key.
+func isMergeable(t reflect.Type) bool {
+	t = derefType(t)
+
+	if t.Kind() == reflect.Map {
+		return true
+	}
+
+	return t.Kind() == reflect.Struct && t != reflect.TypeOf(time.Time{}) && t.PkgPath() != "go.mongodb.org/mongo-driver/bson/primitive"
+}
+
+// isSequence reports whether the type is stored as a BSON array.
+func isSequence(t reflect.Type) bool {
+	t = derefType(t)
+
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() != reflect.Uint8
+}
@@ -0,0 +1,259 @@
+package mongorepo
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+type patchProfile struct {
+	City string `bson:"city"`
+}
+
+type patchEntity struct {
+	ID      primitive.ObjectID `bson:"_id"`
+	Status  string             `bson:"status"`
+	Count   int                `bson:"count"`
+	Tags    []string           `bson:"tags"`
+	Profile patchProfile       `bson:"profile"`
+}
+
+func TestJSONPatchOperations(t *testing.T) {
+	tests := []struct {
+		name                     string
+		patch                    string
+		set, unset, push, checks bson.M
+		err                      bool
+	}{
+		{
+			name:  "replace and add",
+			patch: `[{"op": "replace", "path": "/status", "value": "published"}, {"op": "add", "path": "/count", "value": 3}]`,
+			set:   bson.M{"status": "published", "count": 3},
+		},
+		{
+			name:  "nested path",
+			patch: `[{"op": "replace", "path": "/profile/city", "value": "Salto"}]`,
+			set:   bson.M{"profile.city": "Salto"},
+		},
+		{
+			name:  "append to an array",
+			patch: `[{"op": "add", "path": "/tags/-", "value": "a"}, {"op": "add", "path": "/tags/-", "value": "b"}]`,
+			push:  bson.M{"tags": bson.M{"$each": bson.A{"a", "b"}}},
+		},
+		{
+			name:   "test and remove",
+			patch:  `[{"op": "test", "path": "/status", "value": "draft"}, {"op": "remove", "path": "/profile/city"}]`,
+			unset:  bson.M{"profile.city": ""},
+			checks: bson.M{"status": "draft"},
+		},
+		{name: "empty patch", patch: `[]`},
+		{name: "malformed document", patch: `{"op": "add"}`, err: true},
+		{name: "unsupported operation", patch: `[{"op": "move", "from": "/status", "path": "/count"}]`, err: true},
+		{name: "relative path", patch: `[{"op": "replace", "path": "status", "value": "x"}]`, err: true},
+		{name: "unknown field", patch: `[{"op": "replace", "path": "/unknown", "value": "x"}]`, err: true},
+		{name: "id", patch: `[{"op": "replace", "path": "/_id", "value": "x"}]`, err: true},
+		{name: "value of another type", patch: `[{"op": "replace", "path": "/count", "value": "three"}]`, err: true},
+		{name: "missing value", patch: `[{"op": "replace", "path": "/status"}]`, err: true},
+		{name: "append to a scalar", patch: `[{"op": "add", "path": "/status/-", "value": "x"}]`, err: true},
+		{name: "remove an array element", patch: `[{"op": "remove", "path": "/tags/0"}]`, err: true},
+		{name: "index of a document", patch: `[{"op": "replace", "path": "/tags/first", "value": "x"}]`, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			set, unset, push, checks := bson.M{}, bson.M{}, bson.M{}, bson.M{}
+			err := jsonPatchOperations(reflect.TypeOf(patchEntity{}), []byte(test.patch), set, unset, push, checks)
+			if test.err {
+				if !errors.Is(err, ErrInvalidPatch) {
+					t.Fatalf("expected ErrInvalidPatch, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for name, pair := range map[string][2]bson.M{"set": {set, test.set}, "unset": {unset, test.unset}, "push": {push, test.push}, "tests": {checks, test.checks}} {
+				got, expected := pair[0], pair[1]
+				if expected == nil {
+					expected = bson.M{}
+				}
+				if !reflect.DeepEqual(got, expected) {
+					t.Errorf("%s: got %v, expected %v", name, got, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestParseJSONPointer(t *testing.T) {
+	tests := []struct {
+		pointer  string
+		expected []string
+		err      bool
+	}{
+		{pointer: "/status", expected: []string{"status"}},
+		{pointer: "/profile/city", expected: []string{"profile", "city"}},
+		{pointer: "/a~1b/c~0d", expected: []string{"a/b", "c~d"}},
+		{pointer: "/~01", expected: []string{"~1"}},
+		{pointer: "status", err: true},
+		{pointer: "", err: true},
+	}
+
+	for _, test := range tests {
+		path, err := parseJSONPointer(test.pointer)
+		if test.err {
+			if !errors.Is(err, ErrInvalidPatch) {
+				t.Errorf("%q: expected ErrInvalidPatch, got %v", test.pointer, err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(path, test.expected) {
+			t.Errorf("%q: got %v (%v), expected %v", test.pointer, path, err, test.expected)
+		}
+	}
+}
+
+type patchedListing struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	Title     string             `bson:"title"`
+	Address   patchProfile       `bson:"address"`
+	Price     int                `bson:"price"`
+	CreatedAt time.Time          `bson:"created_at"`
+	DeletedAt *time.Time         `bson:"deleted_at,omitempty"`
+	Version   int64              `bson:"version"`
+	DedupeKey string             `bson:"dedupe_key"`
+}
+
+// patchedListings returns a repository of listings maintaining every field rejected by ApplyJSONPatch.
+func patchedListings(client *mongo.Client) *Repository[patchedListing] {
+	return New[patchedListing](&Config{
+		MongoClient:    client,
+		DbName:         "homes",
+		CreatedAtField: "CreatedAt",
+		DeletedAtField: "DeletedAt",
+		VersionField:   "Version",
+		DedupeFields:   []string{"Title", "Address"},
+		DedupeKeyField: "DedupeKey",
+	})
+}
+
+func TestApplyJSONPatchMaintainedFields(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	patches := map[string]string{
+		"_id":        `[{"op": "replace", "path": "/_id", "value": "000000000000000000000000"}]`,
+		"created_at": `{"created_at": "2024-01-01T00:00:00Z"}`,
+		"deleted_at": `{"deleted_at": null}`,
+		"version":    `{"version": 1}`,
+		"dedupe_key": `{"dedupe_key": "forged"}`,
+	}
+
+	for field, patch := range patches {
+		mt.Run(field, func(mt *mtest.T) {
+			format := MergePatch
+			if strings.HasPrefix(patch, "[") {
+				format = JSONPatch
+			}
+
+			err := patchedListings(mt.Client).ApplyJSONPatch(primitive.NewObjectID(), []byte(patch), format)
+			if !errors.Is(err, ErrInvalidPatch) {
+				mt.Errorf("got %v, expected ErrInvalidPatch", err)
+			}
+			if event := mt.GetStartedEvent(); event != nil {
+				mt.Errorf("the rejected patch reached the server: %s", event.CommandName)
+			}
+		})
+	}
+}
+
+func TestApplyJSONPatchDedupeKey(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	id := primitive.NewObjectID()
+
+	tests := []struct {
+		name     string
+		patch    string
+		expected *patchedListing // the listing whose dedupe key is written, nil when the key is left untouched
+	}{
+		{name: "dedupe field", patch: `{"title": "Loft"}`, expected: &patchedListing{Title: "Loft", Address: patchProfile{City: "Salto"}}},
+		{name: "nested dedupe field", patch: `{"address": {"city": "Paysandú"}}`, expected: &patchedListing{Title: "House", Address: patchProfile{City: "Paysandú"}}},
+		{name: "other field", patch: `{"price": 100}`},
+	}
+
+	for _, test := range tests {
+		mt.Run(test.name, func(mt *mtest.T) {
+			repo := patchedListings(mt.Client)
+			if test.expected != nil {
+				mt.AddMockResponses(mtest.CreateCursorResponse(0, "homes.patched_listings", mtest.FirstBatch, bson.D{
+					{Key: "_id", Value: id},
+					{Key: "title", Value: "House"},
+					{Key: "address", Value: bson.D{{Key: "city", Value: "Salto"}}},
+					{Key: "dedupe_key", Value: "stored"},
+				}))
+			}
+			mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+
+			if err := repo.ApplyJSONPatch(id, []byte(test.patch), MergePatch); err != nil {
+				mt.Fatal(err)
+			}
+
+			var update bson.Raw
+			for _, event := range mt.GetAllStartedEvents() {
+				if event.CommandName == "update" {
+					update = event.Command.Lookup("updates", "0").Document()
+				}
+			}
+
+			key, err := update.LookupErr("u", "$set", "dedupe_key")
+			if test.expected == nil {
+				if err == nil {
+					mt.Errorf("the patch of another field wrote the dedupe key %v", key)
+				}
+				return
+			}
+			if expected := repo.dedupeKey(NewEntityReflection(repo.config, test.expected)); key.StringValue() != expected {
+				mt.Errorf("got the dedupe key %v, expected %s", key, expected)
+			}
+			if !strings.Contains(update.Lookup("q").String(), `"stored"`) {
+				mt.Errorf("the update filter %v is not conditioned on the stored dedupe key", update.Lookup("q"))
+			}
+		})
+	}
+}
+
+func TestPatchValue(t *testing.T) {
+	set := func(value any) func(any) (any, bool) { return func(any) (any, bool) { return value, true } }
+	remove := func(any) (any, bool) { return nil, false }
+
+	tests := []struct {
+		name     string
+		doc      bson.M
+		path     string
+		create   bool
+		fn       func(any) (any, bool)
+		expected bson.M
+	}{
+		{name: "set", doc: bson.M{"a": 1}, path: "b", create: true, fn: set(2), expected: bson.M{"a": 1, "b": 2}},
+		{name: "set creates documents", doc: bson.M{}, path: "a.b", create: true, fn: set(1), expected: bson.M{"a": bson.M{"b": 1}}},
+		{name: "set pads arrays", doc: bson.M{"a": bson.A{1}}, path: "a.2", create: true, fn: set(3), expected: bson.M{"a": bson.A{1, nil, 3}}},
+		{name: "unset", doc: bson.M{"a": bson.M{"b": 1, "c": 2}}, path: "a.b", fn: remove, expected: bson.M{"a": bson.M{"c": 2}}},
+		{name: "unset of a missing field", doc: bson.M{"a": 1}, path: "b.c", fn: remove, expected: bson.M{"a": 1}},
+		{name: "unset of an array element", doc: bson.M{"a": bson.A{1, 2}}, path: "a.0", fn: remove, expected: bson.M{"a": bson.A{nil, 2}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := patchValue(test.doc, strings.Split(test.path, "."), test.create, test.fn); !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("got %v, expected %v", got, test.expected)
+			}
+		})
+	}
+}
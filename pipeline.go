@@ -0,0 +1,208 @@
+package mongorepo
+
+import (
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PipelineBuilder builds an aggregation pipeline stage by stage, with a typed method per common stage,
+// instead of hand-written nested bson.D stages. Expressions keep their "$" field paths (e.g. "$amount"),
+// while the fields taken by Lookup, Unwind and Count are plain names.
+//
+//	pipeline := mongorepo.Pipeline().
+//		Match(bson.M{"status": "paid"}).
+//		Group("$customer_id", bson.M{"total": bson.M{"$sum": "$amount"}}).
+//		Sort(bson.D{{Key: "total", Value: -1}}).
+//		Limit(10).
+//		Build()
+//	cursor, err := repo.Aggregate(&pipeline)
+type PipelineBuilder struct {
+	stages mongo.Pipeline
+}
+
+// Pipeline starts an empty aggregation pipeline.
+//
+// Returns:
+//   - A pointer to a PipelineBuilder.
+func Pipeline() *PipelineBuilder {
+	return &PipelineBuilder{stages: mongo.Pipeline{}}
+}
+
+// Match adds a $match stage.
+//
+// Parameters:
+//   - filter: The query filter, e.g. bson.M{"status": "paid"}.
+//
+// Returns:
+//   - The builder, for chaining.
+func (p *PipelineBuilder) Match(filter any) *PipelineBuilder {
+	return p.Stage("$match", filter)
+}
+
+// Group adds a $group stage.
+//
+// Parameters:
+//   - id: The group key expression, e.g. "$customer_id", bson.M{"year": bson.M{"$year": "$created_at"}} or nil for a single group.
+//   - accumulators: The computed fields, e.g. bson.M{"total": bson.M{"$sum": "$amount"}}; may be nil.
+//
+// Returns:
+//   - The builder, for chaining.
+func (p *PipelineBuilder) Group(id any, accumulators bson.M) *PipelineBuilder {
+	fields := make([]string, 0, len(accumulators))
+	for field := range accumulators {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	group := bson.D{{Key: "_id", Value: id}}
+	for _, field := range fields {
+		group = append(group, bson.E{Key: field, Value: accumulators[field]})
+	}
+
+	return p.Stage("$group", group)
+}
+
+// Sort adds a $sort stage.
+//
+// Parameters:
+//   - sort: The ordered sort specification, e.g. bson.D{{Key: "total", Value: -1}}.
+//
+// Returns:
+//   - The builder, for chaining.
+func (p *PipelineBuilder) Sort(sort bson.D) *PipelineBuilder {
+	return p.Stage("$sort", sort)
+}
+
+// Project adds a $project stage.
+//
+// Parameters:
+//   - projection: The projection, e.g. bson.M{"name": 1, "total": bson.M{"$multiply": bson.A{"$price", "$qty"}}}.
+//
+// Returns:
+//   - The builder, for chaining.
+func (p *PipelineBuilder) Project(projection any) *PipelineBuilder {
+	return p.Stage("$project", projection)
+}
+
+// AddFields adds an $addFields stage.
+//
+// Parameters:
+//   - fields: The fields to add or replace, e.g. bson.M{"total": bson.M{"$sum": "$items.price"}}.
+//
+// Returns:
+//   - The builder, for chaining.
+func (p *PipelineBuilder) AddFields(fields any) *PipelineBuilder {
+	return p.Stage("$addFields", fields)
+}
+
+// Skip adds a $skip stage.
+//
+// Parameters:
+//   - n: The number of documents to skip.
+//
+// Returns:
+//   - The builder, for chaining.
+func (p *PipelineBuilder) Skip(n int64) *PipelineBuilder {
+	return p.Stage("$skip", n)
+}
+
+// Limit adds a $limit stage.
+//
+// Parameters:
+//   - n: The maximum number of documents passed on.
+//
+// Returns:
+//   - The builder, for chaining.
+func (p *PipelineBuilder) Limit(n int64) *PipelineBuilder {
+	return p.Stage("$limit", n)
+}
+
+// Lookup adds a $lookup stage joining the documents of another collection by equality.
+//
+// Parameters:
+//   - from: The collection to join.
+//   - localField: The field of the input documents.
+//   - foreignField: The field of the documents of from.
+//   - as: The array field receiving the joined documents.
+//
+// Returns:
+//   - The builder, for chaining.
+func (p *PipelineBuilder) Lookup(from, localField, foreignField, as string) *PipelineBuilder {
+	return p.Stage("$lookup", bson.D{
+		{Key: "from", Value: from},
+		{Key: "localField", Value: localField},
+		{Key: "foreignField", Value: foreignField},
+		{Key: "as", Value: as},
+	})
+}
+
+// Unwind adds an $unwind stage outputting one document per element of an array field. Documents where
+// the array is missing or empty are dropped.
+//
+// Parameters:
+//   - field: The array field, e.g. "items".
+//
+// Returns:
+//   - The builder, for chaining.
+func (p *PipelineBuilder) Unwind(field string) *PipelineBuilder {
+	return p.Stage("$unwind", fieldPath(field))
+}
+
+// UnwindPreserveEmpty adds an $unwind stage like Unwind, keeping the documents where the array is
+// missing, null or empty.
+//
+// Parameters:
+//   - field: The array field, e.g. "items".
+//
+// Returns:
+//   - The builder, for chaining.
+func (p *PipelineBuilder) UnwindPreserveEmpty(field string) *PipelineBuilder {
+	return p.Stage("$unwind", bson.D{
+		{Key: "path", Value: fieldPath(field)},
+		{Key: "preserveNullAndEmptyArrays", Value: true},
+	})
+}
+
+// Count adds a $count stage.
+//
+// Parameters:
+//   - field: The field receiving the number of documents.
+//
+// Returns:
+//   - The builder, for chaining.
+func (p *PipelineBuilder) Count(field string) *PipelineBuilder {
+	return p.Stage("$count", field)
+}
+
+// Stage adds any other stage, e.g. Stage("$sample", bson.M{"size": 5}).
+//
+// Parameters:
+//   - name: The stage operator, e.g. "$facet".
+//   - value: The stage specification.
+//
+// Returns:
+//   - The builder, for chaining.
+func (p *PipelineBuilder) Stage(name string, value any) *PipelineBuilder {
+	p.stages = append(p.stages, bson.D{{Key: name, Value: value}})
+	return p
+}
+
+// Build returns the pipeline built so far; the builder can still be extended afterwards.
+//
+// Returns:
+//   - The aggregation pipeline.
+func (p *PipelineBuilder) Build() mongo.Pipeline {
+	return append(mongo.Pipeline{}, p.stages...)
+}
+
+// fieldPath returns the "$" prefixed path of a field.
+func fieldPath(field string) string {
+	if strings.HasPrefix(field, "$") {
+		return field
+	}
+
+	return "$" + field
+}
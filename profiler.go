@@ -0,0 +1,95 @@
+package mongorepo
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ProfilingLevel is the level of the database profiler.
+type ProfilingLevel int
+
+const (
+	ProfilingOff  ProfilingLevel = 0 // The profiler is off.
+	ProfilingSlow ProfilingLevel = 1 // Operations slower than the slowms threshold are profiled.
+	ProfilingAll  ProfilingLevel = 2 // Every operation is profiled.
+)
+
+// ProfileEntry is a document of the system.profile collection. Raw holds the full entry,
+// whose other fields depend on the operation and the server version.
+type ProfileEntry struct {
+	Op           string    `bson:"op"`
+	Namespace    string    `bson:"ns"`
+	Command      bson.Raw  `bson:"command,omitempty"`
+	Millis       int64     `bson:"millis"`
+	PlanSummary  string    `bson:"planSummary,omitempty"`
+	KeysExamined int64     `bson:"keysExamined,omitempty"`
+	DocsExamined int64     `bson:"docsExamined,omitempty"`
+	NReturned    int64     `bson:"nreturned,omitempty"`
+	Timestamp    time.Time `bson:"ts"`
+	Raw          bson.Raw  `bson:"-"`
+}
+
+// EnableProfiling sets the profiler level of the repository database. Profiling has a cost on busy servers,
+// remember to turn it off (ProfilingOff) when the investigation is over. Not supported by mongos.
+//
+// Parameters:
+//   - level: The profiling level.
+//   - slowMs: The threshold in milliseconds of slow operations, the server setting is kept when <= 0.
+//
+// Returns:
+//   - The profiling level that was active before the call.
+//   - An error if the command fails.
+func (r *Repository[T]) EnableProfiling(level ProfilingLevel, slowMs int) (ProfilingLevel, error) {
+	command := bson.D{{Key: "profile", Value: int(level)}}
+	if slowMs > 0 {
+		command = append(command, bson.E{Key: "slowms", Value: slowMs})
+	}
+
+	var result struct {
+		Was int `bson:"was"`
+	}
+	if err := r.Database().RunCommand(r.config.Context, command).Decode(&result); err != nil {
+		return ProfilingOff, err
+	}
+
+	return ProfilingLevel(result.Was), nil
+}
+
+// GetProfilerEntries reads the entries of the system.profile collection of the repository database
+// concerning the repository collection, most recent first.
+//
+// Parameters:
+//   - filter: A BSON map further selecting the entries, e.g. bson.M{"millis": bson.M{"$gt": 100}}; may be nil.
+//   - opts: Optional FindOptions, e.g. a limit.
+//
+// Returns:
+//   - The profiler entries.
+//   - An error if the query fails.
+func (r *Repository[T]) GetProfilerEntries(filter bson.M, opts ...*options.FindOptions) ([]ProfileEntry, error) {
+	query := bson.M{"ns": r.config.DbName + "." + r.config.CollectionName}
+	if len(filter) > 0 {
+		query = bson.M{"$and": bson.A{query, filter}}
+	}
+
+	ctx := r.config.Context
+	findOpts := append([]*options.FindOptions{options.Find().SetSort(bson.D{{Key: "ts", Value: -1}})}, opts...)
+	cursor, err := r.Database().Collection("system.profile").Find(ctx, query, findOpts...)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []ProfileEntry
+	for cursor.Next(ctx) {
+		var entry ProfileEntry
+		if err := cursor.Decode(&entry); err != nil {
+			return nil, err
+		}
+		entry.Raw = append(bson.Raw(nil), cursor.Current...)
+		entries = append(entries, entry)
+	}
+
+	return entries, cursor.Err()
+}
@@ -0,0 +1,105 @@
+package mongorepo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// viewProjections caches the projection of each entity type and view name, keyed by viewKey.
+var viewProjections sync.Map
+
+// viewKey identifies a view of an entity type.
+type viewKey struct {
+	entityType reflect.Type
+	view       string
+}
+
+// FindView retrieves the entities matching the query, fetching only the fields of a named view.
+// Views are declared with the `view` struct tag, listing the views a field belongs to:
+//
+//	Name  string `bson:"name" view:"list,detail"`
+//	Notes string `bson:"notes" view:"detail"`
+//
+// The _id is always fetched; fields outside the view keep their zero value.
+//
+// Parameters:
+//   - view: The name of the view, e.g. "list".
+//   - query: A BSON map defining the search criteria.
+//   - opts: Optional FindOptions; their projection is replaced by the one of the view.
+//
+// Returns:
+//   - A slice of pointers to the matching entities of type `T`.
+//   - An error if the operation fails.
+//
+// Panics:
+//   - If no field of `T` belongs to the view.
+func (r *Repository[T]) FindView(view string, query bson.M, opts ...*options.FindOptions) ([]*T, error) {
+	projection := options.Find().SetProjection(r.viewProjection(view))
+	return r.Find(query, append(opts, projection)...)
+}
+
+// FindOneView retrieves a single entity matching the query, fetching only the fields of a named view, see FindView.
+//
+// Parameters:
+//   - view: The name of the view, e.g. "detail".
+//   - query: A BSON map defining the search criteria.
+//   - opts: Optional FindOneOptions; their projection is replaced by the one of the view.
+//
+// Returns:
+//   - A pointer to the entity of type `T`.
+//   - ErrNotFound if no document matches, or an error if the operation fails.
+//
+// Panics:
+//   - If no field of `T` belongs to the view.
+func (r *Repository[T]) FindOneView(view string, query bson.M, opts ...*options.FindOneOptions) (*T, error) {
+	projection := options.FindOne().SetProjection(r.viewProjection(view))
+	return r.FindOne(query, append(opts, projection)...)
+}
+
+// viewProjection returns the cached projection of a view of `T`.
+func (r *Repository[T]) viewProjection(view string) bson.M {
+	entityType := reflect.TypeOf((*T)(nil)).Elem()
+	cacheKey := viewKey{entityType: entityType, view: view}
+
+	if projection, ok := viewProjections.Load(cacheKey); ok {
+		return projection.(bson.M)
+	}
+
+	projection := bson.M{}
+	collectViewFields(entityType, view, projection)
+	if len(projection) == 0 {
+		panic(fmt.Sprintf("Configuration error: No field of %s belongs to the view %q.", entityType.Name(), view))
+	}
+	projection["_id"] = 1
+
+	viewProjections.Store(cacheKey, projection)
+	return projection
+}
+
+// collectViewFields adds to the projection the BSON keys of the fields tagged with the view, looking into inlined structs.
+func collectViewFields(t reflect.Type, view string, projection bson.M) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("bson")
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+
+		if strings.Contains(tag, ",inline") && derefType(field.Type).Kind() == reflect.Struct {
+			collectViewFields(derefType(field.Type), view, projection)
+			continue
+		}
+
+		for _, name := range strings.Split(field.Tag.Get("view"), ",") {
+			if strings.TrimSpace(name) == view {
+				projection[bsonFieldName(t, field.Name)] = 1
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package mongorepo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// defaultDurationBuckets are the upper bounds in seconds of the operation duration histogram.
+var defaultDurationBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// metricLabelNames are the labels of the Prometheus series, in exposition order.
+var metricLabelNames = []string{"service", "entity", "collection", "operation"}
+
+// PrometheusMetrics is a ready-made Metrics collecting the operations of the repositories into Prometheus series
+// labelled by service, entity, collection and operation, served in the text exposition format by ServeHTTP:
+//
+//   - mongorepo_operations_total: the number of operations.
+//   - mongorepo_operation_errors_total: the number of failed operations, not counting the documents not found.
+//   - mongorepo_documents_returned_total: the number of documents returned by the reads.
+//   - mongorepo_operation_duration_seconds: the histogram of the durations.
+//
+// It needs no Prometheus client library; mount it on the metrics endpoint scraped by Prometheus, or on its own path.
+type PrometheusMetrics struct {
+	mu      sync.Mutex
+	buckets []float64
+	series  map[string]*operationSeries
+}
+
+// operationSeries holds the series of a label set.
+type operationSeries struct {
+	labels     []string
+	operations uint64
+	errors     uint64
+	documents  uint64
+	counts     []uint64 // The number of durations of each bucket, not cumulated.
+	sum        float64
+}
+
+// NewPrometheusMetrics creates an empty PrometheusMetrics.
+//
+// Parameters:
+//   - buckets: The upper bounds in seconds of the duration histogram, in increasing order, default: 1ms to 10s.
+//
+// Returns:
+//   - A pointer to a PrometheusMetrics, to be set as Config.Metrics.
+func NewPrometheusMetrics(buckets ...float64) *PrometheusMetrics {
+	if len(buckets) == 0 {
+		buckets = defaultDurationBuckets
+	}
+
+	return &PrometheusMetrics{buckets: append([]float64{}, buckets...), series: map[string]*operationSeries{}}
+}
+
+// ObserveOperation records an operation.
+//
+// Parameters:
+//   - operation: The measurements of the operation.
+func (p *PrometheusMetrics) ObserveOperation(operation OperationMetrics) {
+	labels := make([]string, len(metricLabelNames))
+	for i, name := range metricLabelNames {
+		labels[i] = operation.Labels[name]
+	}
+	key := strings.Join(labels, "\x00")
+	seconds := operation.Duration.Seconds()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	series, ok := p.series[key]
+	if !ok {
+		series = &operationSeries{labels: labels, counts: make([]uint64, len(p.buckets)+1)}
+		p.series[key] = series
+	}
+
+	series.operations++
+	if operation.Err != nil && !errors.Is(operation.Err, mongo.ErrNoDocuments) {
+		series.errors++
+	}
+	series.documents += uint64(operation.Documents)
+	series.counts[sort.SearchFloat64s(p.buckets, seconds)]++
+	series.sum += seconds
+}
+
+// ServeHTTP writes the series in the Prometheus text exposition format.
+func (p *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = p.Write(w)
+}
+
+// Write writes the series in the Prometheus text exposition format, e.g. to append them to another endpoint.
+//
+// Parameters:
+//   - w: The writer receiving the series.
+//
+// Returns:
+//   - An error if a write fails.
+func (p *PrometheusMetrics) Write(w io.Writer) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	keys := make([]string, 0, len(p.series))
+	for key := range p.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	counters := []struct {
+		name, help string
+		value      func(s *operationSeries) uint64
+	}{
+		{"mongorepo_operations_total", "The number of repository operations.", func(s *operationSeries) uint64 { return s.operations }},
+		{"mongorepo_operation_errors_total", "The number of failed repository operations.", func(s *operationSeries) uint64 { return s.errors }},
+		{"mongorepo_documents_returned_total", "The number of documents returned by the repository reads.", func(s *operationSeries) uint64 { return s.documents }},
+	}
+	for _, counter := range counters {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n", counter.name, counter.help, counter.name)
+		for _, key := range keys {
+			series := p.series[key]
+			fmt.Fprintf(&b, "%s{%s} %d\n", counter.name, promLabels(series.labels, ""), counter.value(series))
+		}
+	}
+
+	const histogram = "mongorepo_operation_duration_seconds"
+	fmt.Fprintf(&b, "# HELP %s The duration of the repository operations.\n# TYPE %s histogram\n", histogram, histogram)
+	for _, key := range keys {
+		series := p.series[key]
+		var cumulated uint64
+		for i, bound := range p.buckets {
+			cumulated += series.counts[i]
+			le := strconv.FormatFloat(bound, 'g', -1, 64)
+			fmt.Fprintf(&b, "%s_bucket{%s} %d\n", histogram, promLabels(series.labels, le), cumulated)
+		}
+		fmt.Fprintf(&b, "%s_bucket{%s} %d\n", histogram, promLabels(series.labels, "+Inf"), series.operations)
+		fmt.Fprintf(&b, "%s_sum{%s} %s\n", histogram, promLabels(series.labels, ""), strconv.FormatFloat(series.sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "%s_count{%s} %d\n", histogram, promLabels(series.labels, ""), series.operations)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// labelEscaper escapes the label values as the text exposition format requires.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// promLabels formats a label set, with the le label of a histogram bucket when le is set.
+func promLabels(values []string, le string) string {
+	pairs := make([]string, 0, len(values)+1)
+	for i, name := range metricLabelNames {
+		pairs = append(pairs, name+`="`+labelEscaper.Replace(values[i])+`"`)
+	}
+	if le != "" {
+		pairs = append(pairs, `le="`+le+`"`)
+	}
+
+	return strings.Join(pairs, ",")
+}
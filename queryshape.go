@@ -0,0 +1,176 @@
+package mongorepo
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// defaultMaxQueryShapes is the number of distinct shapes a QueryShapeRecorder tracks by default.
+	defaultMaxQueryShapes = 1000
+
+	// shapeSamples is the number of most recent durations kept per shape to compute the percentiles.
+	shapeSamples = 512
+)
+
+// QueryShapeRecorder counts the queries of the repositories configured with it (Config.QueryShapes) per query
+// shape: the filter or pipeline with its values replaced by "?" and its keys sorted, so the queries differing only
+// by their values are counted together. It keeps the latency percentiles of the most recent queries of each shape,
+// in memory, and is safe for concurrent use.
+type QueryShapeRecorder struct {
+	mu        sync.Mutex
+	maxShapes int
+	shapes    map[queryShapeKey]*queryShapeRecord
+	dropped   uint64
+}
+
+// queryShapeKey identifies a shape.
+type queryShapeKey struct {
+	collection, operation, shape string
+}
+
+// queryShapeRecord holds the measurements of a shape.
+type queryShapeRecord struct {
+	count, errors uint64
+	total, max    time.Duration
+	samples       []time.Duration // A ring of the most recent durations.
+	next          int
+}
+
+// QueryShapeStats are the measurements of a query shape, see QueryShapeRecorder.Report.
+type QueryShapeStats struct {
+	Collection string        `json:"collection"`
+	Operation  string        `json:"operation"` // The repository method, e.g. "Find".
+	Shape      string        `json:"shape"`     // e.g. {"status":"?","total":{"$gte":"?"}}
+	Count      uint64        `json:"count"`
+	Errors     uint64        `json:"errors"` // The failed queries, not counting the documents not found.
+	Mean       time.Duration `json:"mean_ns"`
+	P50        time.Duration `json:"p50_ns"` // The percentiles of the most recent queries of the shape.
+	P95        time.Duration `json:"p95_ns"`
+	P99        time.Duration `json:"p99_ns"`
+	Max        time.Duration `json:"max_ns"`
+}
+
+// QueryShapeReport is the content of a QueryShapeRecorder.
+type QueryShapeReport struct {
+	Shapes  []QueryShapeStats `json:"shapes"`  // The shapes, the most frequent first.
+	Dropped uint64            `json:"dropped"` // The queries not recorded because maxShapes distinct shapes were already tracked.
+}
+
+// NewQueryShapeRecorder creates an empty QueryShapeRecorder, shared by any number of repositories.
+//
+// Parameters:
+//   - maxShapes: The number of distinct shapes tracked, bounding its memory; the queries of further shapes are
+//     only counted as dropped. 1000 when <= 0.
+//
+// Returns:
+//   - A pointer to a QueryShapeRecorder, to be set as Config.QueryShapes.
+func NewQueryShapeRecorder(maxShapes int) *QueryShapeRecorder {
+	if maxShapes <= 0 {
+		maxShapes = defaultMaxQueryShapes
+	}
+
+	return &QueryShapeRecorder{maxShapes: maxShapes, shapes: map[queryShapeKey]*queryShapeRecord{}}
+}
+
+// Report returns the measurements recorded so far.
+//
+// Returns:
+//   - A pointer to a QueryShapeReport.
+func (q *QueryShapeRecorder) Report() *QueryShapeReport {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	report := &QueryShapeReport{Shapes: make([]QueryShapeStats, 0, len(q.shapes)), Dropped: q.dropped}
+	for key, record := range q.shapes {
+		samples := slices.Clone(record.samples)
+		slices.Sort(samples)
+
+		report.Shapes = append(report.Shapes, QueryShapeStats{
+			Collection: key.collection,
+			Operation:  key.operation,
+			Shape:      key.shape,
+			Count:      record.count,
+			Errors:     record.errors,
+			Mean:       record.total / time.Duration(record.count),
+			P50:        percentile(samples, 0.50),
+			P95:        percentile(samples, 0.95),
+			P99:        percentile(samples, 0.99),
+			Max:        record.max,
+		})
+	}
+
+	slices.SortFunc(report.Shapes, func(a, b QueryShapeStats) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Shape, b.Shape)
+	})
+
+	return report
+}
+
+// Reset forgets every shape, e.g. to measure a new deployment.
+func (q *QueryShapeRecorder) Reset() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.shapes = map[queryShapeKey]*queryShapeRecord{}
+	q.dropped = 0
+}
+
+// ServeHTTP writes the Report as JSON, e.g. on an internal debug endpoint.
+func (q *QueryShapeRecorder) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(q.Report())
+}
+
+// record adds a query of a shape.
+func (q *QueryShapeRecorder) record(collection, operation, shape string, duration time.Duration, err error) {
+	key := queryShapeKey{collection: collection, operation: operation, shape: shape}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	record := q.shapes[key]
+	if record == nil {
+		if len(q.shapes) >= q.maxShapes {
+			q.dropped++
+			return
+		}
+		record = &queryShapeRecord{}
+		q.shapes[key] = record
+	}
+
+	record.count++
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		record.errors++
+	}
+	record.total += duration
+	record.max = max(record.max, duration)
+
+	if len(record.samples) < shapeSamples {
+		record.samples = append(record.samples, duration)
+	} else {
+		record.samples[record.next] = duration
+		record.next = (record.next + 1) % shapeSamples
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted durations, 0 if there are none.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)]
+}
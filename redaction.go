@@ -0,0 +1,176 @@
+package mongorepo
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// rolesKey is the context key of the caller roles.
+type rolesKey struct{}
+
+// redactedField is a struct field restricted to roles, or holding structs that may have restricted fields.
+type redactedField struct {
+	index  int
+	roles  []string // The roles allowed to see the field, nil if the field is not restricted.
+	nested bool     // Whether the field may hold structs with restricted fields.
+}
+
+// redactedFields caches the redactedField of the struct types, keyed by reflect.Type.
+var redactedFields sync.Map
+
+// WithRoles returns a copy of ctx carrying the roles of the caller. Finders run with such a context, through
+// Config.Context or WithContext, strip the fields tagged with `roles:"..."` that none of the roles may see:
+//
+//	type User struct {
+//		Name  string `bson:"name"`
+//		Email string `bson:"email" roles:"admin,support"`
+//	}
+//
+//	user, err := repo.WithContext(mongorepo.WithRoles(ctx, "customer")).FindById(id) // user.Email is empty
+//
+// Without roles in the context, nothing is redacted. Redacted entities must not be written back with Update,
+// which would clear the redacted fields.
+//
+// Parameters:
+//   - ctx: The parent context.
+//   - roles: The roles of the caller.
+//
+// Returns:
+//   - The context carrying the roles.
+func WithRoles(ctx context.Context, roles ...string) context.Context {
+	return context.WithValue(ctx, rolesKey{}, append([]string{}, roles...))
+}
+
+// RolesFromContext returns the caller roles carried by ctx.
+//
+// Parameters:
+//   - ctx: The context.
+//
+// Returns:
+//   - The roles, and true if ctx carries roles.
+func RolesFromContext(ctx context.Context) ([]string, bool) {
+	roles, ok := ctx.Value(rolesKey{}).([]string)
+	return roles, ok
+}
+
+// unredacted returns a view of the repository whose finders do not redact, for loading shared data such as cache entries.
+func (r *Repository[T]) unredacted() *Repository[T] {
+	view := r.view()
+	view.config.Context = context.WithValue(r.config.Context, rolesKey{}, nil)
+	return view
+}
+
+// redact strips from entities the fields that the roles of the repository context may not see.
+func (r *Repository[T]) redact(entities ...*T) {
+	roles, ok := RolesFromContext(r.config.Context)
+	if !ok {
+		return
+	}
+
+	for _, entity := range entities {
+		if entity != nil {
+			redactValue(reflect.ValueOf(entity).Elem(), roles)
+		}
+	}
+}
+
+// redactValue zeroes the restricted fields of a value, recursing into structs, pointers, slices, arrays and maps.
+func redactValue(v reflect.Value, roles []string) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			redactValue(v.Elem(), roles)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			redactValue(v.Index(i), roles)
+		}
+	case reflect.Map:
+		// map values are not addressable: redact a copy and store it back
+		for _, key := range v.MapKeys() {
+			value := reflect.New(v.Type().Elem()).Elem()
+			value.Set(v.MapIndex(key))
+			redactValue(value, roles)
+			v.SetMapIndex(key, value)
+		}
+	case reflect.Struct:
+		if !v.CanSet() {
+			return
+		}
+		for _, field := range structRedactedFields(v.Type()) {
+			value := v.Field(field.index)
+			if field.roles != nil && !slices.ContainsFunc(roles, func(role string) bool { return slices.Contains(field.roles, role) }) {
+				value.SetZero()
+				continue
+			}
+			if field.nested {
+				redactValue(value, roles)
+			}
+		}
+	}
+}
+
+// structRedactedFields returns the restricted and nested fields of a struct type, cached per type.
+func structRedactedFields(t reflect.Type) []redactedField {
+	if cached, ok := redactedFields.Load(t); ok {
+		return cached.([]redactedField)
+	}
+
+	var fields []redactedField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		var roles []string
+		if tag, ok := field.Tag.Lookup("roles"); ok {
+			roles = []string{}
+			for _, role := range strings.Split(tag, ",") {
+				if role = strings.TrimSpace(role); role != "" {
+					roles = append(roles, role)
+				}
+			}
+		}
+
+		nested := mayHoldStruct(field.Type, map[reflect.Type]bool{})
+		if roles != nil || nested {
+			fields = append(fields, redactedField{index: i, roles: roles, nested: nested})
+		}
+	}
+
+	redactedFields.Store(t, fields)
+	return fields
+}
+
+// mayHoldStruct reports whether values of a type may hold structs with restricted fields.
+func mayHoldStruct(t reflect.Type, seen map[reflect.Type]bool) bool {
+	// a type met again while being inspected is recursive: assume it may hold restricted fields
+	if seen[t] {
+		return true
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return mayHoldStruct(t.Elem(), seen)
+	case reflect.Interface:
+		return true
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if _, ok := field.Tag.Lookup("roles"); ok || mayHoldStruct(field.Type, seen) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,257 @@
+package mongorepo
+
+import (
+	"math/big"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// defaultBatchSize is the number of documents processed per batch by the scanning helpers.
+const defaultBatchSize = 500
+
+// RefRepair defines what CheckReferences does with dangling references.
+type RefRepair int
+
+const (
+	RefReportOnly RefRepair = iota // Only report the dangling references.
+	RefUnset                       // Unset the reference field, or pull the dangling values from an array of references.
+	RefDelete                      // Delete the documents holding a dangling reference.
+)
+
+// RefRule describes a reference between the repository collection and a target collection of the same database.
+type RefRule struct {
+	Field            string    // The BSON key (dotted paths allowed) holding the reference, a single value or an array of values.
+	TargetCollection string    // The referenced collection, e.g. "customers".
+	TargetField      string    // The referenced key in the target collection, default: "_id"
+	Repair           RefRepair // What to do with dangling references, default: RefReportOnly
+	BatchSize        int       // The number of documents checked per $in query, default: 500
+}
+
+// DanglingRef is a reference whose target does not exist.
+type DanglingRef struct {
+	DocumentID       any    // The _id of the document holding the reference.
+	Field            string // The BSON key of the reference.
+	TargetCollection string // The referenced collection.
+	Value            any    // The dangling value.
+}
+
+// RefReport is the outcome of CheckReferences.
+type RefReport struct {
+	Checked  int64         // The number of documents checked, across rules.
+	Dangling []DanglingRef // The dangling references found.
+	Repaired int64         // The number of documents updated or deleted by the repair.
+}
+
+// CheckReferences scans the repository collection for references whose target document does not exist,
+// since MongoDB does not enforce foreign keys. Documents are checked in batches with one $in query per batch,
+// and dangling references are optionally repaired. Soft-deleted documents are included on both sides.
+// RefDelete repairs go through DeleteMany: a soft delete when DeletedAtField is configured, and a backup to the
+// BackupCollection first when configured; RefUnset repairs are backed up and stamped like UpdateMany.
+// A regional repository only checks and repairs the documents of its region.
+//
+// Parameters:
+//   - rules: The references to check.
+//
+// Returns:
+//   - The report of the checked documents, dangling references and repairs.
+//   - An error if a query or a repair fails.
+func (r *Repository[T]) CheckReferences(rules ...RefRule) (*RefReport, error) {
+	report := &RefReport{}
+	if err := r.guardRegion(); err != nil {
+		return report, err
+	}
+
+	for _, rule := range rules {
+		if rule.TargetField == "" {
+			rule.TargetField = "_id"
+		}
+		if rule.BatchSize <= 0 {
+			rule.BatchSize = defaultBatchSize
+		}
+
+		if err := r.checkReferences(rule, report); err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// checkReferences runs a single rule, appending its results to the report.
+func (r *Repository[T]) checkReferences(rule RefRule, report *RefReport) error {
+	ctx := r.config.Context
+	opts := options.Find().
+		SetProjection(bson.M{rule.Field: 1}).
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetBatchSize(int32(rule.BatchSize))
+
+	filter := r.regionScope(bson.M{rule.Field: bson.M{"$exists": true}})
+	start := time.Now()
+	cursor, err := r.Collection().Find(ctx, filter, r.findComment("CheckReferences"), opts)
+	if err != nil {
+		r.observe("CheckReferences", filter, start, 0, err)
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var batch []bson.Raw
+	scanned := 0
+	defer func() { r.observe("CheckReferences", filter, start, scanned, cursor.Err()) }()
+	for cursor.Next(ctx) {
+		scanned++
+		batch = append(batch, append(bson.Raw(nil), cursor.Current...))
+		if len(batch) == rule.BatchSize {
+			if err := r.checkReferenceBatch(rule, batch, report); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	return r.checkReferenceBatch(rule, batch, report)
+}
+
+// checkReferenceBatch looks up the references of a batch of documents in the target collection and repairs the dangling ones.
+func (r *Repository[T]) checkReferenceBatch(rule RefRule, batch []bson.Raw, report *RefReport) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	ctx := r.config.Context
+	path := strings.Split(rule.Field, ".")
+
+	refs := make([][]bson.RawValue, len(batch))
+	var values []bson.RawValue
+	for i, doc := range batch {
+		refs[i] = referenceValues(doc.Lookup(path...))
+		values = append(values, refs[i]...)
+	}
+	report.Checked += int64(len(batch))
+
+	cursor, err := r.Database().Collection(rule.TargetCollection).Find(ctx,
+		bson.M{rule.TargetField: bson.M{"$in": values}},
+		options.Find().SetProjection(bson.M{rule.TargetField: 1}))
+	if err != nil {
+		return err
+	}
+
+	existing := map[string]bool{}
+	for cursor.Next(ctx) {
+		existing[rawValueKey(cursor.Current.Lookup(strings.Split(rule.TargetField, ".")...))] = true
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	for i, doc := range batch {
+		var dangling []bson.RawValue
+		for _, value := range refs[i] {
+			if !existing[rawValueKey(value)] {
+				dangling = append(dangling, value)
+			}
+		}
+		if len(dangling) == 0 {
+			continue
+		}
+
+		id := doc.Lookup("_id")
+		for _, value := range dangling {
+			report.Dangling = append(report.Dangling, DanglingRef{
+				DocumentID:       decodeRawValue(id),
+				Field:            rule.Field,
+				TargetCollection: rule.TargetCollection,
+				Value:            decodeRawValue(value),
+			})
+		}
+
+		repaired, err := r.repairReference(rule, id, doc.Lookup(path...).Type == bsontype.Array, dangling)
+		if err != nil {
+			return err
+		}
+		if repaired {
+			report.Repaired++
+		}
+	}
+
+	return nil
+}
+
+// repairReference applies the rule repair strategy to a document holding dangling references.
+func (r *Repository[T]) repairReference(rule RefRule, id bson.RawValue, isArray bool, dangling []bson.RawValue) (bool, error) {
+	switch rule.Repair {
+	case RefUnset:
+		filter := r.regionScope(bson.M{"_id": id}).(bson.M)
+		if err := r.backup("CheckReferences", filter); err != nil {
+			return false, err
+		}
+
+		update := bson.M{"$unset": bson.M{rule.Field: ""}}
+		if isArray {
+			update = bson.M{"$pull": bson.M{rule.Field: bson.M{"$in": dangling}}}
+		}
+
+		start := time.Now()
+		result, err := r.Collection().UpdateOne(r.config.Context, filter, r.stampUpdate(update), r.updateComment("CheckReferences"))
+		r.observe("CheckReferences", filter, start, 0, err)
+		if err != nil {
+			return false, classify(err)
+		}
+		return result.ModifiedCount > 0, nil
+	case RefDelete:
+		deleted, err := r.DeleteMany(bson.M{"_id": id})
+		return deleted > 0, err
+	default:
+		return false, nil
+	}
+}
+
+// referenceValues returns the values of a reference field, flattening arrays.
+func referenceValues(value bson.RawValue) []bson.RawValue {
+	if value.Type != bsontype.Array {
+		return []bson.RawValue{value}
+	}
+
+	values, err := value.Array().Values()
+	if err != nil {
+		return nil
+	}
+
+	return values
+}
+
+// rawValueKey builds a comparable key of a BSON value from its type and encoded bytes. Numbers get the key of
+// their value whatever their type, since $in matches an int32 against the equal int64, double or decimal.
+func rawValueKey(value bson.RawValue) string {
+	var number *big.Rat
+	switch value.Type {
+	case bsontype.Int32:
+		number = new(big.Rat).SetInt64(int64(value.Int32()))
+	case bsontype.Int64:
+		number = new(big.Rat).SetInt64(value.Int64())
+	case bsontype.Double:
+		number = new(big.Rat).SetFloat64(value.Double()) // nil for NaN and infinities
+	case bsontype.Decimal128:
+		number, _ = new(big.Rat).SetString(value.Decimal128().String())
+	}
+	if number != nil {
+		return "n" + number.RatString()
+	}
+
+	return string(rune(value.Type)) + string(value.Value)
+}
+
+// decodeRawValue decodes a BSON value into its default Go representation.
+func decodeRawValue(value bson.RawValue) any {
+	var decoded any
+	if err := value.Unmarshal(&decoded); err != nil {
+		return value
+	}
+
+	return decoded
+}
@@ -0,0 +1,112 @@
+package mongorepo
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestRawValueKey(t *testing.T) {
+	id := primitive.NewObjectID()
+	decimal := func(value string) primitive.Decimal128 {
+		d, err := primitive.ParseDecimal128(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	tests := []struct {
+		name  string
+		a, b  any
+		equal bool
+	}{
+		{name: "int32 and int64", a: int32(5), b: int64(5), equal: true},
+		{name: "int64 and double", a: int64(5), b: 5.0, equal: true},
+		{name: "double and decimal", a: 5.0, b: decimal("5.00"), equal: true},
+		{name: "decimal exponent", a: decimal("5E+3"), b: int32(5000), equal: true},
+		{name: "fraction", a: 5.5, b: decimal("5.5"), equal: true},
+		{name: "other numbers", a: int32(5), b: 5.5},
+		{name: "large int64", a: int64(math.MaxInt64), b: int64(math.MaxInt64 - 1)},
+		{name: "NaN", a: math.NaN(), b: math.NaN(), equal: true},
+		{name: "number and string", a: int32(5), b: "5"},
+		{name: "strings", a: "a", b: "a", equal: true},
+		{name: "other strings", a: "a", b: "b"},
+		{name: "object ids", a: id, b: id, equal: true},
+		{name: "object id and its hex", a: id, b: id.Hex()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a, b := rawValueKey(rawValue(t, test.a)), rawValueKey(rawValue(t, test.b))
+			if (a == b) != test.equal {
+				t.Errorf("got equal keys %v, expected %v", a == b, test.equal)
+			}
+		})
+	}
+}
+
+// rawValue encodes a value the way it is read from a document.
+func rawValue(t *testing.T, value any) bson.RawValue {
+	raw, err := bson.Marshal(bson.M{"v": value})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bson.Raw(raw).Lookup("v")
+}
+
+type referencingOrder struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	CustomerID primitive.ObjectID `bson:"customer_id"`
+	UpdatedAt  time.Time          `bson:"updated_at"`
+	DeletedAt  *time.Time         `bson:"deleted_at,omitempty"`
+}
+
+func TestCheckReferencesRepairs(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	order := bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "customer_id", Value: primitive.NewObjectID()}}
+
+	tests := []struct {
+		name   string
+		repair RefRepair
+		update string // the operator of the repair update
+	}{
+		{name: "soft delete", repair: RefDelete, update: "$set"},
+		{name: "unset", repair: RefUnset, update: "$unset"},
+	}
+
+	for _, test := range tests {
+		mt.Run(test.name, func(mt *mtest.T) {
+			repo := New[referencingOrder](&Config{MongoClient: mt.Client, DbName: "shop", UpdatedAtField: "UpdatedAt", DeletedAtField: "DeletedAt"})
+			mt.AddMockResponses(
+				mtest.CreateCursorResponse(0, "shop.referencing_orders", mtest.FirstBatch, order),
+				mtest.CreateCursorResponse(0, "shop.customers", mtest.FirstBatch),
+				mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+			)
+
+			report, err := repo.CheckReferences(RefRule{Field: "customer_id", TargetCollection: "customers", Repair: test.repair})
+			if err != nil {
+				mt.Fatal(err)
+			}
+			if len(report.Dangling) != 1 || report.Repaired != 1 {
+				mt.Errorf("got %d dangling references and %d repairs, expected one of each", len(report.Dangling), report.Repaired)
+			}
+
+			events := mt.GetAllStartedEvents()
+			if len(events) != 3 || events[2].CommandName != "update" {
+				mt.Fatalf("expected the repair to be an update, got %d commands", len(events))
+			}
+			update := events[2].Command.Lookup("updates", "0", "u").Document()
+			if _, err := update.LookupErr(test.update); err != nil {
+				mt.Errorf("the repair update %v has no %s", update, test.update)
+			}
+			if _, err := update.LookupErr("$set", "updated_at"); err != nil {
+				mt.Errorf("the repair update %v is not stamped", update)
+			}
+		})
+	}
+}
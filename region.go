@@ -0,0 +1,242 @@
+package mongorepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrUnknownRegion is returned by the RegionResolver for a region it has no target for, or no region at all.
+	ErrUnknownRegion = errors.New("mongorepo: unknown region")
+
+	// ErrRegionMismatch is returned when data would cross regions: a request bound to a region reaching the
+	// repository of another one, or a regional repository reading or writing an entity of another region.
+	ErrRegionMismatch = errors.New("mongorepo: cross-region access")
+)
+
+// regionKey is the context key of the request region.
+type regionKey struct{}
+
+// RegionTarget is where the data of a region lives.
+type RegionTarget struct {
+	MongoClient    *mongo.Client // The client of the regional cluster, default: the client of the base repository
+	DbName         string        // The regional database, default: the database of the base repository
+	CollectionName string        // The regional collection, default: the collection of the base repository
+}
+
+// RegionResolverConfig holds the configuration of a RegionResolver.
+type RegionResolverConfig struct {
+	Regions     map[string]RegionTarget // The target of each region, e.g. "eu" and "us".
+	RegionField string                  // The field in the entity struct (a string) holding the region of the entity, e.g. "Region".
+}
+
+// regionBinding binds a regional repository to its region.
+type regionBinding struct {
+	name  string // The region.
+	field string // The field in the entity struct holding the region.
+	key   string // The BSON key of the field.
+}
+
+// RegionResolver routes the operations to region-specific clusters or databases, according to the region of
+// the entity or the one bound to the request context, for data-residency requirements. The regional repositories
+// guard their reads and writes: a document or an entity of another region, or a context bound to another region,
+// fails with ErrRegionMismatch instead of crossing regions.
+type RegionResolver[T any] struct {
+	base   *Repository[T]
+	config RegionResolverConfig
+	repos  map[string]*Repository[T]
+}
+
+// NewRegionResolver creates a RegionResolver over views of the base repository.
+//
+// Parameters:
+//   - base: The repository whose configuration the regional repositories share.
+//   - config: The regional targets and the region field.
+//
+// Returns:
+//   - A pointer to a RegionResolver.
+//
+// Panics:
+//   - If no region or no RegionField is configured.
+func NewRegionResolver[T any](base *Repository[T], config RegionResolverConfig) *RegionResolver[T] {
+	if len(config.Regions) == 0 {
+		panic("Configuration error: RegionResolver requires at least one region.")
+	}
+
+	if config.RegionField == "" {
+		panic("Configuration error: The RegionResolver RegionField is not set.")
+	}
+
+	rr := &RegionResolver[T]{base: base, config: config, repos: map[string]*Repository[T]{}}
+	for name, target := range config.Regions {
+		repo := base.view()
+		if target.MongoClient != nil {
+			repo.config.MongoClient = target.MongoClient
+		}
+		if target.DbName != "" {
+			repo.config.DbName = target.DbName
+		}
+		if target.CollectionName != "" {
+			repo.config.CollectionName = target.CollectionName
+		}
+		repo.region = &regionBinding{name: name, field: config.RegionField, key: base.fieldKey(config.RegionField)}
+
+		rr.repos[name] = repo
+	}
+
+	return rr
+}
+
+// WithRegion binds a request context to a region, e.g. the region of the authenticated tenant.
+//
+// Parameters:
+//   - ctx: The parent context.
+//   - region: The region of the request.
+//
+// Returns:
+//   - A context carrying the region.
+func WithRegion(ctx context.Context, region string) context.Context {
+	return context.WithValue(ctx, regionKey{}, region)
+}
+
+// RegionFromContext returns the region bound to ctx.
+//
+// Parameters:
+//   - ctx: The context.
+//
+// Returns:
+//   - The region and true, or false when ctx is not bound to a region.
+func RegionFromContext(ctx context.Context) (string, bool) {
+	region, ok := ctx.Value(regionKey{}).(string)
+	return region, ok
+}
+
+// Route returns the repository of a region, operating with ctx.
+//
+// Parameters:
+//   - ctx: The context of the request.
+//   - region: The region.
+//
+// Returns:
+//   - A pointer to the regional Repository.
+//   - ErrUnknownRegion if the region is not configured, or ErrRegionMismatch if ctx is bound to another region.
+func (rr *RegionResolver[T]) Route(ctx context.Context, region string) (*Repository[T], error) {
+	repo, ok := rr.repos[region]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownRegion, region)
+	}
+
+	if bound, ok := RegionFromContext(ctx); ok && bound != region {
+		return nil, fmt.Errorf("%w: %s request routed to %s", ErrRegionMismatch, bound, region)
+	}
+
+	return repo.WithContext(ctx), nil
+}
+
+// Resolve returns the repository of the region bound to ctx (see WithRegion).
+//
+// Parameters:
+//   - ctx: The context of the request.
+//
+// Returns:
+//   - A pointer to the regional Repository.
+//   - ErrUnknownRegion if ctx is not bound to a configured region.
+func (rr *RegionResolver[T]) Resolve(ctx context.Context) (*Repository[T], error) {
+	region, ok := RegionFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("%w: the context is not bound to a region", ErrUnknownRegion)
+	}
+
+	return rr.Route(ctx, region)
+}
+
+// For returns the repository of the region of an entity, according to its RegionField.
+//
+// Parameters:
+//   - ctx: The context of the request.
+//   - entity: A pointer to the entity to route.
+//
+// Returns:
+//   - A pointer to the regional Repository.
+//   - ErrUnknownRegion if the region is not configured, or ErrRegionMismatch if ctx is bound to another region.
+func (rr *RegionResolver[T]) For(ctx context.Context, entity *T) (*Repository[T], error) {
+	region := NewEntityReflection(rr.base.config, entity).GetString(rr.config.RegionField)
+	return rr.Route(ctx, region)
+}
+
+// Regions returns the configured regions.
+//
+// Returns:
+//   - The regions, sorted.
+func (rr *RegionResolver[T]) Regions() []string {
+	regions := make([]string, 0, len(rr.repos))
+	for region := range rr.repos {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	return regions
+}
+
+// guardRegion checks an operation of a regional repository before it is sent, and is called by every read and
+// write entry point: it fails when the context is bound to another region, or when one of the entities written
+// belongs to another region. The filters of the operations are restricted to the region by regionScope.
+func (r *Repository[T]) guardRegion(entities ...*T) error {
+	if r.region == nil {
+		return nil
+	}
+
+	if bound, ok := RegionFromContext(r.config.Context); ok && bound != r.region.name {
+		return fmt.Errorf("%w: %s request on the %s repository", ErrRegionMismatch, bound, r.region.name)
+	}
+
+	for _, entity := range entities {
+		if region := NewEntityReflection(r.config, entity).GetString(r.region.field); region != r.region.name {
+			return fmt.Errorf("%w: entity of region %q in the %s repository", ErrRegionMismatch, region, r.region.name)
+		}
+	}
+
+	return nil
+}
+
+// regionScope restricts the filter of an operation of a regional repository to the documents of its region, so
+// filter-based reads and writes never reach the documents of another region. A filter already constraining the
+// region field is combined with the restriction using $and, so it cannot select another region.
+func (r *Repository[T]) regionScope(filter any) any {
+	if r.region == nil {
+		return filter
+	}
+
+	condition := bson.M{r.region.key: r.region.name}
+	switch f := filter.(type) {
+	case bson.M:
+		if _, ok := f[r.region.key]; ok {
+			return bson.M{"$and": bson.A{filter, condition}}
+		}
+	case bson.D:
+		if hasKey(f, r.region.key) {
+			return bson.M{"$and": bson.A{filter, condition}}
+		}
+	}
+
+	return mergeScope(filter, condition)
+}
+
+// guardDocumentRegion fails when a regional repository decodes a document of another region, e.g. one returned
+// by a query of an embedding repository that bypassed regionScope.
+func (r *Repository[T]) guardDocumentRegion(raw bson.Raw) error {
+	if r.region == nil {
+		return nil
+	}
+
+	if region, ok := raw.Lookup(r.region.key).StringValueOK(); !ok || region != r.region.name {
+		return fmt.Errorf("%w: document %v of region %q in the %s repository", ErrRegionMismatch, decodeRawValue(raw.Lookup("_id")), region, r.region.name)
+	}
+
+	return nil
+}
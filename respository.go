@@ -2,8 +2,9 @@ package mongorepo
 
 import (
 	"context"
-	"log"
 	"reflect"
+	"slices"
+	"time"
 
 	"github.com/iancoleman/strcase"
 	"github.com/jinzhu/inflection"
@@ -17,7 +18,10 @@ import (
 // It utilizes MongoDB as the underlying database and supports CRUD operations with built-in reflection
 // for dynamic field access and management of common fields like ID, CreatedAt, UpdatedAt, and DeletedAt.
 type Repository[T any] struct {
-	config *Config
+	config  *Config
+	trashed trashedScope
+	region  *regionBinding
+	tenant  *tenantNames
 }
 
 // NewRepository initializes a new Repository instance with the specified configuration.
@@ -40,6 +44,46 @@ func New[T any](config *Config) *Repository[T] {
 		config.Context = context.Background()
 	}
 
+	if config.CountersCollection == "" {
+		config.CountersCollection = "counters"
+	}
+
+	if config.CheckpointsCollection == "" {
+		config.CheckpointsCollection = "checkpoints"
+	}
+
+	if config.DeadLetterCollection == "" {
+		config.DeadLetterCollection = "dead_letters"
+	}
+
+	if config.OutboxCollection == "" {
+		config.OutboxCollection = "outbox"
+	}
+
+	if config.MaxDocumentSize <= 0 {
+		config.MaxDocumentSize = maxBSONDocumentSize
+	}
+
+	if config.GridFSBucket == "" {
+		config.GridFSBucket = "fs"
+	}
+
+	if config.ModifyMaxAttempts <= 0 {
+		config.ModifyMaxAttempts = 3
+	}
+
+	if config.InsertBatchSize <= 0 {
+		config.InsertBatchSize = 1000
+	}
+
+	if config.LockTTL <= 0 {
+		config.LockTTL = 30 * time.Second
+	}
+
+	if config.BackupRetention <= 0 {
+		config.BackupRetention = 7 * 24 * time.Hour
+	}
+
 	if config.MongoClient == nil {
 		panic("Configuration error: The *mongo.Client is not set.")
 	}
@@ -57,15 +101,18 @@ func New[T any](config *Config) *Repository[T] {
 		config.CollectionName = inflection.Plural(snakeCaseStr)
 	}
 
-	return &Repository[T]{config: config}
+	repo := &Repository[T]{config: config}
+	repo.resolveTenant()
+	return repo
 }
 
-// Collection retrieves the MongoDB Collection from the repository's configuration.
+// Collection retrieves the MongoDB Collection from the repository's configuration, with the ReadPreference,
+// ReadConcern and WriteConcern of the repository applied over the CollectionOptions.
 //
 // Returns:
 //   - A pointer to the MongoDB Collection.
 func (r *Repository[T]) Collection() *mongo.Collection {
-	return r.Database().Collection(r.config.CollectionName, r.config.CollectionOptions)
+	return r.Database().Collection(r.config.CollectionName, r.config.CollectionOptions, r.concernOptions())
 }
 
 // Database retrieves the MongoDB Database from the repository's configuration.
@@ -76,126 +123,953 @@ func (r *Repository[T]) Database() *mongo.Database {
 	return r.config.MongoClient.Database(r.config.DbName, r.config.DatabaseOptions)
 }
 
+// OnCollection returns a view of the repository that targets another collection holding the same entity type,
+// e.g. data partitioned by month (events_2024_05). With a TenantResolver, the collection suffix of the tenant is
+// appended to name. The original repository is not modified.
+//
+// Parameters:
+//   - name: The name of the collection the view operates on.
+//
+// Returns:
+//   - A pointer to a Repository sharing the configuration of `r` except for the collection name.
+func (r *Repository[T]) OnCollection(name string) *Repository[T] {
+	view := r.view()
+	view.config.CollectionName = name
+	view.resolveTenant()
+	return view
+}
+
+// WithContext returns a view of the repository whose operations use ctx instead of Config.Context,
+// so request handlers can propagate their own deadlines and cancellation:
+//
+//	entity, err := repo.WithContext(r.Context()).FindById(id)
+//
+// With a TenantResolver, the view targets the database and collection of the tenant of ctx.
+// The original repository is not modified.
+//
+// Parameters:
+//   - ctx: The context of the operations of the view.
+//
+// Returns:
+//   - A pointer to a Repository sharing the configuration of `r` except for the context.
+func (r *Repository[T]) WithContext(ctx context.Context) *Repository[T] {
+	view := r.view()
+	view.config.Context = ctx
+	view.resolveTenant()
+	return view
+}
+
+// Unbounded returns a view of the repository whose finders ignore Config.MaxFindLimit, for the queries
+// that are meant to fetch every matching document:
+//
+//	entities, err := repo.Unbounded().Find(bson.M{"tenant": tenant})
+//
+// The original repository is not modified.
+//
+// Returns:
+//   - A pointer to a Repository sharing the configuration of `r` without MaxFindLimit.
+func (r *Repository[T]) Unbounded() *Repository[T] {
+	view := r.view()
+	view.config.MaxFindLimit = 0
+	return view
+}
+
+// boundFindOptions appends the MaxFindLimit to find options without limit.
+func (r *Repository[T]) boundFindOptions(opts []*options.FindOptions) []*options.FindOptions {
+	if r.config.MaxFindLimit <= 0 {
+		return opts
+	}
+
+	for _, opt := range opts {
+		if opt != nil && opt.Limit != nil && *opt.Limit > 0 {
+			return opts
+		}
+	}
+
+	return append(append([]*options.FindOptions{}, opts...), options.Find().SetLimit(r.config.MaxFindLimit))
+}
+
+// view returns a copy of the repository with its own copy of the configuration,
+// so scoped views can be adjusted without affecting the repository they derive from.
+func (r *Repository[T]) view() *Repository[T] {
+	config := *r.config
+	return &Repository[T]{config: &config, trashed: r.trashed, region: r.region, tenant: r.tenant}
+}
+
 // Aggregate executes an aggregation pipeline on the MongoDB collection associated with the repository.
+// When soft deletes are configured, a $match excluding soft-deleted documents is injected at the start of the pipeline.
+// With an AggregationGuard, the pipeline is checked against its cost guardrails first.
 //
 // Parameters:
 //   - pipeline: A MongoDB aggregation pipeline represented as a slice of aggregation stages.
 //   - opts: Optional aggregation options such as batch size, collation, or max time.
 //
 // Returns:
-//   - (*mongo.Cursor, error): A cursor to iterate over the aggregation result set, or an error if the operation fails.
+//   - (*mongo.Cursor, error): A cursor to iterate over the aggregation result set, a PipelineRejectedError
+//     (matching ErrPipelineRejected) if the AggregationGuard rejects the pipeline, or an error if the operation fails.
 func (r *Repository[T]) Aggregate(pipeline *mongo.Pipeline, opts ...*options.AggregateOptions) (*mongo.Cursor, error) {
-	return r.Database().Aggregate(r.config.Context, pipeline, opts...)
+	if err := r.guardRegion(); err != nil {
+		return nil, err
+	}
+
+	scoped := r.scopePipeline(*pipeline)
+	if err := r.lintPipeline(scoped, opts); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	cursor, err := r.Collection().Aggregate(r.config.Context, scoped, append([]*options.AggregateOptions{r.aggregateComment("Aggregate")}, opts...)...)
+	r.observe("Aggregate", scoped, start, 0, err)
+
+	return cursor, err
 }
 
-// Document...todo
-func (r *Repository[T]) FindByHexId(id string) *T {
+// AggregateInto executes an aggregation pipeline on the collection of a repository, like Aggregate, and decodes
+// every result into `R`, which usually differs from the entity type (e.g. the rows of a grouped report).
+// The results are not entities, so they are decoded as is, without the hydration and redaction of the finders.
+//
+// Parameters:
+//   - r: The repository whose collection is aggregated.
+//   - pipeline: A MongoDB aggregation pipeline represented as a slice of aggregation stages.
+//   - opts: Optional aggregation options such as batch size, collation, or max time.
+//
+// Returns:
+//   - A slice with the decoded results, empty if there is none.
+//   - An error if the aggregation or the decoding fails.
+func AggregateInto[R any, T any](r *Repository[T], pipeline *mongo.Pipeline, opts ...*options.AggregateOptions) ([]R, error) {
+	cursor, err := r.Aggregate(pipeline, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []R{}
+	err = cursor.All(r.config.Context, &results)
+	return results, err
+}
+
+// FindByHexId retrieves an entity by the string representation of its ObjectID.
+//
+// Parameters:
+//   - id: the string representation of the object id.
+//
+// Returns:
+//   - A pointer to the entity of type `T`.
+//   - ErrInvalidID if the id is not a valid ObjectID, ErrNotFound if not found, or an error if the operation fails.
+func (r *Repository[T]) FindByHexId(id string) (*T, error) {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		log.Printf("FindByHexId error: %s", err.Error())
-		return nil
+		return nil, &kindError{kind: ErrInvalidID, err: err}
 	}
 
 	return r.FindById(objectID)
 }
 
-// FindById retrieves an entity by its unique MongoDB ObjectID.
+// FindById retrieves an entity by its ID, a primitive.ObjectID or a value of the type of the IdField.
 // This method is a convenience wrapper around FindOne.
 //
 // Parameters:
-//   - id: The ObjectID of the entity to retrieve.
+//   - id: The ID of the entity to retrieve.
 //
 // Returns:
-//   - A pointer to the entity of type `T`, or nil if not found.
-func (r *Repository[T]) FindById(id primitive.ObjectID) *T {
+//   - A pointer to the entity of type `T`.
+//   - ErrNotFound if not found, or an error if the operation fails.
+func (r *Repository[T]) FindById(id any) (*T, error) {
 	return r.FindOne(bson.M{"_id": id})
 }
 
 // FindOne retrieves a single entity matching the provided query filter.
+// Soft-deleted documents are excluded unless soft-delete scoping is disabled.
 //
 // Parameters:
 //   - query: A BSON map defining the search criteria.
 //   - opts: Optional FindOneOptions to modify the query behavior.
 //
 // Returns:
-//   - A pointer to the entity of type `T`, or nil if no document matches the query.
-func (r *Repository[T]) FindOne(query bson.M, opts ...*options.FindOneOptions) *T {
-	var entity T
-
-	err := r.Collection().FindOne(r.config.Context, query, opts...).Decode(&entity)
+//   - A pointer to the entity of type `T`.
+//   - ErrNotFound if no document matches the query, or an error if the operation fails.
+func (r *Repository[T]) FindOne(query bson.M, opts ...*options.FindOneOptions) (*T, error) {
+	if err := r.guardRegion(); err != nil {
+		return nil, err
+	}
 
+	start := time.Now()
+	raw, err := r.Collection().FindOne(r.config.Context, r.scopeFilter(query), append([]*options.FindOneOptions{r.findOneComment("FindOne")}, opts...)...).Raw()
+	r.observe("FindOne", query, start, singleResult(err), err)
 	if err != nil {
-		log.Printf("FindOne error: %s", err.Error())
-		return nil
+		return nil, classify(err)
 	}
 
-	return &entity
+	return r.decodeEntity(raw)
 }
 
 // Find retrieves all entities matching the provided query filter.
+// Soft-deleted documents are excluded unless soft-delete scoping is disabled.
+// Paginated queries (with a skip, or with a limit and a sort) get an _id tiebreaker appended to the sort, see StableSort.
+// Queries without limit are limited to Config.MaxFindLimit documents when configured, see Unbounded.
 //
 // Parameters:
 //   - query: A BSON map defining the search criteria.
 //   - opts: Optional FindOptions to modify the query behavior (e.g., sorting, pagination).
 //
 // Returns:
-//   - A slice of pointers to entities of type `T` that match the query, or nil if an error occurs.
-func (r *Repository[T]) Find(query bson.M, opts ...*options.FindOptions) []*T {
+//   - A slice of pointers to entities of type `T` that match the query, empty if none does.
+//   - An error if the operation fails.
+func (r *Repository[T]) Find(query bson.M, opts ...*options.FindOptions) ([]*T, error) {
+	if err := r.guardRegion(); err != nil {
+		return nil, err
+	}
+
 	var entities []*T
 
-	cursor, err := r.Collection().Find(r.config.Context, query, opts...)
+	stable, err := stableFindOptions(r.boundFindOptions(append([]*options.FindOptions{r.findComment("Find")}, opts...)))
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	cursor, err := r.Collection().Find(r.config.Context, r.scopeFilter(query), stable...)
+	if err != nil {
+		r.observe("Find", query, start, 0, err)
+		return nil, err
+	}
+	defer cursor.Close(r.config.Context)
+
+	for cursor.Next(r.config.Context) {
+		entity, err := r.decodeEntity(cursor.Current)
+		if err != nil {
+			return nil, err
+		}
+		entities = append(entities, entity)
+	}
+	r.observe("Find", query, start, len(entities), cursor.Err())
+
+	return entities, cursor.Err()
+}
+
+// decodeEntity decodes a document into a new entity the way every finder does: through hydrate (region check,
+// strict decoding, offloaded fields and deprecated reads), then redacted for the roles of the context. The keys
+// a pipeline adds next to the entity fields, such as a search score, are dropped first so strict decoding
+// does not reject them.
+func (r *Repository[T]) decodeEntity(raw bson.Raw, added ...string) (*T, error) {
+	if len(added) > 0 {
+		kept, err := withoutKeys(raw, added...)
+		if err != nil {
+			return nil, err
+		}
+		if raw, err = bson.Marshal(kept); err != nil {
+			return nil, err
+		}
+	}
+
+	var entity T
+	if err := r.hydrate(raw, &entity); err != nil {
+		return nil, err
+	}
+	r.redact(&entity)
+
+	return &entity, nil
+}
+
+// FindByIdsOrdered retrieves the entities with the given IDs in the order of ids, e.g. to keep the ranking
+// of the IDs returned by a search service. IDs without a matching document are skipped. The IDs are matched
+// like $in matches them, so an int ID finds the entity of the equal int64 ID.
+//
+// Parameters:
+//   - ids: The IDs of the entities to retrieve, in the expected order, e.g. ObjectIDs or sequence numbers.
+//
+// Returns:
+//   - A slice of pointers to the entities of type `T`, in the order of ids.
+//   - An error if the operation fails.
+func (r *Repository[T]) FindByIdsOrdered(ids []any) ([]*T, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	found, err := r.Find(bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+
+	byId := make(map[string]*T, len(found))
+	for _, entity := range found {
+		byId[orderedIDKey(NewEntityReflection(r.config, entity).GetID())] = entity
+	}
+
+	entities := make([]*T, 0, len(found))
+	for _, id := range ids {
+		if entity, ok := byId[orderedIDKey(id)]; ok {
+			entities = append(entities, entity)
+		}
+	}
+
+	return entities, nil
+}
+
+// orderedIDKey identifies an ID by its BSON value, see rawValueKey.
+func orderedIDKey(id any) string {
+	kind, data, err := bson.MarshalValue(id)
 	if err != nil {
-		log.Printf("Find error: %s", err.Error())
-		return nil
+		return batchKey(id)
 	}
 
-	if err := cursor.All(r.config.Context, &entities); err != nil {
-		log.Printf("Find cursor error: %s", err.Error())
-		return nil
+	return rawValueKey(bson.RawValue{Type: kind, Value: data})
+}
+
+// Count returns the number of documents matching the query. Soft-deleted documents are excluded unless
+// soft-delete scoping is disabled.
+//
+// Parameters:
+//   - query: A BSON map defining the search criteria.
+//   - opts: Optional CountOptions (e.g., limit, hint).
+//
+// Returns:
+//   - The number of matching documents.
+//   - An error if the operation fails.
+func (r *Repository[T]) Count(query bson.M, opts ...*options.CountOptions) (int64, error) {
+	if err := r.guardRegion(); err != nil {
+		return 0, err
 	}
 
-	return entities
+	start := time.Now()
+	count, err := r.Collection().CountDocuments(r.config.Context, r.scopeFilter(query), append([]*options.CountOptions{r.countComment("Count")}, opts...)...)
+	r.observe("Count", query, start, 0, err)
+
+	return count, err
+}
+
+// Exists reports whether at least one document matches the query, stopping at the first match.
+// Soft-deleted documents are excluded unless soft-delete scoping is disabled.
+//
+// Parameters:
+//   - query: A BSON map defining the search criteria.
+//
+// Returns:
+//   - true if a document matches the query.
+//   - An error if the operation fails.
+func (r *Repository[T]) Exists(query bson.M) (bool, error) {
+	count, err := r.Count(query, options.Count().SetLimit(1))
+	return count > 0, err
+}
+
+// Distinct returns the distinct values of a field among the documents matching the query, decoded into `V`.
+// Soft-deleted documents are excluded unless soft-delete scoping is disabled.
+//
+// Parameters:
+//   - r: The repository to query.
+//   - key: The BSON key of the field, dotted paths allowed, e.g. "address.city".
+//   - query: A BSON map defining the search criteria, may be nil.
+//
+// Returns:
+//   - The distinct values.
+//   - An error if the operation fails or a value does not decode into `V`.
+func Distinct[V any, T any](r *Repository[T], key string, query bson.M) ([]V, error) {
+	if err := r.guardRegion(); err != nil {
+		return nil, err
+	}
+
+	if query == nil {
+		query = bson.M{}
+	}
+
+	start := time.Now()
+	values, err := r.Collection().Distinct(r.config.Context, key, r.scopeFilter(query), r.distinctComment("Distinct"))
+	r.observe("Distinct", query, start, len(values), err)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := bson.Marshal(bson.M{"values": values})
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded struct {
+		Values []V `bson:"values"`
+	}
+	if err := bson.Unmarshal(raw, &decoded); err != nil {
+		return nil, err
+	}
+
+	return decoded.Values, nil
 }
 
 // Create inserts a new entity into the MongoDB Collection.
-// The method automatically sets the ID and CreatedAt fields if they are present in the entity.
+// The method automatically sets the ID (a new ObjectID, the IDGenerator value or the next IDSequence value)
+// and CreatedAt fields if they are present in the entity.
+// When deduplication is configured, the dedupe key is computed and a duplicate either returns
+// ErrDuplicateContent or, with DedupeReturnExisting, loads the existing document into entity.
+// Documents larger than MaxDocumentSize are handled according to the OversizeStrategy.
+// With ReadYourWrites, Create only returns once the document is majority committed and readable.
+// The CopyFieldsOnWrite copies are refreshed from their source documents first, and the copies of the WriteTargets
+// are written in the same transaction.
 //
 // Parameters:
 //   - entity: A pointer to the entity of type `T` to be inserted.
 //
 // Returns:
+//   - ErrReferenceNotFound if a CopyFieldsOnWrite source does not exist.
+//   - A *DuplicateKeyError (ErrDuplicateKey) naming the index if a unique index rejects the document.
 //   - An error if the insertion fails.
 func (r *Repository[T]) Create(entity *T) error {
+	if err := r.guardRegion(entity); err != nil {
+		return err
+	}
+
+	if len(r.config.WriteTargets) > 0 {
+		return r.fanOut(func(repo *Repository[T]) error { return repo.Create(entity) }, false, entity)
+	}
+
 	er := NewEntityReflection(r.config, entity)
-	er.SetNewID()
+	if err := r.assignIDs(er); err != nil {
+		return err
+	}
+	r.prepareInsert(er)
+
+	if err := r.copyFieldsOnWrite(entity); err != nil {
+		return err
+	}
+
+	document, err := r.guardSize(entity)
+	if err != nil {
+		return err
+	}
 
+	collection := r.Collection()
+	if r.config.ReadYourWrites {
+		collection = r.majorityCollection()
+	}
+
+	start := time.Now()
+	_, err = collection.InsertOne(r.config.Context, document, r.insertOneComment("Create"))
+	r.observe("Create", nil, start, 0, err)
+	if r.config.DedupeKeyField != "" && mongo.IsDuplicateKeyError(err) {
+		return classify(r.resolveDuplicate(entity, er, err))
+	}
+
+	if err == nil && r.config.ReadYourWrites {
+		return r.readBack(er.GetID())
+	}
+
+	return classify(err)
+}
+
+// CreateMany inserts several entities, setting the ID and CreatedAt field of every entity like Create does.
+// The entities are inserted in order with one InsertMany per Config.InsertBatchSize entities, to stay under
+// the message size limit; on error, the entities of the following batches are not inserted. With WriteTargets, the
+// batches and the copies are written in one transaction, so either every entity is inserted or none.
+//
+// Parameters:
+//   - entities: The pointers to the entities of type `T` to be inserted.
+//
+// Returns:
+//   - An error if an insertion fails.
+func (r *Repository[T]) CreateMany(entities []*T) error {
+	if err := r.guardRegion(entities...); err != nil {
+		return err
+	}
+
+	if len(r.config.WriteTargets) > 0 {
+		return r.fanOut(func(repo *Repository[T]) error { return repo.CreateMany(entities) }, false, entities...)
+	}
+
+	if err := r.copyFieldsOnWrite(entities...); err != nil {
+		return err
+	}
+
+	ers := make([]*EntityReflection, 0, len(entities))
+	for _, entity := range entities {
+		ers = append(ers, NewEntityReflection(r.config, entity))
+	}
+	if err := r.assignIDs(ers...); err != nil {
+		return err
+	}
+
+	documents := make([]any, 0, len(entities))
+	for i, entity := range entities {
+		r.prepareInsert(ers[i])
+
+		document, err := r.guardSize(entity)
+		if err != nil {
+			return err
+		}
+		documents = append(documents, document)
+	}
+
+	for start := 0; start < len(documents); start += r.config.InsertBatchSize {
+		end := min(start+r.config.InsertBatchSize, len(documents))
+
+		began := time.Now()
+		_, err := r.Collection().InsertMany(r.config.Context, documents[start:end], r.insertManyComment("CreateMany"))
+		r.observe("CreateMany", nil, began, 0, err)
+		if err != nil {
+			return classify(err)
+		}
+	}
+
+	return nil
+}
+
+// prepareInsert sets the fields maintained by the repository on a new entity, except for the ID.
+func (r *Repository[T]) prepareInsert(er *EntityReflection) {
 	// only update CreatedAtField if is configured
 	if r.config.CreatedAtField != "" {
 		er.SetCreatedAt()
 	}
 
-	_, err := r.Collection().InsertOne(r.config.Context, entity)
-	return err
+	r.setDedupeKey(er)
 }
 
 // Update modifies an existing entity in the MongoDB Collection.
 // The method automatically sets the UpdatedAt field to the current time before performing the update.
+// When VersionField is configured, the update only applies if the stored version is the one of the entity, and increments it.
+// When deduplication is configured, the dedupe key is recomputed from the current DedupeFields.
+// With WriteTargets, the copies of the entity are updated in the same transaction.
+// With ShardKeyFields, the filter includes the shard key values of the entity, targeting a single shard.
+// Documents larger than MaxDocumentSize are handled according to the OversizeStrategy.
+// With WriteAmplification, a sample of the updates is compared with the stored documents first.
 //
 // Parameters:
 //   - entity: A pointer to the entity of type `T` with updated data.
 //
 // Returns:
+//   - ErrStaleDocument if VersionField is configured and the document was changed (or deleted) since it was loaded.
+//   - ErrNotFound if VersionField is not configured and the document does not exist.
+//   - A *DuplicateKeyError (ErrDuplicateKey) naming the index if a unique index rejects the document.
 //   - An error if the update operation fails.
 func (r *Repository[T]) Update(entity *T) error {
+	if err := r.guardRegion(entity); err != nil {
+		return err
+	}
+
+	if len(r.config.WriteTargets) > 0 {
+		return r.fanOut(func(repo *Repository[T]) error { return repo.Update(entity) }, false, entity)
+	}
+
 	er := NewEntityReflection(r.config, entity)
 
 	// only update UpdatedAtField if is configured
 	if r.config.UpdatedAtField != "" {
 		er.SetUpdateAt()
 	}
+	r.setDedupeKey(er)
+
+	if err := r.copyFieldsOnWrite(entity); err != nil {
+		return err
+	}
+
+	document, err := r.guardSize(entity)
+	if err != nil {
+		return err
+	}
+
+	previous, err := r.offloadedDocument(er.GetID())
+	if err != nil {
+		return err
+	}
+
+	r.measureAmplification(er.GetID(), document)
+
+	if r.config.VersionField != "" {
+		if err := r.versionedUpdate("Update", er, document); err != nil {
+			return err
+		}
+	} else {
+		filter := r.entityFilter(er)
+		start := time.Now()
+		result, err := r.Collection().UpdateOne(r.config.Context, filter, bson.M{"$set": document}, r.updateComment("Update"))
+		r.observe("Update", filter, start, 0, err)
+		if err != nil {
+			return classify(err)
+		}
+		if result.MatchedCount == 0 {
+			return errNoDocuments
+		}
+	}
+
+	return r.releaseOffloaded(previous, document)
+}
+
+// versionedUpdate writes a document only if its stored version is still the one of the entity,
+// incrementing it, and reports ErrStaleDocument otherwise. The entity gets the new version.
+func (r *Repository[T]) versionedUpdate(operation string, er *EntityReflection, document any) error {
+	key := r.fieldKey(r.config.VersionField)
+	version := er.GetVersion()
+
+	set, err := withoutKeys(document, key)
+	if err != nil {
+		return err
+	}
+
+	filter := r.entityFilter(er)
+	filter[key] = versionFilter(version)
+	update := bson.M{"$set": set, "$inc": bson.M{key: 1}}
+
+	start := time.Now()
+	result, err := r.Collection().UpdateOne(r.config.Context, filter, update, r.updateComment(operation))
+	r.observe(operation, filter, start, 0, err)
+	if err != nil {
+		return classify(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrStaleDocument
+	}
+
+	er.SetVersion(version + 1)
+	return nil
+}
+
+// versionFilter matches a stored version; version 0 also matches the documents written before versioning was enabled.
+func versionFilter(version int64) any {
+	if version == 0 {
+		return bson.M{"$in": bson.A{0, nil}}
+	}
+
+	return version
+}
+
+// withoutKeys encodes a document without some of its top-level keys.
+func withoutKeys(document any, keys ...string) (bson.D, error) {
+	raw, err := bson.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	elements, err := rawElements(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := elements[:0]
+	for _, element := range elements {
+		if !slices.Contains(keys, element.Key) {
+			kept = append(kept, element)
+		}
+	}
+
+	return kept, nil
+}
+
+// UpdateFields writes only the given fields of a document, leaving the fields changed by other processes untouched.
+// The UpdatedAt field is set when configured. Selecting the document by ID only, it is broadcast to every shard
+// when the ID is not the shard key; on sharded collections, prefer UpdateMany with the shard key in the filter.
+//
+// Parameters:
+//   - id: The ID of the document to update.
+//   - fields: A BSON map of the keys to set and their values, e.g. bson.M{"name": "Jorge", "address.city": "Montevideo"}.
+//
+// Returns:
+//   - ErrNotFound if the document does not exist.
+//   - An error if the update operation fails.
+func (r *Repository[T]) UpdateFields(id any, fields bson.M) error {
+	if err := r.guardRegion(); err != nil {
+		return err
+	}
+
+	filter := r.regionScope(bson.M{"_id": id}).(bson.M)
+	start := time.Now()
+	result, err := r.Collection().UpdateOne(r.config.Context, filter, r.stampUpdate(bson.M{"$set": fields}), r.updateComment("UpdateFields"))
+	r.observe("UpdateFields", filter, start, 0, err)
+	if err != nil {
+		return classify(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return errNoDocuments
+	}
+
+	return nil
+}
+
+// Upsert updates the document matching the filter with the entity, or inserts the entity when nothing matches.
+// The ID and the CreatedAt field are only written on insert, while UpdatedAt is written in both cases.
+// The entity is then loaded with the stored document, so it holds the ID and CreatedAt of an existing document,
+// which is what the copies of the WriteTargets receive. With IDSequence, an entity without ID is first written as
+// an update, and a value of the sequence is only reserved for the upsert when nothing matched.
+//
+// Parameters:
+//   - entity: A pointer to the entity of type `T` to be written.
+//   - filter: A BSON map selecting the document to update, e.g. bson.M{"email": "jon@example.com"}.
+//
+// Returns:
+//   - true if the entity was inserted, false if an existing document was updated.
+//   - An error if the operation fails.
+func (r *Repository[T]) Upsert(entity *T, filter bson.M) (bool, error) {
+	if err := r.guardRegion(entity); err != nil {
+		return false, err
+	}
+
+	if len(r.config.WriteTargets) > 0 {
+		var inserted bool
+		err := r.fanOut(func(repo *Repository[T]) (err error) {
+			inserted, err = repo.Upsert(entity, filter)
+			return err
+		}, false, entity)
+		return inserted, err
+	}
+
+	er := NewEntityReflection(r.config, entity)
+	// a value of the IDSequence is only reserved once the filter matched nothing, so updates do not burn one
+	reserve := !er.HasID() && r.config.IDSequence != ""
+	if !er.HasID() && !reserve {
+		if err := r.assignIDs(er); err != nil {
+			return false, err
+		}
+	}
+
+	r.prepareInsert(er)
+	if r.config.UpdatedAtField != "" {
+		er.SetUpdateAt()
+	}
+
+	if err := r.copyFieldsOnWrite(entity); err != nil {
+		return false, err
+	}
+
+	document, err := r.guardSize(entity)
+	if err != nil {
+		return false, err
+	}
+
+	raw, err := bson.Marshal(document)
+	if err != nil {
+		return false, err
+	}
+
+	elements, err := rawElements(raw)
+	if err != nil {
+		return false, err
+	}
+
+	createdAtKey := ""
+	if r.config.CreatedAtField != "" {
+		createdAtKey = r.fieldKey(r.config.CreatedAtField)
+	}
+
+	versionKey := ""
+	if r.config.VersionField != "" {
+		versionKey = r.fieldKey(r.config.VersionField)
+	}
+
+	set, setOnInsert := bson.D{}, bson.D{}
+	for _, element := range elements {
+		switch {
+		case versionKey != "" && element.Key == versionKey:
+		case element.Key == "_id" && reserve:
+		case element.Key == "_id" || element.Key == createdAtKey:
+			setOnInsert = append(setOnInsert, element)
+		default:
+			set = append(set, element)
+		}
+	}
+
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if versionKey != "" {
+		update["$inc"] = bson.M{versionKey: 1}
+	}
+
+	scoped := r.regionScope(filter)
+	write := func(update bson.M, upsert bool) (*mongo.UpdateResult, error) {
+		start := time.Now()
+		result, err := r.Collection().UpdateOne(r.config.Context, scoped, update, r.updateComment("Upsert"), options.Update().SetUpsert(upsert))
+		r.observe("Upsert", filter, start, 0, err)
+		return result, classify(err)
+	}
+
+	var result *mongo.UpdateResult
+	if reserve && len(update) > 0 {
+		if result, err = write(update, false); err != nil {
+			return false, err
+		}
+	}
+
+	if result == nil || result.MatchedCount == 0 {
+		if reserve {
+			if err := r.assignIDs(er); err != nil {
+				return false, err
+			}
+			setOnInsert = append(setOnInsert, bson.E{Key: "_id", Value: er.GetID()})
+		}
+
+		upsert := bson.M{"$setOnInsert": setOnInsert}
+		for operator, operand := range update {
+			upsert[operator] = operand
+		}
+		if result, err = write(upsert, true); err != nil {
+			return false, err
+		}
+	}
+	inserted := result.UpsertedID != nil
+
+	// load the stored document, which holds the ID and CreatedAt of an existing document
+	stored := scoped
+	if inserted {
+		stored = bson.M{"_id": result.UpsertedID}
+	}
+	start := time.Now()
+	raw, err = r.Collection().FindOne(r.config.Context, stored, r.findOneComment("Upsert")).Raw()
+	r.observe("Upsert", stored, start, singleResult(err), err)
+	if err != nil {
+		return inserted, classify(err)
+	}
+
+	return inserted, r.hydrate(raw, entity)
+}
+
+// UpdateMany applies an update to every document matching the filter. Soft-deleted documents are left untouched
+// unless soft-delete scoping is disabled, and the UpdatedAt field is set when configured. With a BackupCollection,
+// the matching documents are backed up first.
+//
+// Parameters:
+//   - filter: A BSON map selecting the documents to update.
+//   - update: A BSON map of update operators, e.g. bson.M{"$set": bson.M{"status": "archived"}}.
+//
+// Returns:
+//   - The number of modified documents.
+//   - An error if the backup or the update fails.
+func (r *Repository[T]) UpdateMany(filter bson.M, update bson.M) (int64, error) {
+	if err := r.guardRegion(); err != nil {
+		return 0, err
+	}
+
+	scoped := r.scopeFilter(filter)
+	if err := r.backup("UpdateMany", scoped); err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	result, err := r.Collection().UpdateMany(r.config.Context, scoped, r.stampUpdate(update), r.updateComment("UpdateMany"))
+	r.observe("UpdateMany", filter, start, 0, err)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// stampUpdate adds the fields maintained by the repository to an update: the UpdatedAt field to its $set
+// and the increment of the VersionField to its $inc, when configured.
+func (r *Repository[T]) stampUpdate(update bson.M) bson.M {
+	if r.config.UpdatedAtField == "" && r.config.VersionField == "" {
+		return update
+	}
+
+	stamped := make(bson.M, len(update)+2)
+	for operator, value := range update {
+		stamped[operator] = value
+	}
+
+	if r.config.UpdatedAtField != "" {
+		stamped["$set"] = withOperand(update["$set"], r.fieldKey(r.config.UpdatedAtField), time.Now())
+	}
+
+	if r.config.VersionField != "" {
+		stamped["$inc"] = withOperand(update["$inc"], r.fieldKey(r.config.VersionField), 1)
+	}
+
+	return stamped
+}
+
+// stampPipeline is the counterpart of stampUpdate for update pipelines, appending a $set stage with the
+// UpdatedAt field and the incremented VersionField, when configured.
+func (r *Repository[T]) stampPipeline(update mongo.Pipeline) mongo.Pipeline {
+	stamp := bson.D{}
+	if r.config.UpdatedAtField != "" {
+		stamp = append(stamp, bson.E{Key: r.fieldKey(r.config.UpdatedAtField), Value: time.Now()})
+	}
+	if r.config.VersionField != "" {
+		key := r.fieldKey(r.config.VersionField)
+		stamp = append(stamp, bson.E{Key: key, Value: bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$" + key, 0}}, 1}}})
+	}
+
+	if len(stamp) == 0 {
+		return update
+	}
+
+	return append(append(mongo.Pipeline{}, update...), bson.D{{Key: "$set", Value: stamp}})
+}
+
+// withOperand adds a key to the operand of an update operator, unless the operand already holds it.
+func withOperand(operand any, key string, value any) any {
+	switch fields := operand.(type) {
+	case nil:
+		return bson.M{key: value}
+	case bson.M:
+		merged := make(bson.M, len(fields)+1)
+		for k, v := range fields {
+			merged[k] = v
+		}
+		if _, ok := merged[key]; !ok {
+			merged[key] = value
+		}
+		return merged
+	case bson.D:
+		if !hasKey(fields, key) {
+			return append(append(bson.D{}, fields...), bson.E{Key: key, Value: value})
+		}
+	}
 
-	_, err := r.Collection().UpdateByID(r.config.Context, er.GetID(), bson.M{"$set": entity})
-	return err
+	return operand
+}
+
+// DeleteMany removes every document matching the filter. If the configuration supports soft deletes,
+// the documents not deleted yet get their DeletedAt field set instead of being permanently deleted.
+// With a BackupCollection, the matching documents are backed up first.
+//
+// Parameters:
+//   - filter: A BSON map selecting the documents to delete.
+//
+// Returns:
+//   - The number of deleted (or soft-deleted) documents.
+//   - An error if the backup or the deletion fails.
+func (r *Repository[T]) DeleteMany(filter bson.M) (int64, error) {
+	if err := r.guardRegion(); err != nil {
+		return 0, err
+	}
+
+	if r.config.DeletedAtField != "" {
+		key := r.fieldKey(r.config.DeletedAtField)
+		scoped := r.regionScope(bson.M{"$and": bson.A{filter, bson.M{key: bson.M{"$exists": false}}}}).(bson.M)
+		if err := r.backup("DeleteMany", scoped); err != nil {
+			return 0, err
+		}
+
+		start := time.Now()
+		result, err := r.Collection().UpdateMany(r.config.Context, scoped, r.stampUpdate(bson.M{"$set": bson.M{key: time.Now()}}), r.updateComment("DeleteMany"))
+		r.observe("DeleteMany", filter, start, 0, err)
+		if err != nil {
+			return 0, err
+		}
+		return result.ModifiedCount, nil
+	}
+
+	scoped := r.regionScope(filter).(bson.M)
+	if err := r.backup("DeleteMany", scoped); err != nil {
+		return 0, err
+	}
+
+	offloaded, err := r.offloadedDocuments(scoped)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	result, err := r.Collection().DeleteMany(r.config.Context, scoped, r.deleteComment("DeleteMany"))
+	r.observe("DeleteMany", filter, start, 0, err)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, previous := range offloaded {
+		if err := r.releaseOffloaded(previous, nil); err != nil {
+			return result.DeletedCount, err
+		}
+	}
+
+	return result.DeletedCount, nil
 }
 
 // Delete removes an entity from the MongoDB Collection.
@@ -205,16 +1079,179 @@ func (r *Repository[T]) Update(entity *T) error {
 //   - entity: A pointer to the entity of type `T` to be deleted.
 //
 // Returns:
+//   - ErrNotFound if the document does not exist (ErrStaleDocument for a soft delete with VersionField configured).
 //   - An error if the deletion fails.
 func (r *Repository[T]) Delete(entity *T) error {
-	er := NewEntityReflection(r.config, entity)
-
 	// make update with timestamp over DeletedAtField if is set
 	if r.config.DeletedAtField != "" {
-		er.SetDeletedAt()
+		NewEntityReflection(r.config, entity).SetDeletedAt()
 		return r.Update(entity)
 	}
 
-	_, err := r.Collection().DeleteOne(r.config.Context, bson.M{"_id": er.GetID()})
-	return err
+	return r.ForceDelete(entity)
+}
+
+// ForceDelete permanently removes an entity from the MongoDB Collection, even when soft deletes are configured,
+// e.g. to purge a soft-deleted document. Its copies in the WriteTargets are removed in the same transaction.
+// With ShardKeyFields, the filter includes the shard key values of the entity, targeting a single shard.
+//
+// Parameters:
+//   - entity: A pointer to the entity of type `T` to be deleted.
+//
+// Returns:
+//   - ErrNotFound if the document does not exist.
+//   - An error if the deletion fails.
+func (r *Repository[T]) ForceDelete(entity *T) error {
+	if err := r.guardRegion(entity); err != nil {
+		return err
+	}
+
+	if len(r.config.WriteTargets) > 0 {
+		return r.fanOut(func(repo *Repository[T]) error { return repo.ForceDelete(entity) }, true, entity)
+	}
+
+	er := NewEntityReflection(r.config, entity)
+
+	previous, err := r.offloadedDocument(er.GetID())
+	if err != nil {
+		return err
+	}
+
+	filter := r.entityFilter(er)
+	start := time.Now()
+	result, err := r.Collection().DeleteOne(r.config.Context, filter, r.deleteComment("ForceDelete"))
+	r.observe("ForceDelete", filter, start, 0, err)
+	if err != nil {
+		return classify(err)
+	}
+	if result.DeletedCount == 0 {
+		return errNoDocuments
+	}
+
+	return r.releaseOffloaded(previous, nil)
+}
+
+// Restore undoes the soft delete of an entity, removing its DeletedAt field; the UpdatedAt field is set and the
+// VersionField incremented when configured.
+// With ShardKeyFields, the filter includes the shard key values of the entity, targeting a single shard.
+//
+// Parameters:
+//   - entity: A pointer to the soft-deleted entity of type `T`.
+//
+// Returns:
+//   - ErrNotFound if the document does not exist.
+//   - An error if the update fails.
+//
+// Panics:
+//   - If DeletedAtField is not configured.
+func (r *Repository[T]) Restore(entity *T) error {
+	if r.config.DeletedAtField == "" {
+		panic("Configuration error: Restore requires the DeletedAtField to be set.")
+	}
+
+	if err := r.guardRegion(entity); err != nil {
+		return err
+	}
+
+	er := NewEntityReflection(r.config, entity)
+	er.ClearDeletedAt()
+	if r.config.UpdatedAtField != "" {
+		er.SetUpdateAt()
+	}
+
+	update := bson.M{"$unset": bson.M{r.fieldKey(r.config.DeletedAtField): ""}}
+	if r.config.UpdatedAtField != "" {
+		update["$set"] = bson.M{r.fieldKey(r.config.UpdatedAtField): er.GetTimeField(r.config.UpdatedAtField)}
+	}
+
+	filter := r.entityFilter(er)
+	start := time.Now()
+	result, err := r.Collection().UpdateOne(r.config.Context, filter, r.stampUpdate(update), r.updateComment("Restore"))
+	r.observe("Restore", filter, start, 0, err)
+	if err != nil {
+		return classify(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return errNoDocuments
+	}
+
+	if r.config.VersionField != "" {
+		er.SetVersion(er.GetVersion() + 1)
+	}
+
+	return nil
+}
+
+// FindOneAndUpdate atomically updates the first document matching the filter and returns it, by default as it was
+// before the update; use options.FindOneAndUpdate().SetReturnDocument(options.After) to get the updated document.
+// Soft-deleted documents are left untouched unless soft-delete scoping is disabled, and UpdatedAt is set when configured.
+//
+// Parameters:
+//   - filter: A BSON map selecting the document to update.
+//   - update: A BSON map of update operators.
+//   - opts: Optional FindOneAndUpdateOptions (e.g., return document, sort, upsert).
+//
+// Returns:
+//   - A pointer to the decoded entity of type `T`.
+//   - ErrNotFound if no document matches the filter, or an error if the operation fails.
+func (r *Repository[T]) FindOneAndUpdate(filter bson.M, update bson.M, opts ...*options.FindOneAndUpdateOptions) (*T, error) {
+	if err := r.guardRegion(); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	raw, err := r.Collection().FindOneAndUpdate(r.config.Context, r.scopeFilter(filter), r.stampUpdate(update), append([]*options.FindOneAndUpdateOptions{r.findOneAndUpdateComment("FindOneAndUpdate")}, opts...)...).Raw()
+	r.observe("FindOneAndUpdate", filter, start, singleResult(err), err)
+	if err != nil {
+		return nil, classify(err)
+	}
+
+	return r.decodeEntity(raw)
+}
+
+// FindOneAndDelete atomically deletes the first document matching the filter and returns it.
+// If the configuration supports soft deletes, the DeletedAt field of the first document not deleted yet
+// is set instead, and the returned entity holds it.
+//
+// Parameters:
+//   - filter: A BSON map selecting the document to delete.
+//
+// Returns:
+//   - A pointer to the decoded entity of type `T`.
+//   - ErrNotFound if no document matches the filter, or an error if the operation fails.
+func (r *Repository[T]) FindOneAndDelete(filter bson.M) (*T, error) {
+	if err := r.guardRegion(); err != nil {
+		return nil, err
+	}
+
+	if r.config.DeletedAtField != "" {
+		key := r.fieldKey(r.config.DeletedAtField)
+		scoped := r.regionScope(bson.M{"$and": bson.A{filter, bson.M{key: bson.M{"$exists": false}}}})
+		update := r.stampUpdate(bson.M{"$set": bson.M{key: time.Now()}})
+
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		start := time.Now()
+		raw, err := r.Collection().FindOneAndUpdate(r.config.Context, scoped, update, r.findOneAndUpdateComment("FindOneAndDelete"), opts).Raw()
+		r.observe("FindOneAndDelete", filter, start, singleResult(err), err)
+		if err != nil {
+			return nil, classify(err)
+		}
+
+		return r.decodeEntity(raw)
+	}
+
+	start := time.Now()
+	raw, err := r.Collection().FindOneAndDelete(r.config.Context, r.regionScope(filter), r.findOneAndDeleteComment("FindOneAndDelete")).Raw()
+	r.observe("FindOneAndDelete", filter, start, singleResult(err), err)
+	if err != nil {
+		return nil, classify(err)
+	}
+
+	entity, err := r.decodeEntity(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity, r.releaseOffloaded(raw, nil)
 }
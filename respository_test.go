@@ -0,0 +1,136 @@
+package mongorepo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+type storedNote struct {
+	ID   primitive.ObjectID `bson:"_id"`
+	Text string             `bson:"text"`
+}
+
+func TestWritesOfMissingDocuments(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	writes := map[string]func(repo *Repository[storedNote], note *storedNote) error{
+		"Update":      (*Repository[storedNote]).Update,
+		"ForceDelete": (*Repository[storedNote]).ForceDelete,
+	}
+
+	for name, write := range writes {
+		mt.Run(name, func(mt *mtest.T) {
+			repo := New[storedNote](&Config{MongoClient: mt.Client, DbName: "notes"})
+			mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}))
+
+			err := write(repo, &storedNote{ID: primitive.NewObjectID(), Text: "gone"})
+			if !errors.Is(err, ErrNotFound) {
+				mt.Errorf("got %v, expected ErrNotFound", err)
+			}
+		})
+	}
+}
+
+type upsertedAccount struct {
+	ID    int64  `bson:"_id"`
+	Email string `bson:"email"`
+}
+
+func TestUpsert(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	filter := bson.M{"email": "jon@example.com"}
+	stored := bson.D{{Key: "_id", Value: int64(7)}, {Key: "email", Value: "jon@example.com"}}
+
+	tests := []struct {
+		name      string
+		responses []bson.D
+		inserted  bool
+		commands  string
+	}{
+		{
+			name: "existing document",
+			responses: []bson.D{
+				mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+				mtest.CreateCursorResponse(0, "bank.upserted_accounts", mtest.FirstBatch, stored),
+			},
+			commands: "update,find",
+		},
+		{
+			name: "new document",
+			responses: []bson.D{
+				mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}),
+				mtest.CreateSuccessResponse(bson.E{Key: "value", Value: bson.D{{Key: "_id", Value: "upserted_accounts"}, {Key: "seq", Value: int64(7)}}}),
+				mtest.CreateSuccessResponse(
+					bson.E{Key: "n", Value: 1},
+					bson.E{Key: "nModified", Value: 0},
+					bson.E{Key: "upserted", Value: bson.A{bson.D{{Key: "index", Value: 0}, {Key: "_id", Value: int64(7)}}}},
+				),
+				mtest.CreateCursorResponse(0, "bank.upserted_accounts", mtest.FirstBatch, stored),
+			},
+			inserted: true,
+			commands: "update,findAndModify,update,find",
+		},
+	}
+
+	for _, test := range tests {
+		mt.Run(test.name, func(mt *mtest.T) {
+			repo := New[upsertedAccount](&Config{MongoClient: mt.Client, DbName: "bank", IDSequence: "upserted_accounts"})
+			mt.AddMockResponses(test.responses...)
+
+			account := &upsertedAccount{Email: "jon@example.com"}
+			inserted, err := repo.Upsert(account, filter)
+			if err != nil {
+				mt.Fatal(err)
+			}
+			if inserted != test.inserted {
+				mt.Errorf("got inserted %v, expected %v", inserted, test.inserted)
+			}
+			if account.ID != 7 {
+				mt.Errorf("got the ID %d, expected the one of the stored document", account.ID)
+			}
+
+			var commands []string
+			for _, event := range mt.GetAllStartedEvents() {
+				commands = append(commands, event.CommandName)
+			}
+			if strings.Join(commands, ",") != test.commands {
+				mt.Errorf("got the commands %v, expected %s", commands, test.commands)
+			}
+		})
+	}
+}
+
+type rankedItem struct {
+	ID int64 `bson:"_id"`
+}
+
+func TestFindByIdsOrdered(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("ids of another integer type", func(mt *mtest.T) {
+		repo := New[rankedItem](&Config{MongoClient: mt.Client, DbName: "search"})
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "search.ranked_items", mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: int64(1)}},
+			bson.D{{Key: "_id", Value: int64(2)}},
+			bson.D{{Key: "_id", Value: int64(3)}},
+		))
+
+		items, err := repo.FindByIdsOrdered([]any{3, 4, int32(1), 2.0})
+		if err != nil {
+			mt.Fatal(err)
+		}
+
+		var ids []int64
+		for _, item := range items {
+			ids = append(ids, item.ID)
+		}
+		if len(ids) != 3 || ids[0] != 3 || ids[1] != 1 || ids[2] != 2 {
+			mt.Errorf("got the IDs %v, expected [3 1 2]", ids)
+		}
+	})
+}
@@ -0,0 +1,219 @@
+package mongorepo
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// HashTarget is a collection, optionally in another database, receiving a share of the routed entities.
+type HashTarget struct {
+	DbName         string // The database of the target, default: the database of the base repository
+	CollectionName string // The collection of the target.
+}
+
+// HashRouterConfig holds the configuration of a HashRouter.
+type HashRouterConfig struct {
+	Targets      []HashTarget // The collections sharing the data.
+	KeyField     string       // The field in the entity struct holding the routing key, e.g. "TenantID".
+	VirtualNodes int          // The number of points of each target on the hash ring, default: 128
+}
+
+// RebalanceReport is the outcome of Rebalance.
+type RebalanceReport struct {
+	Scanned int64 // The number of documents scanned.
+	Moved   int64 // The number of documents moved to their new target.
+}
+
+// HashRouter maps routing keys to one of several collections with consistent hashing, for datasets partitioned
+// manually beyond the practical limits of a single collection. Adding or removing a target only remaps
+// the keys of the neighboring ring segments, which Rebalance moves.
+type HashRouter[T any] struct {
+	base   *Repository[T]
+	config HashRouterConfig
+	ring   []ringPoint
+	repos  []*Repository[T]
+}
+
+// ringPoint is a virtual node of a target on the hash ring.
+type ringPoint struct {
+	hash   uint64
+	target int
+}
+
+// NewHashRouter creates a HashRouter over views of the base repository.
+//
+// Parameters:
+//   - base: The repository whose configuration the targets share.
+//   - config: The targets, the routing key field and the ring settings.
+//
+// Returns:
+//   - A pointer to a HashRouter.
+//
+// Panics:
+//   - If no target or no KeyField is configured, or a target has no CollectionName.
+func NewHashRouter[T any](base *Repository[T], config HashRouterConfig) *HashRouter[T] {
+	if len(config.Targets) == 0 {
+		panic("Configuration error: HashRouter requires at least one target.")
+	}
+
+	if config.KeyField == "" {
+		panic("Configuration error: The HashRouter KeyField is not set.")
+	}
+
+	if config.VirtualNodes <= 0 {
+		config.VirtualNodes = 128
+	}
+
+	h := &HashRouter[T]{base: base, config: config}
+	for i, target := range config.Targets {
+		if target.CollectionName == "" {
+			panic("Configuration error: A HashRouter target has no CollectionName.")
+		}
+
+		repo := base.OnCollection(target.CollectionName)
+		if target.DbName != "" {
+			repo.config.DbName = target.DbName
+		}
+		h.repos = append(h.repos, repo)
+
+		for node := 0; node < config.VirtualNodes; node++ {
+			h.ring = append(h.ring, ringPoint{hash: ringHash(target.DbName + "." + target.CollectionName + "#" + strconv.Itoa(node)), target: i})
+		}
+	}
+	sort.Slice(h.ring, func(i, j int) bool { return h.ring[i].hash < h.ring[j].hash })
+
+	return h
+}
+
+// Route returns the repository of the target owning a routing key.
+//
+// Parameters:
+//   - key: The routing key, formatted with fmt.Sprint before hashing.
+//
+// Returns:
+//   - A pointer to the Repository of the target.
+func (h *HashRouter[T]) Route(key any) *Repository[T] {
+	return h.repos[h.targetIndex(key)]
+}
+
+// For returns the repository of the target owning an entity, according to its KeyField.
+//
+// Parameters:
+//   - entity: A pointer to the entity to route.
+//
+// Returns:
+//   - A pointer to the Repository of the target.
+func (h *HashRouter[T]) For(entity *T) *Repository[T] {
+	return h.Route(NewEntityReflection(h.base.config, entity).GetField(h.config.KeyField))
+}
+
+// Target returns the target owning a routing key.
+//
+// Parameters:
+//   - key: The routing key.
+//
+// Returns:
+//   - The HashTarget of the key.
+func (h *HashRouter[T]) Target(key any) HashTarget {
+	return h.config.Targets[h.targetIndex(key)]
+}
+
+// Targets returns the configured targets, e.g. to build the router of a new layout.
+//
+// Returns:
+//   - A copy of the targets.
+func (h *HashRouter[T]) Targets() []HashTarget {
+	return append([]HashTarget(nil), h.config.Targets...)
+}
+
+// Repositories returns the repositories of every target, e.g. to query all of them.
+//
+// Returns:
+//   - The repositories, in the order of the configured targets.
+func (h *HashRouter[T]) Repositories() []*Repository[T] {
+	return append([]*Repository[T](nil), h.repos...)
+}
+
+// Rebalance moves the documents of the targets of h whose key is owned by another target in the router `to`,
+// typically built with a target added or removed. Each document is upserted in its new target before being
+// deleted from the old one, so an interrupted rebalance can simply be run again. Soft-deleted documents are moved too.
+//
+// Parameters:
+//   - to: The router describing the new layout.
+//
+// Returns:
+//   - The report of the scanned and moved documents, also on failure.
+//   - An error if a read or a write fails.
+func (h *HashRouter[T]) Rebalance(to *HashRouter[T]) (*RebalanceReport, error) {
+	report := &RebalanceReport{}
+	ctx := h.base.config.Context
+
+	for i, source := range h.repos {
+		cursor, err := source.Collection().Find(ctx, bson.M{}, options.Find().SetBatchSize(defaultBatchSize))
+		if err != nil {
+			return report, err
+		}
+
+		err = func() error {
+			defer cursor.Close(ctx)
+
+			for cursor.Next(ctx) {
+				report.Scanned++
+
+				var entity T
+				if err := cursor.Decode(&entity); err != nil {
+					return err
+				}
+
+				destination := to.For(&entity)
+				if sameTarget(destination, source) {
+					continue
+				}
+
+				id := cursor.Current.Lookup("_id")
+				if _, err := destination.Collection().ReplaceOne(ctx, bson.M{"_id": id}, cursor.Current, options.Replace().SetUpsert(true)); err != nil {
+					return err
+				}
+				if _, err := source.Collection().DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+					return err
+				}
+				report.Moved++
+			}
+
+			return cursor.Err()
+		}()
+		if err != nil {
+			return report, fmt.Errorf("rebalancing %s: %w", h.config.Targets[i].CollectionName, err)
+		}
+	}
+
+	return report, nil
+}
+
+// targetIndex finds the first ring point at or after the hash of the key.
+func (h *HashRouter[T]) targetIndex(key any) int {
+	hash := ringHash(fmt.Sprint(key))
+	i := sort.Search(len(h.ring), func(i int) bool { return h.ring[i].hash >= hash })
+	if i == len(h.ring) {
+		i = 0
+	}
+
+	return h.ring[i].target
+}
+
+// sameTarget reports whether two repositories operate on the same collection.
+func sameTarget[T any](a, b *Repository[T]) bool {
+	return a.config.DbName == b.config.DbName && a.config.CollectionName == b.config.CollectionName
+}
+
+// ringHash hashes a string onto the ring. SHA-256 is used for its uniform distribution, even for similar inputs.
+func ringHash(s string) uint64 {
+	sum := sha256.Sum256([]byte(s))
+	return binary.BigEndian.Uint64(sum[:8])
+}
@@ -0,0 +1,101 @@
+package mongorepo
+
+import (
+	"fmt"
+	"testing"
+)
+
+type routedEvent struct {
+	ID       string `bson:"_id"`
+	TenantID string `bson:"tenant_id"`
+}
+
+func TestHashRouter(t *testing.T) {
+	base := New[routedEvent](&Config{MongoClient: offlineClient(t), DbName: "events"})
+	targets := []HashTarget{{CollectionName: "events_0"}, {CollectionName: "events_1"}, {DbName: "archive", CollectionName: "events_2"}}
+	router := NewHashRouter(base, HashRouterConfig{Targets: targets, KeyField: "TenantID"})
+
+	keys := make([]string, 3000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("tenant-%d", i)
+	}
+
+	shares := map[string]int{}
+	for _, key := range keys {
+		repo := router.Route(key)
+		target := router.Target(key)
+
+		if repo != router.Route(key) {
+			t.Fatalf("%s is routed to two targets", key)
+		}
+		if repo != router.For(&routedEvent{TenantID: key}) {
+			t.Fatalf("For and Route disagree on %s", key)
+		}
+		if repo.config.CollectionName != target.CollectionName {
+			t.Fatalf("%s: the repository targets %s, expected %s", key, repo.config.CollectionName, target.CollectionName)
+		}
+		shares[target.CollectionName]++
+	}
+
+	if db := router.Route(keysOf(router, keys, "events_2")).config.DbName; db != "archive" {
+		t.Errorf("the target of another database got the database %q", db)
+	}
+	if db := router.Route(keysOf(router, keys, "events_0")).config.DbName; db != "events" {
+		t.Errorf("the target without database got the database %q, expected the one of the base", db)
+	}
+
+	for _, target := range targets {
+		if share := shares[target.CollectionName]; share < len(keys)/5 {
+			t.Errorf("%s got %d keys out of %d", target.CollectionName, share, len(keys))
+		}
+	}
+
+	// adding a target only moves keys to the new target
+	grown := NewHashRouter(base, HashRouterConfig{Targets: append(router.Targets(), HashTarget{CollectionName: "events_3"}), KeyField: "TenantID"})
+	moved := 0
+	for _, key := range keys {
+		before, after := router.Target(key), grown.Target(key)
+		if before == after {
+			continue
+		}
+		if after.CollectionName != "events_3" {
+			t.Fatalf("%s moved from %s to %s instead of the new target", key, before.CollectionName, after.CollectionName)
+		}
+		moved++
+	}
+	if moved == 0 || moved > len(keys)/2 {
+		t.Errorf("%d keys out of %d moved to the new target", moved, len(keys))
+	}
+}
+
+// keysOf returns a key routed to a collection.
+func keysOf(router *HashRouter[routedEvent], keys []string, collection string) string {
+	for _, key := range keys {
+		if router.Target(key).CollectionName == collection {
+			return key
+		}
+	}
+
+	return ""
+}
+
+func TestNewHashRouterPanics(t *testing.T) {
+	base := New[routedEvent](&Config{MongoClient: offlineClient(t), DbName: "events"})
+
+	tests := map[string]HashRouterConfig{
+		"no target":          {KeyField: "TenantID"},
+		"no key field":       {Targets: []HashTarget{{CollectionName: "events_0"}}},
+		"no collection name": {Targets: []HashTarget{{DbName: "archive"}}, KeyField: "TenantID"},
+	}
+
+	for name, config := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			NewHashRouter(base, config)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package mongorepo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// leaseKey is the BSON key storing the lease expiration of a claimed document.
+const leaseKey = "_mongorepo_lease"
+
+// SchedulerConfig holds the configuration of a Scheduler.
+type SchedulerConfig[T any] struct {
+	ScheduledAtField string                                                  // The field in the entity struct holding the due date; must be of type time.Time.
+	Handler          func(ctx context.Context, entity *T) (time.Time, error) // Processes a due document, returning the next due date or the zero time when done.
+	LeaseDuration    time.Duration                                           // How long a claimed document is reserved for its handler, default: 1 minute
+	RetryDelay       time.Duration                                           // The delay before a failed document is due again, default: LeaseDuration
+	PollInterval     time.Duration                                           // The wait between polls when no document is due, default: 1 second
+	OnError          func(entity *T, err error)                              // Optional callback invoked when the handler fails.
+}
+
+// Scheduler claims documents whose due date has passed, processes them with a handler and then
+// reschedules or completes them. Claims are atomic and leased, so several workers can poll the same
+// collection and a crashed worker's documents become due again once the lease expires.
+type Scheduler[T any] struct {
+	repo   *Repository[T]
+	config SchedulerConfig[T]
+}
+
+// NewScheduler creates a Scheduler on top of a repository.
+//
+// Parameters:
+//   - repo: The repository holding the scheduled entities.
+//   - config: The scheduler configuration.
+//
+// Returns:
+//   - A pointer to a Scheduler.
+//
+// Panics:
+//   - If config.ScheduledAtField or config.Handler is not set.
+func NewScheduler[T any](repo *Repository[T], config SchedulerConfig[T]) *Scheduler[T] {
+	if config.ScheduledAtField == "" {
+		panic("Configuration error: The SchedulerConfig.ScheduledAtField is not set.")
+	}
+
+	if config.Handler == nil {
+		panic("Configuration error: The SchedulerConfig.Handler is not set.")
+	}
+
+	if config.LeaseDuration <= 0 {
+		config.LeaseDuration = time.Minute
+	}
+
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = config.LeaseDuration
+	}
+
+	if config.PollInterval <= 0 {
+		config.PollInterval = time.Second
+	}
+
+	return &Scheduler[T]{repo: repo, config: config}
+}
+
+// EnsureIndex creates the index on the due date used to find due documents efficiently.
+//
+// Returns:
+//   - An error if the index cannot be created.
+func (s *Scheduler[T]) EnsureIndex() error {
+	index := mongo.IndexModel{Keys: bson.D{{Key: s.repo.fieldKey(s.config.ScheduledAtField), Value: 1}}}
+	_, err := s.repo.Collection().Indexes().CreateOne(s.repo.config.Context, index)
+	return err
+}
+
+// Run polls and processes due documents until the context is cancelled.
+//
+// Parameters:
+//   - ctx: The context controlling the lifetime of the loop, passed to the handler.
+//
+// Returns:
+//   - The context error once cancelled, or an error if a claim fails.
+func (s *Scheduler[T]) Run(ctx context.Context) error {
+	for {
+		processed, err := s.RunOnce(ctx)
+		if err != nil {
+			return err
+		}
+
+		if processed > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.config.PollInterval):
+		}
+	}
+}
+
+// RunOnce claims and processes due documents until none is left.
+//
+// Parameters:
+//   - ctx: The context of the operation, passed to the handler.
+//
+// Returns:
+//   - The number of processed documents.
+//   - An error if a claim or a completion update fails.
+func (s *Scheduler[T]) RunOnce(ctx context.Context) (int, error) {
+	processed := 0
+
+	for ctx.Err() == nil {
+		entity, lease, err := s.claim(ctx)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return processed, nil
+		}
+		if err != nil {
+			return processed, err
+		}
+
+		next, handlerErr := s.config.Handler(ctx, entity)
+		if handlerErr != nil {
+			next = time.Now().Add(s.config.RetryDelay)
+			if s.config.OnError != nil {
+				s.config.OnError(entity, handlerErr)
+			}
+		}
+
+		if err := s.release(ctx, entity, lease, next); err != nil {
+			return processed, err
+		}
+		processed++
+	}
+
+	return processed, ctx.Err()
+}
+
+// claim atomically leases the oldest due document.
+// The lease is stamped like the other updates of the repository (UpdatedAt and VersionField, when configured),
+// so it is seen by the change detection of the clients.
+func (s *Scheduler[T]) claim(ctx context.Context) (*T, time.Time, error) {
+	repo := s.repo.WithContext(ctx)
+	if err := repo.guardRegion(); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	now := time.Now()
+	lease := now.Add(s.config.LeaseDuration).Truncate(time.Millisecond)
+	scheduledKey := repo.fieldKey(s.config.ScheduledAtField)
+
+	filter := repo.scopeFilter(bson.M{
+		scheduledKey: bson.M{"$lte": now},
+		"$or": bson.A{
+			bson.M{leaseKey: bson.M{"$exists": false}},
+			bson.M{leaseKey: bson.M{"$lte": now}},
+		},
+	})
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: scheduledKey, Value: 1}}).
+		SetReturnDocument(options.After)
+
+	start := time.Now()
+	raw, err := repo.Collection().FindOneAndUpdate(ctx, filter, repo.stampUpdate(bson.M{"$set": bson.M{leaseKey: lease}}), repo.findOneAndUpdateComment("SchedulerClaim"), opts).Raw()
+	repo.observe("SchedulerClaim", filter, start, singleResult(err), err)
+	if err != nil {
+		return nil, time.Time{}, classify(err)
+	}
+
+	entity, err := repo.decodeEntity(raw, leaseKey)
+	return entity, lease, err
+}
+
+// release drops the lease of a processed document, rescheduling it at next or completing it when next is zero.
+// The update is conditioned on the lease so a worker whose lease expired cannot override a newer claim.
+// Soft-deleted documents are released too, e.g. when the handler deleted the document.
+func (s *Scheduler[T]) release(ctx context.Context, entity *T, lease time.Time, next time.Time) error {
+	repo := s.repo.WithContext(ctx)
+	if err := repo.guardRegion(entity); err != nil {
+		return err
+	}
+
+	scheduledKey := repo.fieldKey(s.config.ScheduledAtField)
+
+	update := bson.M{"$unset": bson.M{leaseKey: ""}}
+	if next.IsZero() {
+		update["$unset"] = bson.M{leaseKey: "", scheduledKey: ""}
+	} else {
+		update["$set"] = bson.M{scheduledKey: next}
+	}
+
+	filter := repo.entityFilter(NewEntityReflection(repo.config, entity))
+	filter[leaseKey] = lease
+
+	start := time.Now()
+	_, err := repo.Collection().UpdateOne(ctx, filter, repo.stampUpdate(update), repo.updateComment("SchedulerRelease"))
+	repo.observe("SchedulerRelease", filter, start, 0, err)
+	return classify(err)
+}
@@ -0,0 +1,57 @@
+package mongorepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+type scheduledReminder struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	DueAt     time.Time          `bson:"due_at"`
+	UpdatedAt time.Time          `bson:"updated_at"`
+}
+
+func TestSchedulerStampsTheLeases(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("claim and release", func(mt *mtest.T) {
+		repo := New[scheduledReminder](&Config{MongoClient: mt.Client, DbName: "app", UpdatedAtField: "UpdatedAt"})
+		scheduler := NewScheduler(repo, SchedulerConfig[scheduledReminder]{
+			ScheduledAtField: "DueAt",
+			Handler:          func(ctx context.Context, reminder *scheduledReminder) (time.Time, error) { return time.Time{}, nil },
+		})
+
+		due := bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "due_at", Value: time.Now().Add(-time.Minute)}}
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: due}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: nil}),
+		)
+
+		processed, err := scheduler.RunOnce(context.Background())
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if processed != 1 {
+			mt.Errorf("processed %d reminders, expected 1", processed)
+		}
+
+		claim := mt.GetStartedEvent().Command
+		if _, err := claim.LookupErr("update", "$set", "updated_at"); err != nil {
+			mt.Errorf("the claim %v is not stamped", claim.Lookup("update"))
+		}
+
+		release := mt.GetStartedEvent().Command.Lookup("updates", "0").Document()
+		if _, err := release.LookupErr("q", leaseKey); err != nil {
+			mt.Errorf("the release filter %v is not conditioned on the lease", release.Lookup("q"))
+		}
+		if _, err := release.LookupErr("u", "$set", "updated_at"); err != nil {
+			mt.Errorf("the release %v is not stamped", release.Lookup("u"))
+		}
+	})
+}
@@ -0,0 +1,260 @@
+package mongorepo
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Schema is a JSON Schema document, ready to be encoded with encoding/json.
+type Schema map[string]any
+
+// schemaDialect selects the flavor of the generated schemas.
+type schemaDialect int
+
+const (
+	dialectJSONSchema schemaDialect = iota // JSON Schema 2020-12: optional values are typed [type, "null"].
+	dialectOpenAPI                         // OpenAPI 3.0 schema objects: optional values are nullable.
+	dialectMongo                           // MongoDB $jsonSchema validators: values are typed by bsonType.
+)
+
+// ExportSchema builds the JSON Schema (2020-12) of the documents of `T` from its `bson` and `validate` tags:
+// properties are named after the BSON keys, `required` fields are listed as required, min/max become
+// length, value or item count bounds and oneof becomes an enum. Pointer fields accept null.
+//
+// Returns:
+//   - The JSON Schema of `T`.
+func ExportSchema[T any]() Schema {
+	entityType := reflect.TypeOf((*T)(nil)).Elem()
+
+	schema := typeSchema(entityType, dialectJSONSchema, map[reflect.Type]bool{})
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = entityType.Name()
+
+	return schema
+}
+
+// ExportOpenAPISchema builds the OpenAPI 3.0 schema object of the documents of `T`, see ExportSchema,
+// to be registered under components.schemas.
+//
+// Returns:
+//   - The OpenAPI schema object of `T`.
+func ExportOpenAPISchema[T any]() Schema {
+	entityType := reflect.TypeOf((*T)(nil)).Elem()
+
+	schema := typeSchema(entityType, dialectOpenAPI, map[reflect.Type]bool{})
+	schema["title"] = entityType.Name()
+
+	return schema
+}
+
+// ExportMongoSchema builds the MongoDB $jsonSchema of the documents of `T`, see ExportSchema: values are typed
+// with their BSON type (objectId, date, int/long...) so the schema can be used as a collection validator.
+//
+// Returns:
+//   - The $jsonSchema of `T`.
+func ExportMongoSchema[T any]() Schema {
+	entityType := reflect.TypeOf((*T)(nil)).Elem()
+
+	schema := typeSchema(entityType, dialectMongo, map[reflect.Type]bool{})
+	schema["title"] = entityType.Name()
+
+	return schema
+}
+
+// typeSchema builds the schema of a Go type. Recursive types are cut with an unconstrained object schema.
+func typeSchema(t reflect.Type, dialect schemaDialect, visiting map[reflect.Type]bool) Schema {
+	if t.Kind() == reflect.Pointer {
+		schema := typeSchema(derefType(t), dialect, visiting)
+		return nullable(schema, dialect)
+	}
+
+	if null, ok := reflect.Zero(t).Interface().(nullValue); ok {
+		return nullable(typeSchema(null.valueType(), dialect, visiting), dialect)
+	}
+
+	switch t {
+	case reflect.TypeOf(time.Time{}), reflect.TypeOf(primitive.DateTime(0)):
+		return dialect.typed(Schema{"type": "string", "format": "date-time"}, "date")
+	case reflect.TypeOf(primitive.ObjectID{}):
+		return dialect.typed(Schema{"type": "string", "pattern": "^[0-9a-fA-F]{24}$"}, "objectId")
+	case reflect.TypeOf(primitive.Decimal128{}):
+		return dialect.typed(Schema{"type": "string", "format": "decimal"}, "decimal")
+	case reflect.TypeOf(CompressedString("")):
+		return dialect.typed(Schema{"type": "string"}, "binData")
+	case reflect.TypeOf(CompressedBytes(nil)):
+		return dialect.typed(Schema{"type": "string", "format": "byte"}, "binData")
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return dialect.typed(Schema{"type": "string"}, "string")
+	case reflect.Bool:
+		return dialect.typed(Schema{"type": "boolean"}, "bool")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return dialect.typed(Schema{"type": "integer"}, []string{"int", "long"})
+	case reflect.Float32, reflect.Float64:
+		return dialect.typed(Schema{"type": "number"}, "number")
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return dialect.nilable(t, dialect.typed(Schema{"type": "string", "format": "byte"}, "binData"))
+		}
+		schema := dialect.typed(Schema{"type": "array"}, "array")
+		schema["items"] = typeSchema(t.Elem(), dialect, visiting)
+		return dialect.nilable(t, schema)
+	case reflect.Map:
+		schema := dialect.typed(Schema{"type": "object"}, "object")
+		schema["additionalProperties"] = typeSchema(t.Elem(), dialect, visiting)
+		return dialect.nilable(t, schema)
+	case reflect.Struct:
+		if visiting[t] {
+			return dialect.typed(Schema{"type": "object"}, "object")
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		properties, required := Schema{}, []string{}
+		structSchema(t, dialect, visiting, properties, &required)
+
+		schema := dialect.typed(Schema{"type": "object"}, "object")
+		schema["properties"] = properties
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+
+	return Schema{}
+}
+
+// structSchema adds the properties of the fields of a struct, looking into inlined structs.
+func structSchema(t reflect.Type, dialect schemaDialect, visiting map[reflect.Type]bool, properties Schema, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("bson")
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+
+		if strings.Contains(tag, ",inline") && derefType(field.Type).Kind() == reflect.Struct {
+			structSchema(derefType(field.Type), dialect, visiting, properties, required)
+			continue
+		}
+
+		key := bsonFieldName(t, field.Name)
+		schema := typeSchema(field.Type, dialect, visiting)
+
+		for _, rule := range parseValidateTag(field.Tag.Get("validate")) {
+			if rule.name == "required" {
+				*required = append(*required, key)
+				continue
+			}
+			applySchemaRule(schema, derefType(field.Type), rule, dialect)
+		}
+
+		properties[key] = schema
+	}
+}
+
+// applySchemaRule translates a validate rule into the matching JSON Schema keywords.
+func applySchemaRule(schema Schema, t reflect.Type, rule validateRule, dialect schemaDialect) {
+	switch rule.name {
+	case "min", "max":
+		limit, err := strconv.ParseFloat(rule.arg, 64)
+		if err != nil {
+			return
+		}
+
+		typ := schema["type"]
+		if bsonType, ok := schema["bsonType"]; ok {
+			typ = bsonType
+		}
+		if types, ok := typ.([]string); ok {
+			typ = types[0]
+		}
+
+		var keyword string
+		switch typ {
+		case "string":
+			keyword = "Length"
+		case "array":
+			keyword = "Items"
+		case "object":
+			keyword = "Properties"
+		case "integer", "number", "int":
+			keyword = "imum"
+		default:
+			return
+		}
+
+		if dialect == dialectMongo && keyword != "imum" {
+			schema[rule.name+keyword] = int64(limit)
+			return
+		}
+		schema[rule.name+keyword] = limit
+	case "oneof":
+		var enum []any
+		for _, option := range strings.Fields(rule.arg) {
+			switch t.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+				reflect.Float32, reflect.Float64:
+				if number, err := strconv.ParseFloat(option, 64); err == nil {
+					enum = append(enum, number)
+				}
+			default:
+				enum = append(enum, option)
+			}
+		}
+		schema["enum"] = enum
+	}
+}
+
+// nullable makes a schema accept null in the given dialect.
+func nullable(schema Schema, dialect schemaDialect) Schema {
+	if dialect == dialectOpenAPI {
+		schema["nullable"] = true
+		return schema
+	}
+
+	if dialect == dialectMongo {
+		switch typ := schema["bsonType"].(type) {
+		case string:
+			schema["bsonType"] = []string{typ, "null"}
+		case []string:
+			if !slices.Contains(typ, "null") {
+				schema["bsonType"] = append(typ, "null")
+			}
+		}
+		return schema
+	}
+
+	if typ, ok := schema["type"].(string); ok {
+		schema["type"] = []string{typ, "null"}
+	}
+
+	return schema
+}
+
+// typed returns the schema of a leaf type in the dialect: the JSON Schema given, or its BSON type for MongoDB.
+func (dialect schemaDialect) typed(schema Schema, bsonType any) Schema {
+	if dialect == dialectMongo {
+		return Schema{"bsonType": bsonType}
+	}
+
+	return schema
+}
+
+// nilable makes the schema of a slice or map accept null for MongoDB, as the driver encodes nil ones as null.
+func (dialect schemaDialect) nilable(t reflect.Type, schema Schema) Schema {
+	if dialect == dialectMongo && t.Kind() != reflect.Array {
+		return nullable(schema, dialect)
+	}
+
+	return schema
+}
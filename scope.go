@@ -0,0 +1,195 @@
+package mongorepo
+
+import (
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// leadingStages lists the aggregation stages MongoDB only accepts as the first stage of a pipeline.
+// Scope stages are injected right after them.
+var leadingStages = map[string]bool{
+	"$search":       true,
+	"$searchMeta":   true,
+	"$vectorSearch": true,
+	"$geoNear":      true,
+	"$collStats":    true,
+	"$indexStats":   true,
+}
+
+// fieldKey resolves the BSON key of a struct field of the repository entity `T`.
+//
+// Parameters:
+//   - field: The name of the struct field.
+//
+// Returns:
+//   - The BSON key of the field.
+func (r *Repository[T]) fieldKey(field string) string {
+	return bsonFieldName(reflect.TypeOf((*T)(nil)).Elem(), field)
+}
+
+// trashedScope selects the soft-deleted documents visible through a repository.
+type trashedScope int
+
+const (
+	trashedExcluded trashedScope = iota // Only documents not soft-deleted are visible, unless DisableSoftDeleteScope is set.
+	trashedIncluded                     // Every document is visible, see WithTrashed.
+	trashedOnly                         // Only soft-deleted documents are visible, see OnlyTrashed.
+)
+
+// WithTrashed returns a view of the repository whose reads include soft-deleted documents,
+// e.g. for admin tooling listing every document. The original repository is not modified.
+//
+// Returns:
+//   - A pointer to a Repository sharing the configuration of `r` without the soft-delete scope.
+func (r *Repository[T]) WithTrashed() *Repository[T] {
+	view := r.view()
+	view.trashed = trashedIncluded
+	return view
+}
+
+// OnlyTrashed returns a view of the repository whose reads only return soft-deleted documents,
+// e.g. to inspect or restore them. The original repository is not modified.
+//
+// Returns:
+//   - A pointer to a Repository sharing the configuration of `r`, scoped to the soft-deleted documents.
+//
+// Panics:
+//   - If DeletedAtField is not configured.
+func (r *Repository[T]) OnlyTrashed() *Repository[T] {
+	if r.config.DeletedAtField == "" {
+		panic("Configuration error: OnlyTrashed requires the DeletedAtField to be set.")
+	}
+
+	view := r.view()
+	view.trashed = trashedOnly
+	return view
+}
+
+// softDeleteScope builds the filter that excludes soft-deleted documents, or selects them for OnlyTrashed views.
+//
+// Returns:
+//   - The scope filter, or nil when DeletedAtField is not configured or scoping is disabled.
+func (r *Repository[T]) softDeleteScope() bson.M {
+	if r.config.DeletedAtField == "" {
+		return nil
+	}
+
+	switch {
+	case r.trashed == trashedOnly:
+		return bson.M{r.fieldKey(r.config.DeletedAtField): bson.M{"$exists": true}}
+	case r.trashed == trashedIncluded || r.config.DisableSoftDeleteScope:
+		return nil
+	}
+
+	return bson.M{r.fieldKey(r.config.DeletedAtField): bson.M{"$exists": false}}
+}
+
+// scopeFilter merges the repository scopes into a query filter: the soft-delete scope and the region of a regional
+// repository. Keys explicitly constrained by the caller are left untouched, so a query on the DeletedAt key wins over
+// the scope; the region cannot be overridden.
+//
+// Parameters:
+//   - query: A BSON map defining the search criteria.
+//
+// Returns:
+//   - A new filter containing the query and the scope conditions.
+func (r *Repository[T]) scopeFilter(query bson.M) bson.M {
+	scope := r.softDeleteScope()
+	if len(scope) == 0 {
+		return r.regionScope(query).(bson.M)
+	}
+
+	return r.regionScope(mergeScope(query, scope)).(bson.M)
+}
+
+// scopePipeline injects the repository scopes as a $match stage at the beginning of an aggregation pipeline.
+// The stage is placed after any stage that must come first ($search, $geoNear, ...) and is merged
+// into an existing leading $match so constructs like $text keep working.
+//
+// Parameters:
+//   - pipeline: The aggregation pipeline to scope.
+//
+// Returns:
+//   - A new pipeline with the scope applied.
+func (r *Repository[T]) scopePipeline(pipeline mongo.Pipeline) mongo.Pipeline {
+	scope := r.softDeleteScope()
+	if len(scope) == 0 && r.region == nil {
+		return pipeline
+	}
+
+	i := 0
+	for i < len(pipeline) && leadingStages[stageName(pipeline[i])] {
+		i++
+	}
+
+	scoped := make(mongo.Pipeline, 0, len(pipeline)+1)
+	scoped = append(scoped, pipeline[:i]...)
+
+	var match any
+	if i < len(pipeline) && stageName(pipeline[i]) == "$match" {
+		match = pipeline[i][0].Value
+		i++
+	}
+	if len(scope) > 0 {
+		match = mergeScope(match, scope)
+	}
+	scoped = append(scoped, bson.D{{Key: "$match", Value: r.regionScope(match)}})
+
+	return append(scoped, pipeline[i:]...)
+}
+
+// stageName returns the operator name of an aggregation stage, e.g. "$match".
+func stageName(stage bson.D) string {
+	if len(stage) == 0 {
+		return ""
+	}
+
+	return stage[0].Key
+}
+
+// mergeScope adds the scope conditions to a filter document without overriding keys already present.
+// Filters of unknown shape are combined with the scope using $and.
+func mergeScope(filter any, scope bson.M) any {
+	switch f := filter.(type) {
+	case nil:
+		merged := bson.M{}
+		for k, v := range scope {
+			merged[k] = v
+		}
+		return merged
+	case bson.M:
+		merged := make(bson.M, len(f)+len(scope))
+		for k, v := range f {
+			merged[k] = v
+		}
+		for k, v := range scope {
+			if _, ok := merged[k]; !ok {
+				merged[k] = v
+			}
+		}
+		return merged
+	case bson.D:
+		merged := append(bson.D{}, f...)
+		for k, v := range scope {
+			if !hasKey(f, k) {
+				merged = append(merged, bson.E{Key: k, Value: v})
+			}
+		}
+		return merged
+	default:
+		return bson.M{"$and": bson.A{filter, scope}}
+	}
+}
+
+// hasKey reports whether a bson.D contains the given top-level key.
+func hasKey(doc bson.D, key string) bool {
+	for _, e := range doc {
+		if e.Key == key {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,144 @@
+package mongorepo
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// scoreKey is the temporary field used to carry the relevance score of a search result.
+const scoreKey = "_mongorepo_score"
+
+// ScoredResult pairs an entity with the relevance score computed by the server.
+type ScoredResult[T any] struct {
+	Entity *T
+	Score  float64
+}
+
+// SearchOptions holds the optional settings of the search helpers.
+type SearchOptions struct {
+	MinScore float64 // Results scoring below MinScore are discarded, default: 0 (no threshold)
+	Limit    int64   // The maximum number of results, default: 0 (no limit)
+}
+
+// TextSearch runs a $text query, which requires a text index on the collection, and returns the
+// matching entities with their text score, best matches first.
+//
+// Parameters:
+//   - search: The $text search string.
+//   - query: A BSON map with additional search criteria, may be nil.
+//   - opts: Optional SearchOptions (score threshold and limit).
+//
+// Returns:
+//   - The matching entities with their score.
+//   - An error if the operation fails.
+func (r *Repository[T]) TextSearch(search string, query bson.M, opts ...*SearchOptions) ([]ScoredResult[T], error) {
+	match := bson.M{}
+	for k, v := range query {
+		match[k] = v
+	}
+	match["$text"] = bson.M{"$search": search}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$addFields", Value: bson.M{scoreKey: bson.M{"$meta": "textScore"}}}},
+		{{Key: "$sort", Value: bson.M{scoreKey: -1}}},
+	}
+
+	return r.scoredSearch(pipeline, mergeSearchOptions(opts))
+}
+
+// Search runs an Atlas Search $search stage and returns the matching entities with their search score,
+// in the order produced by Atlas Search.
+//
+// Parameters:
+//   - search: The $search stage specification, e.g. bson.M{"index": "default", "text": bson.M{...}}.
+//   - opts: Optional SearchOptions (score threshold and limit).
+//
+// Returns:
+//   - The matching entities with their score.
+//   - ErrUnsupported if the server does not provide Atlas Search.
+//   - An error if the operation fails.
+func (r *Repository[T]) Search(search bson.M, opts ...*SearchOptions) ([]ScoredResult[T], error) {
+	if err := r.requireCapability("$search", func(c *Capabilities) bool { return c.AtlasSearch }); err != nil {
+		return nil, err
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$search", Value: search}},
+		{{Key: "$addFields", Value: bson.M{scoreKey: bson.M{"$meta": "searchScore"}}}},
+	}
+
+	return r.scoredSearch(pipeline, mergeSearchOptions(opts))
+}
+
+// VectorSearch runs an Atlas $vectorSearch stage and returns the nearest entities with their similarity score.
+//
+// Parameters:
+//   - search: The $vectorSearch stage specification (index, path, queryVector, numCandidates, limit, filter).
+//   - opts: Optional SearchOptions (score threshold and limit).
+//
+// Returns:
+//   - The nearest entities with their score.
+//   - ErrUnsupported if the server does not provide Atlas Vector Search.
+//   - An error if the operation fails.
+func (r *Repository[T]) VectorSearch(search bson.M, opts ...*SearchOptions) ([]ScoredResult[T], error) {
+	if err := r.requireCapability("$vectorSearch", func(c *Capabilities) bool { return c.VectorSearch }); err != nil {
+		return nil, err
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$vectorSearch", Value: search}},
+		{{Key: "$addFields", Value: bson.M{scoreKey: bson.M{"$meta": "vectorSearchScore"}}}},
+	}
+
+	return r.scoredSearch(pipeline, mergeSearchOptions(opts))
+}
+
+// scoredSearch appends the threshold and limit stages to a search pipeline, runs it and decodes the scored results,
+// hydrated and redacted like the entities of Find.
+func (r *Repository[T]) scoredSearch(pipeline mongo.Pipeline, opts SearchOptions) ([]ScoredResult[T], error) {
+	if opts.MinScore > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{scoreKey: bson.M{"$gte": opts.MinScore}}}})
+	}
+
+	if opts.Limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: opts.Limit}})
+	}
+
+	cursor, err := r.Aggregate(&pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(r.config.Context)
+
+	var results []ScoredResult[T]
+	for cursor.Next(r.config.Context) {
+		entity, err := r.decodeEntity(cursor.Current, scoreKey)
+		if err != nil {
+			return nil, err
+		}
+
+		score, _ := cursor.Current.Lookup(scoreKey).DoubleOK()
+		results = append(results, ScoredResult[T]{Entity: entity, Score: score})
+	}
+
+	return results, cursor.Err()
+}
+
+// mergeSearchOptions combines optional SearchOptions, later values overriding earlier ones.
+func mergeSearchOptions(opts []*SearchOptions) SearchOptions {
+	var merged SearchOptions
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if opt.MinScore != 0 {
+			merged.MinScore = opt.MinScore
+		}
+		if opt.Limit != 0 {
+			merged.Limit = opt.Limit
+		}
+	}
+
+	return merged
+}
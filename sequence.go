@@ -0,0 +1,67 @@
+package mongorepo
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// counter is a document of the counters collection.
+type counter struct {
+	Name string `bson:"_id"`
+	Seq  int64  `bson:"seq"`
+}
+
+// NextSequence atomically increments and returns the named counter stored in the counters collection
+// (Config.CountersCollection) of the repository database. Counters start at 1 and are created on first use.
+//
+// Parameters:
+//   - name: The name of the sequence, e.g. "order_number".
+//
+// Returns:
+//   - The next value of the sequence.
+//   - An error if the operation fails.
+func (r *Repository[T]) NextSequence(name string) (int64, error) {
+	return r.reserveSequence(name, 1)
+}
+
+// reserveSequence atomically reserves the next n values of the named counter, returning the last one.
+func (r *Repository[T]) reserveSequence(name string, n int64) (int64, error) {
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var c counter
+	err := r.Database().Collection(r.config.CountersCollection).
+		FindOneAndUpdate(r.config.Context, bson.M{"_id": name}, bson.M{"$inc": bson.M{"seq": n}}, opts).
+		Decode(&c)
+	if err != nil {
+		return 0, err
+	}
+
+	return c.Seq, nil
+}
+
+// assignIDs sets new IDs to entities about to be inserted: with an IDSequence, the next values of the sequence,
+// reserved with a single $inc, are set to their integer IdField; otherwise SetNewID applies.
+func (r *Repository[T]) assignIDs(ers ...*EntityReflection) error {
+	if r.config.IDSequence == "" {
+		for _, er := range ers {
+			er.SetNewID()
+		}
+		return nil
+	}
+
+	if len(ers) == 0 {
+		return nil
+	}
+
+	last, err := r.reserveSequence(r.config.IDSequence, int64(len(ers)))
+	if err != nil {
+		return err
+	}
+
+	first := last - int64(len(ers)) + 1
+	for i, er := range ers {
+		er.intField(er.config.IdField).SetInt(first + int64(i))
+	}
+
+	return nil
+}
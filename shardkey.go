@@ -0,0 +1,15 @@
+package mongorepo
+
+import "go.mongodb.org/mongo-driver/bson"
+
+// entityFilter selects the document of an entity by its ID and, with ShardKeyFields, by the shard key values of
+// the entity too, so that on a sharded cluster the operation is routed to the shard holding the document instead
+// of being broadcast to every shard. The filter of a regional repository is restricted to its region.
+func (r *Repository[T]) entityFilter(er *EntityReflection) bson.M {
+	filter := bson.M{"_id": er.GetID()}
+	for _, field := range r.config.ShardKeyFields {
+		filter[r.fieldKey(field)] = er.GetField(field)
+	}
+
+	return r.regionScope(filter).(bson.M)
+}
@@ -0,0 +1,304 @@
+package mongorepo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxBSONDocumentSize is the maximum size of a document accepted by the server: 16MB.
+const maxBSONDocumentSize = 16 * 1024 * 1024
+
+// offloadRefKey is the key of the reference left in place of a field offloaded to GridFS.
+const offloadRefKey = "_mongorepo_gridfs"
+
+// OversizeStrategy defines what the write operations do with documents larger than Config.MaxDocumentSize.
+type OversizeStrategy int
+
+const (
+	OversizeIgnore  OversizeStrategy = iota // Documents are sent as is, the server rejects oversized ones.
+	OversizeReject                          // Oversized documents are rejected with a *DocumentTooLargeError before reaching the server.
+	OversizeOffload                         // The OffloadFields of oversized documents are moved to GridFS, in order, until the document fits.
+)
+
+// ErrDocumentTooLarge is matched by errors.Is on a *DocumentTooLargeError.
+var ErrDocumentTooLarge = errors.New("mongorepo: document too large")
+
+// DocumentTooLargeError reports a document exceeding the configured size limit.
+type DocumentTooLargeError struct {
+	Size  int // The encoded size of the document, after offloading.
+	Limit int // The configured limit.
+}
+
+// Error implements the error interface.
+func (e *DocumentTooLargeError) Error() string {
+	return fmt.Sprintf("mongorepo: document of %d bytes exceeds the limit of %d bytes", e.Size, e.Limit)
+}
+
+// Unwrap makes errors.Is(err, ErrDocumentTooLarge) match.
+func (e *DocumentTooLargeError) Unwrap() error {
+	return ErrDocumentTooLarge
+}
+
+// guardSize checks the encoded size of an entity about to be written, according to the OversizeStrategy,
+// after reporting its deprecated fields still populated. It returns the document to write: the entity itself,
+// or its encoded form with offloaded fields replaced by GridFS references.
+func (r *Repository[T]) guardSize(entity *T) (any, error) {
+	r.warnDeprecatedWrites(entity)
+
+	if r.config.OversizeStrategy == OversizeIgnore {
+		return entity, nil
+	}
+
+	raw, err := bson.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := r.config.MaxDocumentSize
+	if len(raw) <= limit {
+		return entity, nil
+	}
+
+	if r.config.OversizeStrategy != OversizeOffload || len(r.config.OffloadFields) == 0 {
+		return nil, &DocumentTooLargeError{Size: len(raw), Limit: limit}
+	}
+
+	doc, err := rawElements(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	size := len(raw)
+	for _, field := range r.config.OffloadFields {
+		key := r.fieldKey(field)
+		for i := range doc {
+			value, ok := doc[i].Value.(bson.RawValue)
+			if doc[i].Key != key || !ok || value.Type == bson.TypeNull {
+				continue
+			}
+
+			fileID, err := r.offload(value)
+			if err != nil {
+				return nil, err
+			}
+			reference := bson.D{{Key: offloadRefKey, Value: fileID}}
+			doc[i].Value = reference
+
+			encoded, _ := bson.Marshal(reference)
+			size -= len(value.Value) - len(encoded)
+		}
+
+		if size <= limit {
+			return doc, nil
+		}
+	}
+
+	return nil, &DocumentTooLargeError{Size: size, Limit: limit}
+}
+
+// offload stores a field value in the GridFS bucket and returns the id of the file.
+func (r *Repository[T]) offload(value bson.RawValue) (primitive.ObjectID, error) {
+	bucket, err := r.gridFS()
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	data, err := bson.Marshal(bson.D{{Key: "v", Value: value}})
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return bucket.UploadFromStream(r.config.CollectionName, bytes.NewReader(data))
+}
+
+// hydrate decodes a document into an entity, loading the offloaded fields back from GridFS, after checking
+// its region and counting the deprecated fields it holds.
+func (r *Repository[T]) hydrate(raw bson.Raw, entity *T) error {
+	if err := r.guardDocumentRegion(raw); err != nil {
+		return err
+	}
+
+	r.countDeprecatedReads(raw)
+
+	if r.config.OversizeStrategy != OversizeOffload || len(offloadedFiles(raw)) == 0 {
+		if err := r.checkUnknownFields(raw); err != nil {
+			return err
+		}
+		return bson.Unmarshal(raw, entity)
+	}
+
+	bucket, err := r.gridFS()
+	if err != nil {
+		return err
+	}
+
+	doc, err := rawElements(raw)
+	if err != nil {
+		return err
+	}
+
+	for i := range doc {
+		fileID, ok := offloadRef(doc[i].Value.(bson.RawValue))
+		if !ok {
+			continue
+		}
+
+		var data bytes.Buffer
+		if _, err := bucket.DownloadToStream(fileID, &data); err != nil {
+			return fmt.Errorf("loading the offloaded field %q: %w", doc[i].Key, err)
+		}
+		doc[i].Value = bson.Raw(data.Bytes()).Lookup("v")
+	}
+
+	hydrated, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	if err := r.checkUnknownFields(hydrated); err != nil {
+		return err
+	}
+
+	return bson.Unmarshal(hydrated, entity)
+}
+
+// releaseOffloaded deletes the GridFS files referenced by a document that are not kept by its new version.
+func (r *Repository[T]) releaseOffloaded(previous bson.Raw, kept any) error {
+	files := offloadedFiles(previous)
+	if len(files) == 0 {
+		return nil
+	}
+
+	keep := map[primitive.ObjectID]bool{}
+	if raw, ok := kept.(bson.D); ok {
+		for _, e := range raw {
+			if reference, ok := e.Value.(bson.D); ok && len(reference) == 1 && reference[0].Key == offloadRefKey {
+				keep[reference[0].Value.(primitive.ObjectID)] = true
+			}
+		}
+	}
+
+	bucket, err := r.gridFS()
+	if err != nil {
+		return err
+	}
+
+	for _, fileID := range files {
+		if keep[fileID] {
+			continue
+		}
+		if err := bucket.DeleteContext(r.config.Context, fileID); err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// offloadedDocument loads the current version of a document when it may reference offloaded fields.
+func (r *Repository[T]) offloadedDocument(id any) (bson.Raw, error) {
+	if r.config.OversizeStrategy != OversizeOffload || len(r.config.OffloadFields) == 0 {
+		return nil, nil
+	}
+
+	projection := bson.M{}
+	for _, field := range r.config.OffloadFields {
+		projection[r.fieldKey(field)] = 1
+	}
+
+	raw, err := r.Collection().FindOne(r.config.Context, bson.M{"_id": id}, options.FindOne().SetProjection(projection)).Raw()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+
+	return raw, err
+}
+
+// offloadedDocuments loads the documents matching a filter that reference offloaded fields.
+func (r *Repository[T]) offloadedDocuments(filter bson.M) ([]bson.Raw, error) {
+	if r.config.OversizeStrategy != OversizeOffload || len(r.config.OffloadFields) == 0 {
+		return nil, nil
+	}
+
+	ctx := r.config.Context
+	references := bson.A{}
+	projection := bson.M{}
+	for _, field := range r.config.OffloadFields {
+		key := r.fieldKey(field)
+		projection[key] = 1
+		references = append(references, bson.M{key + "." + offloadRefKey: bson.M{"$exists": true}})
+	}
+
+	query := bson.M{"$and": bson.A{filter, bson.M{"$or": references}}}
+	cursor, err := r.Collection().Find(ctx, query, options.Find().SetProjection(projection))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var documents []bson.Raw
+	for cursor.Next(ctx) {
+		documents = append(documents, append(bson.Raw(nil), cursor.Current...))
+	}
+
+	return documents, cursor.Err()
+}
+
+// gridFS returns the GridFS bucket storing the offloaded fields.
+func (r *Repository[T]) gridFS() (*gridfs.Bucket, error) {
+	return gridfs.NewBucket(r.Database(), options.GridFSBucket().SetName(r.config.GridFSBucket))
+}
+
+// offloadedFiles returns the ids of the GridFS files referenced by the top-level fields of a document.
+func offloadedFiles(raw bson.Raw) []primitive.ObjectID {
+	elements, err := raw.Elements()
+	if err != nil {
+		return nil
+	}
+
+	var files []primitive.ObjectID
+	for _, element := range elements {
+		if fileID, ok := offloadRef(element.Value()); ok {
+			files = append(files, fileID)
+		}
+	}
+
+	return files
+}
+
+// offloadRef returns the file id of a value holding a GridFS reference.
+func offloadRef(value bson.RawValue) (primitive.ObjectID, bool) {
+	doc, ok := value.DocumentOK()
+	if !ok {
+		return primitive.NilObjectID, false
+	}
+
+	elements, err := doc.Elements()
+	if err != nil || len(elements) != 1 || elements[0].Key() != offloadRefKey {
+		return primitive.NilObjectID, false
+	}
+
+	return elements[0].Value().ObjectIDOK()
+}
+
+// rawElements splits a document into its top-level elements, keeping the values encoded.
+func rawElements(raw bson.Raw) (bson.D, error) {
+	elements, err := raw.Elements()
+	if err != nil {
+		return nil, err
+	}
+
+	doc := make(bson.D, 0, len(elements))
+	for _, element := range elements {
+		doc = append(doc, bson.E{Key: element.Key(), Value: element.Value()})
+	}
+
+	return doc, nil
+}
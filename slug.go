@@ -0,0 +1,133 @@
+package mongorepo
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/text/unicode/norm"
+)
+
+// slugMaxAttempts is the number of times EnsureUniqueSlug retries after losing a duplicate-key race.
+const slugMaxAttempts = 5
+
+// ErrEmptySlug is returned by EnsureUniqueSlug when the source field yields an empty slug.
+var ErrEmptySlug = errors.New("mongorepo: the slug source is empty")
+
+// Slugify converts a text into a URL friendly slug: lowercase ASCII letters and digits separated by dashes,
+// e.g. "Elías Noya!" becomes "elias-noya".
+//
+// Parameters:
+//   - text: The text to convert.
+//
+// Returns:
+//   - The slug, which may be empty when the text has no letters or digits.
+func Slugify(text string) string {
+	var b strings.Builder
+	dash := false
+
+	for _, r := range norm.NFD.String(strings.ToLower(text)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// drop the accents left apart by the NFD decomposition
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		default:
+			dash = true
+		}
+	}
+
+	return b.String()
+}
+
+// EnsureUniqueSlug generates a slug from sourceField, stores it in slugField and persists the entity:
+// new entities (zero ID) are created, existing ones updated. When the slug is taken, a numeric suffix is
+// appended ("my-title-2", "my-title-3", ...). The slug field must be backed by a unique index, so concurrent
+// writers picking the same slug are detected through the duplicate-key error and the slug is recomputed.
+// Duplicate-key errors of other unique indexes are returned unchanged.
+//
+// Parameters:
+//   - entity: A pointer to the entity of type `T`.
+//   - sourceField: The field in the entity struct the slug is generated from; must be a string.
+//   - slugField: The field in the entity struct storing the slug; must be a string.
+//
+// Returns:
+//   - ErrEmptySlug if the source field does not produce a slug.
+//   - A *DuplicateKeyError (ErrDuplicateKey) if another unique index rejects the entity.
+//   - An error if the entity cannot be persisted.
+func (r *Repository[T]) EnsureUniqueSlug(entity *T, sourceField, slugField string) error {
+	er := NewEntityReflection(r.config, entity)
+
+	base := Slugify(er.GetField(sourceField).(string))
+	if base == "" {
+		return ErrEmptySlug
+	}
+
+	// decided once: a failed Create assigns the ID, so the retries of a new entity must create it again
+	isNew := !er.HasID()
+	slugKey := r.fieldKey(slugField)
+	var err error
+	for attempt := 0; attempt < slugMaxAttempts; attempt++ {
+		var slug string
+		if slug, err = r.freeSlug(base, slugKey, er.GetID()); err != nil {
+			return err
+		}
+		er.SetField(slugField, slug)
+
+		if isNew {
+			err = r.Create(entity)
+		} else {
+			err = r.Update(entity)
+		}
+
+		if !isKeyConflict(err, slugKey) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// freeSlug finds the first free slug for a base, looking at every stored document including soft-deleted ones
+// since they still hold the unique index entries. The document with the given id is ignored.
+func (r *Repository[T]) freeSlug(base, slugKey string, id any) (string, error) {
+	query := bson.M{
+		slugKey: primitive.Regex{Pattern: "^" + regexp.QuoteMeta(base) + `(-\d+)?$`},
+		"_id":   bson.M{"$ne": id},
+	}
+
+	cursor, err := r.Collection().Find(r.config.Context, query, options.Find().SetProjection(bson.M{slugKey: 1}))
+	if err != nil {
+		return "", err
+	}
+
+	var taken []bson.M
+	if err := cursor.All(r.config.Context, &taken); err != nil {
+		return "", err
+	}
+
+	highest, baseTaken := 1, false
+	for _, doc := range taken {
+		slug, _ := doc[slugKey].(string)
+		if slug == base {
+			baseTaken = true
+		} else if suffix, err := strconv.Atoi(strings.TrimPrefix(slug, base+"-")); err == nil && suffix > highest {
+			highest = suffix
+		}
+	}
+
+	if !baseTaken {
+		return base, nil
+	}
+
+	return base + "-" + strconv.Itoa(highest+1), nil
+}
@@ -0,0 +1,75 @@
+package mongorepo
+
+import (
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Elías Noya!":         "elias-noya",
+		"Hello World":         "hello-world",
+		"  padded   spaces  ": "padded-spaces",
+		"--a--b--":            "a-b",
+		"Crème Brûlée":        "creme-brulee",
+		"ÜBER straße":         "uber-stra-e",
+		"Go 1.22 released":    "go-1-22-released",
+		"snake_case_name":     "snake-case-name",
+		"日本語":                 "",
+		"!!!":                 "",
+		"":                    "",
+	}
+
+	for text, expected := range tests {
+		if got := Slugify(text); got != expected {
+			t.Errorf("Slugify(%q) = %q, expected %q", text, got, expected)
+		}
+	}
+}
+
+type sluggedPost struct {
+	ID    primitive.ObjectID `bson:"_id"`
+	Title string             `bson:"title"`
+	Slug  string             `bson:"slug"`
+}
+
+func TestEnsureUniqueSlugRetriesTheCreate(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("new entity", func(mt *mtest.T) {
+		repo := New[sluggedPost](&Config{MongoClient: mt.Client, DbName: "blog"})
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "blog.slugged_posts", mtest.FirstBatch),
+			mtest.CreateWriteErrorsResponse(mtest.WriteError{Code: 11000, Message: `E11000 duplicate key error collection: blog.slugged_posts index: slug_1 dup key: { slug: "hello-world" }`}),
+			mtest.CreateCursorResponse(0, "blog.slugged_posts", mtest.FirstBatch, bson.D{{Key: "slug", Value: "hello-world"}}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+		)
+
+		post := &sluggedPost{Title: "Hello World"}
+		if err := repo.EnsureUniqueSlug(post, "Title", "Slug"); err != nil {
+			mt.Fatal(err)
+		}
+		if post.Slug != "hello-world-2" {
+			mt.Errorf("got the slug %q, expected hello-world-2", post.Slug)
+		}
+
+		var commands []string
+		var inserted bson.Raw
+		for _, event := range mt.GetAllStartedEvents() {
+			commands = append(commands, event.CommandName)
+			if event.CommandName == "insert" {
+				inserted = event.Command.Lookup("documents", "0").Document()
+			}
+		}
+		if strings.Join(commands, ",") != "find,insert,find,insert" {
+			mt.Fatalf("got the commands %v, expected the retry to insert the post again", commands)
+		}
+		if id, _ := inserted.Lookup("_id").ObjectIDOK(); id != post.ID || inserted.Lookup("slug").StringValue() != "hello-world-2" {
+			mt.Errorf("the retry inserted %v, expected the post %v with the slug hello-world-2", inserted, post.ID)
+		}
+	})
+}
@@ -0,0 +1,151 @@
+package mongorepo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var (
+	// ErrInvalidTransition is returned by TransitionTo when the transition is not allowed.
+	ErrInvalidTransition = errors.New("mongorepo: state transition not allowed")
+
+	// ErrTransitionConflict is returned by TransitionTo when the stored state is no longer the state of the entity.
+	ErrTransitionConflict error = &kindError{kind: ErrStale, err: errors.New("mongorepo: state changed concurrently")}
+)
+
+// StateMachineConfig holds the configuration of a StateMachine.
+type StateMachineConfig[T any] struct {
+	StateField      string                   // The field in the entity struct holding the state; must be a string or a custom string type.
+	Transitions     map[string][]string      // The allowed transitions: current state -> states it can move to.
+	TimestampFields map[string]string        // Optional fields of type time.Time stamped when entering a state: state -> field.
+	OnTransition    func(TransitionEvent[T]) // Optional callback invoked after every successful transition.
+}
+
+// TransitionEvent describes a state transition performed by a StateMachine.
+type TransitionEvent[T any] struct {
+	Entity *T
+	From   string
+	To     string
+	At     time.Time
+}
+
+// StateMachine guards the transitions of a designated status field of an entity.
+type StateMachine[T any] struct {
+	repo   *Repository[T]
+	config StateMachineConfig[T]
+}
+
+// NewStateMachine creates a StateMachine on top of a repository.
+//
+// Parameters:
+//   - repo: The repository holding the entities.
+//   - config: The state machine configuration.
+//
+// Returns:
+//   - A pointer to a StateMachine.
+//
+// Panics:
+//   - If config.StateField is not set or is not a string field of `T`.
+func NewStateMachine[T any](repo *Repository[T], config StateMachineConfig[T]) *StateMachine[T] {
+	if config.StateField == "" {
+		panic("Configuration error: The StateMachineConfig.StateField is not set.")
+	}
+
+	field, ok := reflect.TypeOf((*T)(nil)).Elem().FieldByName(config.StateField)
+	if !ok || field.Type.Kind() != reflect.String {
+		panic(fmt.Sprintf("Configuration error: The StateMachineConfig.StateField %q must be a string field of the entity.", config.StateField))
+	}
+
+	return &StateMachine[T]{repo: repo, config: config}
+}
+
+// CanTransition reports whether the configuration allows moving from one state to another.
+//
+// Parameters:
+//   - from: The current state.
+//   - to: The target state.
+//
+// Returns:
+//   - true if the transition is allowed.
+func (sm *StateMachine[T]) CanTransition(from, to string) bool {
+	for _, allowed := range sm.config.Transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TransitionTo moves the entity to a new state. The update only applies if the stored state still equals
+// the state of the entity (compare-and-swap), so concurrent transitions cannot both succeed.
+// On success the state, the state timestamp, UpdatedAt and the incremented VersionField (if configured)
+// are set on the entity and the OnTransition callback is invoked.
+//
+// Parameters:
+//   - entity: A pointer to the entity of type `T`.
+//   - newState: The target state.
+//
+// Returns:
+//   - ErrInvalidTransition if the transition is not allowed.
+//   - ErrTransitionConflict if the stored state changed in the meantime.
+//   - An error if the update fails.
+func (sm *StateMachine[T]) TransitionTo(entity *T, newState string) error {
+	er := NewEntityReflection(sm.repo.config, entity)
+	from := er.GetString(sm.config.StateField)
+
+	if !sm.CanTransition(from, newState) {
+		return fmt.Errorf("%w: %q -> %q", ErrInvalidTransition, from, newState)
+	}
+
+	now := time.Now()
+	stateKey := sm.repo.fieldKey(sm.config.StateField)
+	set := bson.M{stateKey: newState}
+
+	timestampField := sm.config.TimestampFields[newState]
+	if timestampField != "" {
+		set[sm.repo.fieldKey(timestampField)] = now
+	}
+	if sm.repo.config.UpdatedAtField != "" {
+		set[sm.repo.fieldKey(sm.repo.config.UpdatedAtField)] = now
+	}
+
+	if err := sm.repo.guardRegion(entity); err != nil {
+		return err
+	}
+
+	filter := sm.repo.entityFilter(er)
+	filter[stateKey] = from
+	start := time.Now()
+	result, err := sm.repo.Collection().UpdateOne(sm.repo.config.Context, filter, sm.repo.stampUpdate(bson.M{"$set": set}), sm.repo.updateComment("TransitionTo"))
+	sm.repo.observe("TransitionTo", filter, start, 0, err)
+	if err != nil {
+		return classify(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: expected state %q", ErrTransitionConflict, from)
+	}
+
+	if sm.repo.config.VersionField != "" {
+		er.SetVersion(er.GetVersion() + 1)
+	}
+
+	er.SetString(sm.config.StateField, newState)
+	if timestampField != "" {
+		er.SetField(timestampField, now)
+	}
+	if sm.repo.config.UpdatedAtField != "" {
+		er.SetField(sm.repo.config.UpdatedAtField, now)
+	}
+
+	if sm.config.OnTransition != nil {
+		sm.config.OnTransition(TransitionEvent[T]{Entity: entity, From: from, To: newState, At: now})
+	}
+
+	return nil
+}
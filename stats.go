@@ -0,0 +1,314 @@
+package mongorepo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// StatsConfig holds the configuration of a StatsMaintainer.
+type StatsConfig struct {
+	KeyField   string   // The BSON key (dotted paths allowed) grouping the documents, e.g. "customer_id".
+	SumFields  []string // The BSON keys (dotted paths allowed) of the numeric values summed per group, default: nil (counts only)
+	Collection string   // The collection storing the aggregates, default: <CollectionName>_stats
+}
+
+// Stats holds the pre-computed aggregates of a group of documents.
+type Stats struct {
+	Key       any                `bson:"_id"`        // The value of the KeyField of the documents of the group.
+	Count     int64              `bson:"count"`      // The number of documents of the group.
+	Sums      map[string]float64 `bson:"sums"`       // The sums of the SumFields, keyed by field with dots replaced by underscores.
+	UpdatedAt time.Time          `bson:"updated_at"` // When the aggregates were last changed.
+}
+
+// StatsMaintainer keeps per-key aggregates (counts and sums) of a repository in a stats collection, updated
+// incrementally from write hooks (OnCreate, OnUpdate, OnDelete) or from a change stream (Run), so dashboards
+// read pre-computed values instead of aggregating the source collection on every view. Rebuild recomputes
+// every aggregate from the source collection, e.g. after the maintainer was stopped. Soft-deleted documents
+// are not counted.
+type StatsMaintainer[T any] struct {
+	repo   *Repository[T]
+	config StatsConfig
+}
+
+// statsDelta is the contribution of a document to the aggregates of its group.
+type statsDelta struct {
+	key   bson.RawValue
+	count int64
+	sums  map[string]float64
+}
+
+// NewStatsMaintainer creates a StatsMaintainer on top of a repository.
+//
+// Parameters:
+//   - repo: The repository of the source documents.
+//   - config: The stats configuration.
+//
+// Returns:
+//   - A pointer to a StatsMaintainer.
+//
+// Panics:
+//   - If config.KeyField is not set.
+func NewStatsMaintainer[T any](repo *Repository[T], config StatsConfig) *StatsMaintainer[T] {
+	if config.KeyField == "" {
+		panic("Configuration error: The StatsConfig.KeyField is not set.")
+	}
+
+	if config.Collection == "" {
+		config.Collection = repo.config.CollectionName + "_stats"
+	}
+
+	return &StatsMaintainer[T]{repo: repo, config: config}
+}
+
+// OnCreate adds a created entity to the aggregates of its group.
+//
+// Parameters:
+//   - entity: The created entity.
+//
+// Returns:
+//   - An error if the encoding of the entity or the update fails.
+func (s *StatsMaintainer[T]) OnCreate(entity *T) error {
+	return s.OnUpdate(nil, entity)
+}
+
+// OnUpdate moves an updated entity across the aggregates: the contribution of its previous state is removed
+// and the one of its new state is added, which also handles a change of group and soft deletes.
+//
+// Parameters:
+//   - before: The entity before the update, nil for a creation.
+//   - after: The entity after the update, nil for a deletion.
+//
+// Returns:
+//   - An error if the encoding of an entity or an update fails.
+func (s *StatsMaintainer[T]) OnUpdate(before, after *T) error {
+	previous, err := s.delta(before, true)
+	if err != nil {
+		return err
+	}
+
+	next, err := s.delta(after, true)
+	if err != nil {
+		return err
+	}
+
+	if previous != nil && next != nil && rawValueKey(previous.key) == rawValueKey(next.key) {
+		for field, sum := range previous.sums {
+			next.sums[field] -= sum
+		}
+		next.count -= previous.count
+		return s.apply(next, 1)
+	}
+
+	if err := s.apply(previous, -1); err != nil {
+		return err
+	}
+
+	return s.apply(next, 1)
+}
+
+// OnDelete removes a deleted entity from the aggregates of its group, whether it was hard or soft-deleted.
+//
+// Parameters:
+//   - entity: The deleted entity.
+//
+// Returns:
+//   - An error if the encoding of the entity or the update fails.
+func (s *StatsMaintainer[T]) OnDelete(entity *T) error {
+	previous, err := s.delta(entity, false)
+	if err != nil {
+		return err
+	}
+
+	return s.apply(previous, -1)
+}
+
+// Run applies the change stream events of the source collection to the aggregates until ctx is done.
+// Updates and deletions need the pre-images of the documents, which must be enabled on the collection
+// with changeStreamPreAndPostImages; events without pre-image are skipped, so run Rebuild after enabling them.
+//
+// Parameters:
+//   - ctx: The context controlling the lifetime of the change stream.
+//
+// Returns:
+//   - ctx.Err() once ctx is done.
+//   - An error if the change stream fails or an event cannot be applied.
+func (s *StatsMaintainer[T]) Run(ctx context.Context) error {
+	opts := options.ChangeStream().
+		SetFullDocument(options.UpdateLookup).
+		SetFullDocumentBeforeChange(options.WhenAvailable)
+
+	stream, err := s.repo.WithContext(ctx).Watch(nil, opts)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	for stream.Next() {
+		event := stream.Current()
+
+		switch event.OperationType {
+		case "insert":
+			err = s.OnCreate(event.FullDocument)
+		case "update", "replace", "delete":
+			if event.FullDocumentBeforeChange != nil {
+				err = s.OnUpdate(event.FullDocumentBeforeChange, event.FullDocument)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := stream.Err(); err != nil {
+		return err
+	}
+
+	return ctx.Err()
+}
+
+// Rebuild recomputes every aggregate from the source collection and replaces the stats collection with them.
+//
+// Returns:
+//   - An error if the aggregation fails.
+func (s *StatsMaintainer[T]) Rebuild() error {
+	group := bson.D{{Key: "_id", Value: "$" + s.config.KeyField}, {Key: "count", Value: bson.M{"$sum": 1}}}
+	var sums any = bson.M{"$literal": bson.M{}}
+	fields := bson.D{}
+	for _, field := range s.config.SumFields {
+		name := statsSumName(field)
+		group = append(group, bson.E{Key: name, Value: bson.M{"$sum": "$" + field}})
+		fields = append(fields, bson.E{Key: name, Value: "$" + name})
+	}
+	if len(fields) > 0 {
+		sums = fields
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: group}},
+		{{Key: "$project", Value: bson.M{"count": 1, "sums": sums, "updated_at": "$$NOW"}}},
+		{{Key: "$out", Value: s.config.Collection}},
+	}
+
+	cursor, err := s.repo.Aggregate(&pipeline)
+	if err != nil {
+		return err
+	}
+
+	return cursor.Close(s.repo.config.Context)
+}
+
+// Get returns the aggregates of a group.
+//
+// Parameters:
+//   - key: The value of the KeyField of the group.
+//
+// Returns:
+//   - The aggregates of the group, with zero counts if no document belongs to it.
+//   - An error if the read fails.
+func (s *StatsMaintainer[T]) Get(key any) (*Stats, error) {
+	stats := &Stats{Key: key, Sums: map[string]float64{}}
+
+	err := s.collection().FindOne(s.repo.config.Context, bson.M{"_id": key}).Decode(stats)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return stats, nil
+	}
+
+	return stats, err
+}
+
+// All returns the aggregates of every group.
+//
+// Returns:
+//   - The aggregates of the groups.
+//   - An error if the read fails.
+func (s *StatsMaintainer[T]) All() ([]Stats, error) {
+	ctx := s.repo.config.Context
+
+	cursor, err := s.collection().Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	var stats []Stats
+	err = cursor.All(ctx, &stats)
+	return stats, err
+}
+
+// delta computes the contribution of an entity to its group, nil for a nil entity. When live is set,
+// soft-deleted entities contribute nothing.
+func (s *StatsMaintainer[T]) delta(entity *T, live bool) (*statsDelta, error) {
+	if entity == nil {
+		return nil, nil
+	}
+
+	raw, err := bson.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+	doc := bson.Raw(raw)
+
+	config := s.repo.config
+	if live && config.DeletedAtField != "" && !config.DisableSoftDeleteScope {
+		if deleted := doc.Lookup(s.repo.fieldKey(config.DeletedAtField)); deleted.Type != 0 {
+			return nil, nil
+		}
+	}
+
+	key := doc.Lookup(strings.Split(s.config.KeyField, ".")...)
+	if key.Type == 0 {
+		key = bson.RawValue{Type: bsontype.Null}
+	}
+
+	delta := &statsDelta{key: key, count: 1, sums: map[string]float64{}}
+	for _, field := range s.config.SumFields {
+		delta.sums[statsSumName(field)] = numericValue(doc.Lookup(strings.Split(field, ".")...))
+	}
+
+	return delta, nil
+}
+
+// apply adds (sign 1) or subtracts (sign -1) a contribution to the aggregates of its group.
+func (s *StatsMaintainer[T]) apply(delta *statsDelta, sign int64) error {
+	if delta == nil {
+		return nil
+	}
+
+	inc := bson.M{"count": sign * delta.count}
+	for name, sum := range delta.sums {
+		inc["sums."+name] = float64(sign) * sum
+	}
+
+	update := bson.M{"$inc": inc, "$set": bson.M{"updated_at": time.Now()}}
+	_, err := s.collection().UpdateOne(s.repo.config.Context, bson.M{"_id": delta.key}, update, options.Update().SetUpsert(true))
+	return err
+}
+
+// collection returns the stats collection.
+func (s *StatsMaintainer[T]) collection() *mongo.Collection {
+	return s.repo.Database().Collection(s.config.Collection)
+}
+
+// statsSumName returns the key of a sum in the stats documents.
+func statsSumName(field string) string {
+	return strings.ReplaceAll(field, ".", "_")
+}
+
+// numericValue returns the value of a numeric BSON value as a float64, 0 for other types.
+func numericValue(value bson.RawValue) float64 {
+	if f, ok := value.DoubleOK(); ok {
+		return f
+	}
+
+	if i, ok := value.AsInt64OK(); ok {
+		return float64(i)
+	}
+
+	return 0
+}
@@ -0,0 +1,231 @@
+package mongorepo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Cursor is the iteration contract shared by *mongo.Cursor and *mongo.ChangeStream.
+type Cursor interface {
+	Next(ctx context.Context) bool
+	Decode(val interface{}) error
+	Err() error
+	Close(ctx context.Context) error
+}
+
+// Stream is a typed, lazily decoded sequence of results, returned by FindStream, AggregateStream and Watch.
+// It is consumed once, like the cursor it wraps:
+//
+//	for stream.Next() {
+//		item := stream.Current()
+//	}
+//	if err := stream.Err(); err != nil { ... }
+//
+// Streams are not safe for concurrent use and must be closed, which Collect and ForEach do.
+type Stream[R any] struct {
+	pull    func() (R, bool, error)
+	close   func() error
+	current R
+	err     error
+	done    bool
+}
+
+// NewStream wraps a cursor into a Stream decoding each document into `R`.
+//
+// Parameters:
+//   - ctx: The context of the iteration.
+//   - cursor: A *mongo.Cursor, a *mongo.ChangeStream or any other Cursor.
+//
+// Returns:
+//   - A pointer to a Stream.
+func NewStream[R any](ctx context.Context, cursor Cursor) *Stream[R] {
+	return &Stream[R]{
+		pull: func() (R, bool, error) {
+			var item R
+			if !cursor.Next(ctx) {
+				return item, false, cursor.Err()
+			}
+			err := cursor.Decode(&item)
+			return item, err == nil, err
+		},
+		close: func() error { return cursor.Close(ctx) },
+	}
+}
+
+// Next advances the stream to its next item. It returns false when the stream is exhausted or failed, see Err.
+func (s *Stream[R]) Next() bool {
+	if s.done {
+		return false
+	}
+
+	item, ok, err := s.pull()
+	if !ok {
+		s.done, s.err = true, err
+		return false
+	}
+
+	s.current = item
+	return true
+}
+
+// Current returns the item the stream was advanced to by Next.
+func (s *Stream[R]) Current() R {
+	return s.current
+}
+
+// Err returns the error that stopped the stream, nil if it was exhausted normally.
+func (s *Stream[R]) Err() error {
+	return s.err
+}
+
+// Close releases the underlying cursor.
+func (s *Stream[R]) Close() error {
+	s.done = true
+	return s.close()
+}
+
+// Filter returns a stream of the items for which keep returns true. Closing either stream closes both.
+//
+// Parameters:
+//   - keep: The predicate selecting the items.
+//
+// Returns:
+//   - A pointer to the filtered Stream.
+func (s *Stream[R]) Filter(keep func(item R) bool) *Stream[R] {
+	return &Stream[R]{
+		pull: func() (R, bool, error) {
+			for s.Next() {
+				if item := s.Current(); keep(item) {
+					return item, true, nil
+				}
+			}
+			var zero R
+			return zero, false, s.Err()
+		},
+		close: s.Close,
+	}
+}
+
+// Collect drains the stream into a slice and closes it.
+//
+// Returns:
+//   - The remaining items of the stream.
+//   - The error that stopped the stream, if any.
+func (s *Stream[R]) Collect() ([]R, error) {
+	defer s.Close()
+
+	var items []R
+	for s.Next() {
+		items = append(items, s.Current())
+	}
+
+	return items, s.Err()
+}
+
+// ForEach calls fn for every remaining item and closes the stream. An error returned by fn stops the iteration.
+//
+// Parameters:
+//   - fn: The function applied to each item.
+//
+// Returns:
+//   - The error returned by fn or the error that stopped the stream, if any.
+func (s *Stream[R]) ForEach(fn func(item R) error) error {
+	defer s.Close()
+
+	for s.Next() {
+		if err := fn(s.Current()); err != nil {
+			return err
+		}
+	}
+
+	return s.Err()
+}
+
+// Map returns a stream of the items of s transformed by fn. An error returned by fn stops the stream.
+// Closing either stream closes both.
+//
+// Parameters:
+//   - s: The source stream.
+//   - fn: The transformation applied to each item.
+//
+// Returns:
+//   - A pointer to the mapped Stream.
+func Map[R, S any](s *Stream[R], fn func(item R) (S, error)) *Stream[S] {
+	return &Stream[S]{
+		pull: func() (S, bool, error) {
+			if !s.Next() {
+				var zero S
+				return zero, false, s.Err()
+			}
+			mapped, err := fn(s.Current())
+			return mapped, err == nil, err
+		},
+		close: s.Close,
+	}
+}
+
+// FindStream is the streaming counterpart of Find, decoding the matching entities one at a time
+// instead of loading them all in memory. They are hydrated and redacted like the entities of Find.
+//
+// Parameters:
+//   - query: A BSON map defining the search criteria.
+//   - opts: Optional FindOptions to modify the query behavior (e.g., sorting, batch size).
+//
+// Returns:
+//   - A Stream of the matching entities.
+//   - An error if the query fails.
+func (r *Repository[T]) FindStream(query bson.M, opts ...*options.FindOptions) (*Stream[*T], error) {
+	if err := r.guardRegion(); err != nil {
+		return nil, err
+	}
+
+	stable, err := stableFindOptions(append([]*options.FindOptions{r.findComment("FindStream")}, opts...))
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := r.Collection().Find(r.config.Context, r.scopeFilter(query), stable...)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.entityStream(cursor), nil
+}
+
+// AggregateStream is the typed counterpart of Aggregate, decoding each result into `T`, hydrated and redacted
+// like the entities of Find. Use NewStream on the cursor of Aggregate for results of another shape, which
+// are decoded as is.
+//
+// Parameters:
+//   - pipeline: A MongoDB aggregation pipeline.
+//   - opts: Optional aggregation options.
+//
+// Returns:
+//   - A Stream of the results.
+//   - An error if the aggregation fails.
+func (r *Repository[T]) AggregateStream(pipeline mongo.Pipeline, opts ...*options.AggregateOptions) (*Stream[*T], error) {
+	cursor, err := r.Aggregate(&pipeline, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.entityStream(cursor), nil
+}
+
+// entityStream wraps a cursor into a Stream decoding each document with decodeEntity.
+func (r *Repository[T]) entityStream(cursor *mongo.Cursor) *Stream[*T] {
+	ctx := r.config.Context
+	return &Stream[*T]{
+		pull: func() (*T, bool, error) {
+			if !cursor.Next(ctx) {
+				return nil, false, cursor.Err()
+			}
+			entity, err := r.decodeEntity(cursor.Current)
+			return entity, err == nil, err
+		},
+		close: func() error { return cursor.Close(ctx) },
+	}
+}
@@ -0,0 +1,223 @@
+package mongorepo
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrUnknownFields is wrapped by the UnknownFieldsError returned by the finders in StrictDecode mode.
+var ErrUnknownFields = errors.New("mongorepo: document has fields unknown to the entity")
+
+// UnknownFieldsError reports the stored fields that have no counterpart in the entity struct,
+// which a later Update would silently drop.
+type UnknownFieldsError struct {
+	ID     any      // The _id of the document.
+	Fields []string // The BSON paths of the unknown fields, e.g. "address.zip"; array elements share the path of the array.
+}
+
+func (e *UnknownFieldsError) Error() string {
+	return ErrUnknownFields.Error() + ": " + strings.Join(e.Fields, ", ")
+}
+
+func (e *UnknownFieldsError) Unwrap() error {
+	return ErrUnknownFields
+}
+
+// UnknownFieldsReport is the outcome of ScanUnknownFields.
+type UnknownFieldsReport struct {
+	Scanned int64            // The number of documents scanned.
+	Fields  map[string]int64 // The number of documents holding each unknown BSON path.
+}
+
+// structKeys describes the BSON keys a struct type decodes.
+type structKeys struct {
+	fields   map[string]reflect.Type // The type of the field decoding each key.
+	catchAll bool                    // Whether an inline map accepts any key.
+}
+
+// structKeysCache caches the structKeys of the struct types, keyed by reflect.Type.
+var structKeysCache sync.Map
+
+var (
+	unmarshalerType      = reflect.TypeOf((*bson.Unmarshaler)(nil)).Elem()
+	valueUnmarshalerType = reflect.TypeOf((*bson.ValueUnmarshaler)(nil)).Elem()
+	zeroerType           = reflect.TypeOf((*bsoncodec.Zeroer)(nil)).Elem()
+)
+
+// ScanUnknownFields streams the documents matching the filter and counts the stored fields that the entity
+// struct does not declare, to detect drift between the Go struct and the stored documents (e.g. in staging)
+// before an Update silently drops data. Soft-deleted documents are included.
+//
+// Parameters:
+//   - filter: A BSON map selecting the documents to scan.
+//
+// Returns:
+//   - The report of the unknown fields.
+//   - An error if the scan fails.
+func (r *Repository[T]) ScanUnknownFields(filter bson.M) (*UnknownFieldsReport, error) {
+	ctx := r.config.Context
+
+	cursor, err := r.Collection().Find(ctx, filter, options.Find().SetBatchSize(defaultBatchSize))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	report := &UnknownFieldsReport{Fields: map[string]int64{}}
+	entityType := reflect.TypeOf((*T)(nil)).Elem()
+	for cursor.Next(ctx) {
+		report.Scanned++
+		for _, field := range unknownFields(cursor.Current, entityType) {
+			report.Fields[field]++
+		}
+	}
+
+	return report, cursor.Err()
+}
+
+// checkUnknownFields returns an UnknownFieldsError if StrictDecode is enabled and the document has fields unknown to `T`.
+func (r *Repository[T]) checkUnknownFields(raw bson.Raw) error {
+	if !r.config.StrictDecode {
+		return nil
+	}
+
+	fields := unknownFields(raw, reflect.TypeOf((*T)(nil)).Elem())
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return &UnknownFieldsError{ID: decodeRawValue(raw.Lookup("_id")), Fields: fields}
+}
+
+// unknownFields returns the sorted, distinct BSON paths of a document that a struct type does not decode.
+func unknownFields(raw bson.Raw, t reflect.Type) []string {
+	found := map[string]bool{}
+	collectUnknownFields(raw, t, "", found)
+
+	fields := make([]string, 0, len(found))
+	for field := range found {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	return fields
+}
+
+// collectUnknownFields walks a document against a struct type, recursing into embedded documents and arrays.
+// The top-level keys maintained by mongorepo itself (locks, leases, ...) are not reported.
+func collectUnknownFields(raw bson.Raw, t reflect.Type, prefix string, found map[string]bool) {
+	keys := structKeysOf(t)
+	if keys == nil || keys.catchAll {
+		return
+	}
+
+	elements, err := raw.Elements()
+	if err != nil {
+		return
+	}
+
+	for _, element := range elements {
+		if prefix == "" && strings.HasPrefix(element.Key(), "_mongorepo_") {
+			continue
+		}
+
+		path := prefix + element.Key()
+		fieldType, ok := keys.fields[element.Key()]
+		if !ok {
+			found[path] = true
+			continue
+		}
+		collectUnknownValue(element.Value(), fieldType, path, found)
+	}
+}
+
+// collectUnknownValue walks a value against the type of the field decoding it.
+func collectUnknownValue(value bson.RawValue, t reflect.Type, path string, found map[string]bool) {
+	t = derefType(t)
+
+	switch value.Type {
+	case bsontype.EmbeddedDocument:
+		if t.Kind() == reflect.Struct {
+			collectUnknownFields(value.Document(), t, path+".", found)
+		}
+	case bsontype.Array:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return
+		}
+		values, err := value.Array().Values()
+		if err != nil {
+			return
+		}
+		for _, item := range values {
+			collectUnknownValue(item, t.Elem(), path, found)
+		}
+	}
+}
+
+// structKeysOf returns the keys decoded by a struct type, nil for types decoded by a custom unmarshaler
+// or that are not structs (maps and interfaces accept any key).
+func structKeysOf(t reflect.Type) *structKeys {
+	t = derefType(t)
+	if t.Kind() != reflect.Struct || customDecoded(t) {
+		return nil
+	}
+
+	if cached, ok := structKeysCache.Load(t); ok {
+		return cached.(*structKeys)
+	}
+
+	keys := &structKeys{fields: map[string]reflect.Type{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, flags, _ := strings.Cut(field.Tag.Get("bson"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if strings.Contains(flags, "inline") {
+			switch inlined := derefType(field.Type); inlined.Kind() {
+			case reflect.Map:
+				keys.catchAll = true
+			case reflect.Struct:
+				if nested := structKeysOf(inlined); nested != nil {
+					for key, fieldType := range nested.fields {
+						keys.fields[key] = fieldType
+					}
+					keys.catchAll = keys.catchAll || nested.catchAll
+				}
+			}
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		keys.fields[name] = field.Type
+	}
+
+	structKeysCache.Store(t, keys)
+	return keys
+}
+
+// customDecoded reports whether a type is decoded by its own unmarshaler rather than field by field.
+// Types such as time.Time are detected through the Zeroer interface the driver uses for them.
+func customDecoded(t reflect.Type) bool {
+	pointer := reflect.PointerTo(t)
+	if pointer.Implements(unmarshalerType) || pointer.Implements(valueUnmarshalerType) {
+		return true
+	}
+
+	return t.NumField() == 0 || (t.Implements(zeroerType) && t.PkgPath() == "time")
+}
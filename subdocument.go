@@ -0,0 +1,155 @@
+package mongorepo
+
+import (
+	"fmt"
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// SubdocumentConfig holds the configuration of a SubdocumentAccessor.
+type SubdocumentConfig struct {
+	ArrayField string // The field in the entity struct holding the embedded documents; must be a slice of the child type.
+	IdField    string // The field in the child struct identifying a child in the array, default: ID
+}
+
+// SubdocumentAccessor works with an array of embedded documents as if it were a repository of its own:
+// children are added, updated and removed in place with array and positional operators, without loading
+// and rewriting the parent document. The UpdatedAt field of the parent is maintained when configured.
+type SubdocumentAccessor[T any, C any] struct {
+	repo     *Repository[T]
+	config   SubdocumentConfig
+	arrayKey string
+	idKey    string
+}
+
+// NewSubdocumentAccessor creates a SubdocumentAccessor for an embedded array of the entities of a repository.
+//
+// Parameters:
+//   - repo: The repository of the parent entities.
+//   - config: The subdocument configuration.
+//
+// Returns:
+//   - A pointer to a SubdocumentAccessor.
+//
+// Panics:
+//   - If config.ArrayField is not set or the fields are not found.
+func NewSubdocumentAccessor[T any, C any](repo *Repository[T], config SubdocumentConfig) *SubdocumentAccessor[T, C] {
+	if config.ArrayField == "" {
+		panic("Configuration error: The SubdocumentConfig.ArrayField is not set.")
+	}
+
+	if config.IdField == "" {
+		config.IdField = "ID"
+	}
+
+	return &SubdocumentAccessor[T, C]{
+		repo:     repo,
+		config:   config,
+		arrayKey: repo.fieldKey(config.ArrayField),
+		idKey:    bsonFieldName(reflect.TypeOf((*C)(nil)).Elem(), config.IdField),
+	}
+}
+
+// AddChild appends a child to the array of a parent. A zero primitive.ObjectID child id is generated first.
+//
+// Parameters:
+//   - parentId: The ID of the parent.
+//   - child: A pointer to the child to add.
+//
+// Returns:
+//   - ErrNotFound if the parent does not exist.
+//   - An error if the update fails.
+func (s *SubdocumentAccessor[T, C]) AddChild(parentId any, child *C) error {
+	id := s.childIdField(child)
+	if id.Type() == reflect.TypeOf(primitive.ObjectID{}) && id.IsZero() {
+		id.Set(reflect.ValueOf(primitive.NewObjectID()))
+	}
+
+	return s.update(bson.M{"_id": parentId}, bson.M{"$push": bson.M{s.arrayKey: child}})
+}
+
+// UpdateChild replaces a child, matched by its id, with the positional $ operator.
+//
+// Parameters:
+//   - parentId: The ID of the parent.
+//   - child: A pointer to the child with the new data.
+//
+// Returns:
+//   - ErrNotFound if the parent or the child does not exist.
+//   - An error if the update fails.
+func (s *SubdocumentAccessor[T, C]) UpdateChild(parentId any, child *C) error {
+	filter := bson.M{"_id": parentId, s.arrayKey + "." + s.idKey: s.childIdField(child).Interface()}
+
+	return s.update(filter, bson.M{"$set": bson.M{s.arrayKey + ".$": child}})
+}
+
+// RemoveChild removes a child, matched by its id, from the array of a parent.
+//
+// Parameters:
+//   - parentId: The ID of the parent.
+//   - childId: The id of the child to remove.
+//
+// Returns:
+//   - ErrNotFound if the parent or the child does not exist.
+//   - An error if the update fails.
+func (s *SubdocumentAccessor[T, C]) RemoveChild(parentId any, childId any) error {
+	filter := bson.M{"_id": parentId, s.arrayKey + "." + s.idKey: childId}
+
+	return s.update(filter, bson.M{"$pull": bson.M{s.arrayKey: bson.M{s.idKey: childId}}})
+}
+
+// FindChildren retrieves the children of a parent matching a filter, in array order.
+//
+// Parameters:
+//   - parentId: The ID of the parent.
+//   - filter: A BSON map on the keys of the children, e.g. bson.M{"status": "open"}; may be nil.
+//
+// Returns:
+//   - A slice of pointers to the matching children.
+//   - An error if the aggregation fails.
+func (s *SubdocumentAccessor[T, C]) FindChildren(parentId any, filter bson.M) ([]*C, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"_id": parentId}}},
+		{{Key: "$unwind", Value: "$" + s.arrayKey}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$" + s.arrayKey}}},
+	}
+	if len(filter) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: filter}})
+	}
+
+	cursor, err := s.repo.Aggregate(&pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	children := []*C{}
+	err = cursor.All(s.repo.config.Context, &children)
+	return children, err
+}
+
+// update runs an update on a parent, maintaining its UpdatedAt field.
+func (s *SubdocumentAccessor[T, C]) update(filter bson.M, update bson.M) error {
+	result, err := s.repo.Collection().UpdateOne(s.repo.config.Context, s.repo.scopeFilter(filter), s.repo.stampUpdate(update))
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errNoDocuments
+	}
+
+	return nil
+}
+
+// childIdField returns the id field of a child.
+func (s *SubdocumentAccessor[T, C]) childIdField(child *C) reflect.Value {
+	field := reflect.ValueOf(child).Elem().FieldByName(s.config.IdField)
+	if !field.IsValid() {
+		panic(fmt.Sprintf("Error: Field %q not found in the child struct.", s.config.IdField))
+	}
+
+	return field
+}
@@ -0,0 +1,99 @@
+package mongorepo
+
+import "context"
+
+// tenantKey is the context key of the request tenant.
+type tenantKey struct{}
+
+// TenantResolver returns the tenant-specific database and collection suffix of the operations run with ctx,
+// e.g. from the tenant bound by WithTenant. An empty dbName keeps the database of the repository, an empty
+// suffix its collection.
+type TenantResolver func(ctx context.Context) (dbName, collectionSuffix string)
+
+// tenantNames are the untenanted database and collection of a repository, and the ones its tenant resolved to.
+type tenantNames struct {
+	dbName, collectionName         string
+	resolvedDb, resolvedCollection string
+}
+
+// WithTenant binds a request context to a tenant, e.g. the tenant of the authenticated user.
+//
+// Parameters:
+//   - ctx: The parent context.
+//   - tenant: The tenant ID.
+//
+// Returns:
+//   - A context carrying the tenant.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant bound to ctx.
+//
+// Parameters:
+//   - ctx: The context.
+//
+// Returns:
+//   - The tenant ID and true, or false when ctx is not bound to a tenant.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	return tenant, ok && tenant != ""
+}
+
+// TenantDatabases is a TenantResolver routing each tenant bound by WithTenant to its own database, named
+// prefix + tenant, e.g. "shop_acme" with the prefix "shop_". Contexts without tenant use the repository database.
+//
+// Parameters:
+//   - prefix: The prefix of the tenant databases.
+//
+// Returns:
+//   - The TenantResolver.
+func TenantDatabases(prefix string) TenantResolver {
+	return func(ctx context.Context) (string, string) {
+		if tenant, ok := TenantFromContext(ctx); ok {
+			return prefix + tenant, ""
+		}
+		return "", ""
+	}
+}
+
+// TenantCollections is a TenantResolver routing each tenant bound by WithTenant to its own collections, suffixed
+// with separator + tenant, e.g. "orders_acme" with the separator "_". Contexts without tenant use the repository
+// collection.
+//
+// Parameters:
+//   - separator: The separator between the collection name and the tenant.
+//
+// Returns:
+//   - The TenantResolver.
+func TenantCollections(separator string) TenantResolver {
+	return func(ctx context.Context) (string, string) {
+		if tenant, ok := TenantFromContext(ctx); ok {
+			return "", separator + tenant
+		}
+		return "", ""
+	}
+}
+
+// resolveTenant points the repository to the database and collection the TenantResolver returns for its context.
+// The untenanted names are kept across views, so resolving again replaces the tenant instead of stacking suffixes;
+// names changed since the last resolution (e.g. by OnCollection) become the new untenanted ones.
+func (r *Repository[T]) resolveTenant() {
+	if r.config.TenantResolver == nil {
+		return
+	}
+
+	names := tenantNames{dbName: r.config.DbName, collectionName: r.config.CollectionName}
+	if r.tenant != nil && r.tenant.resolvedDb == r.config.DbName && r.tenant.resolvedCollection == r.config.CollectionName {
+		names.dbName, names.collectionName = r.tenant.dbName, r.tenant.collectionName
+	}
+
+	dbName, suffix := r.config.TenantResolver(r.config.Context)
+	names.resolvedDb, names.resolvedCollection = names.dbName, names.collectionName+suffix
+	if dbName != "" {
+		names.resolvedDb = dbName
+	}
+
+	r.config.DbName, r.config.CollectionName = names.resolvedDb, names.resolvedCollection
+	r.tenant = &names
+}
@@ -0,0 +1,93 @@
+package mongorepo
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DeleteProgress reports the progress of DeleteManyThrottled.
+type DeleteProgress struct {
+	Deleted int64 // The number of deleted (or soft-deleted) documents.
+	Batches int   // The number of completed batches.
+	LastID  any   // The _id of the last document of the last completed batch.
+}
+
+// DeleteManyThrottled removes every document matching the filter like DeleteMany, in batches of ascending _id
+// ranges with a pause between them, so purging millions of documents does not saturate the cluster (replication
+// lag, cache eviction, oplog churn). Each batch deletes the _ids found after the last one of the previous batch,
+// so a run stopped halfway can simply be started again.
+//
+// Parameters:
+//   - filter: A BSON map selecting the documents to delete.
+//   - batchSize: The number of documents deleted per batch.
+//   - pause: The time waited between two batches.
+//   - onProgress: Called after each batch, may be nil.
+//
+// Returns:
+//   - The progress of the deletion, also on failure.
+//   - The context error if the context is done, or an error if a query or a deletion fails.
+//
+// Panics:
+//   - If batchSize is not positive.
+func (r *Repository[T]) DeleteManyThrottled(filter bson.M, batchSize int, pause time.Duration, onProgress func(progress DeleteProgress)) (*DeleteProgress, error) {
+	if batchSize <= 0 {
+		panic("Configuration error: The batchSize must be positive.")
+	}
+
+	ctx := r.config.Context
+	progress := &DeleteProgress{}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(batchSize)).
+		SetProjection(bson.M{"_id": 1})
+
+	for {
+		query := filter
+		if progress.LastID != nil {
+			query = bson.M{"$and": bson.A{filter, bson.M{"_id": bson.M{"$gt": progress.LastID}}}}
+		}
+
+		cursor, err := r.Collection().Find(ctx, query, r.findComment("DeleteManyThrottled"), opts)
+		if err != nil {
+			return progress, err
+		}
+
+		ids := bson.A{}
+		for cursor.Next(ctx) {
+			ids = append(ids, decodeRawValue(cursor.Current.Lookup("_id")))
+		}
+		err = cursor.Err()
+		cursor.Close(ctx)
+		if err != nil {
+			return progress, err
+		}
+
+		if len(ids) == 0 {
+			return progress, nil
+		}
+
+		deleted, err := r.DeleteMany(bson.M{"$and": bson.A{filter, bson.M{"_id": bson.M{"$in": ids}}}})
+		progress.Deleted += deleted
+		if err != nil {
+			return progress, err
+		}
+
+		progress.Batches++
+		progress.LastID = ids[len(ids)-1]
+		if onProgress != nil {
+			onProgress(*progress)
+		}
+
+		if len(ids) < batchSize {
+			return progress, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return progress, ctx.Err()
+		case <-time.After(pause):
+		}
+	}
+}
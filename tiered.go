@@ -0,0 +1,146 @@
+package mongorepo
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ArchiveLoader loads entities from storage outside MongoDB, e.g. BSON exports kept in S3.
+type ArchiveLoader[T any] interface {
+	// Load returns the archived entities matching the query.
+	Load(ctx context.Context, query bson.M) ([]*T, error)
+}
+
+// TieredRepository reads from a hot collection first and falls back to an archive collection
+// and, optionally, to an external ArchiveLoader, presenting a single read API over tiered data.
+type TieredRepository[T any] struct {
+	hot    *Repository[T]
+	cold   *Repository[T]
+	loader ArchiveLoader[T]
+}
+
+// NewTieredRepository creates a TieredRepository.
+//
+// Parameters:
+//   - hot: The repository of the hot collection, queried first.
+//   - cold: The repository of the archive collection.
+//   - loader: An optional loader for data exported outside MongoDB, may be nil.
+//
+// Returns:
+//   - A pointer to a TieredRepository.
+func NewTieredRepository[T any](hot, cold *Repository[T], loader ArchiveLoader[T]) *TieredRepository[T] {
+	if hot == nil || cold == nil {
+		panic("Configuration error: TieredRepository requires both the hot and the cold repositories.")
+	}
+
+	return &TieredRepository[T]{hot: hot, cold: cold, loader: loader}
+}
+
+// WithContext returns a view of the tiered repository whose MongoDB tiers and ArchiveLoader use ctx.
+//
+// Parameters:
+//   - ctx: The context of the operations of the view.
+//
+// Returns:
+//   - A pointer to a TieredRepository over the same tiers.
+func (t *TieredRepository[T]) WithContext(ctx context.Context) *TieredRepository[T] {
+	return &TieredRepository[T]{hot: t.hot.WithContext(ctx), cold: t.cold.WithContext(ctx), loader: t.loader}
+}
+
+// FindById retrieves an entity by its ID from the first tier holding it.
+//
+// Parameters:
+//   - id: The ID of the entity to retrieve.
+//
+// Returns:
+//   - A pointer to the entity of type `T`.
+//   - ErrNotFound if no tier holds it, or an error if a tier fails.
+func (t *TieredRepository[T]) FindById(id any) (*T, error) {
+	return t.FindOne(bson.M{"_id": id})
+}
+
+// FindOne retrieves a single entity matching the query from the first tier holding one:
+// the hot collection, then the archive collection, then the ArchiveLoader.
+//
+// Parameters:
+//   - query: A BSON map defining the search criteria.
+//   - opts: Optional FindOneOptions, applied to the MongoDB tiers.
+//
+// Returns:
+//   - A pointer to the entity of type `T`.
+//   - ErrNotFound if no tier holds a match, or an error if a tier fails.
+func (t *TieredRepository[T]) FindOne(query bson.M, opts ...*options.FindOneOptions) (*T, error) {
+	for _, repo := range []*Repository[T]{t.hot, t.cold} {
+		entity, err := repo.FindOne(query, opts...)
+		if err == nil {
+			return entity, nil
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+	}
+
+	if t.loader == nil {
+		return nil, errNoDocuments
+	}
+
+	archived, err := t.loader.Load(t.hot.config.Context, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(archived) == 0 {
+		return nil, errNoDocuments
+	}
+	t.hot.redact(archived[0])
+
+	return archived[0], nil
+}
+
+// Find retrieves the entities matching the query across every tier. An entity present in several tiers
+// is returned once, from the hottest tier holding it. Options such as sort or limit apply to each MongoDB tier
+// individually and results are concatenated hot first.
+//
+// Parameters:
+//   - query: A BSON map defining the search criteria.
+//   - opts: Optional FindOptions, applied to the MongoDB tiers.
+//
+// Returns:
+//   - A slice of pointers to the matching entities.
+//   - An error if a tier fails.
+func (t *TieredRepository[T]) Find(query bson.M, opts ...*options.FindOptions) ([]*T, error) {
+	hot, err := t.hot.Find(query, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	cold, err := t.cold.Find(query, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var archived []*T
+	if t.loader != nil {
+		if archived, err = t.loader.Load(t.hot.config.Context, query); err != nil {
+			return nil, err
+		}
+		t.hot.redact(archived...)
+	}
+
+	seen := map[any]bool{}
+	var entities []*T
+	for _, tier := range [][]*T{hot, cold, archived} {
+		for _, entity := range tier {
+			id := NewEntityReflection(t.hot.config, entity).GetID()
+			if !seen[id] {
+				seen[id] = true
+				entities = append(entities, entity)
+			}
+		}
+	}
+
+	return entities, nil
+}
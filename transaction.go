@@ -0,0 +1,100 @@
+package mongorepo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WithTransaction runs fn in a multi-document transaction, committing it when fn returns nil and aborting it
+// otherwise. The session travels in txCtx, so every repository operation run with WithContext(txCtx), on this
+// repository or on any other repository of the same client, is part of the transaction:
+//
+//	err := orders.WithTransaction(ctx, func(txCtx context.Context) error {
+//		if err := orders.WithContext(txCtx).Create(order); err != nil {
+//			return err
+//		}
+//		return stock.WithContext(txCtx).UpdateFields(itemId, bson.M{"reserved": true})
+//	})
+//
+// When ctx carries a session started by BeginSession, the transaction runs on that session.
+// Transient transaction errors and unknown commit results are retried by the driver, so fn may run several
+// times and must not have side effects outside the transaction.
+//
+// Parameters:
+//   - ctx: The context of the transaction.
+//   - fn: The function run in the transaction, receiving the context carrying the session.
+//   - opts: Optional TransactionOptions (read concern, write concern, read preference).
+//
+// Returns:
+//   - ErrUnsupported if the server topology does not support transactions.
+//   - The error returned by fn, or an error if the transaction fails.
+func (r *Repository[T]) WithTransaction(ctx context.Context, fn func(txCtx context.Context) error, opts ...*options.TransactionOptions) error {
+	if err := r.requireCapability("transactions", func(c *Capabilities) bool { return c.Transactions }); err != nil {
+		return err
+	}
+
+	// join the session stashed by BeginSession, if any
+	session := mongo.SessionFromContext(ctx)
+	if session == nil {
+		var err error
+		if session, err = r.config.MongoClient.StartSession(); err != nil {
+			return err
+		}
+		defer session.EndSession(ctx)
+	}
+
+	_, err := session.WithTransaction(ctx, func(txCtx mongo.SessionContext) (any, error) {
+		return nil, fn(txCtx)
+	}, opts...)
+
+	return err
+}
+
+// BeginSession starts a session on client and stashes it in the returned context, so that several repositories
+// share it: every repository operation run with WithContext(sessCtx) detects and uses the session, and
+// WithTransaction(sessCtx, ...) runs its transaction on it. End the session with EndSession when done:
+//
+//	sessCtx, err := mongorepo.BeginSession(ctx, client)
+//	if err != nil {
+//		return err
+//	}
+//	defer mongorepo.EndSession(sessCtx)
+//
+//	err = orders.WithTransaction(sessCtx, func(txCtx context.Context) error {
+//		if err := orders.WithContext(txCtx).Create(order); err != nil {
+//			return err
+//		}
+//		return payments.WithContext(txCtx).Create(payment)
+//	})
+//
+// The repositories sharing a session must use the client the session was started on.
+//
+// Parameters:
+//   - ctx: The parent context.
+//   - client: The client starting the session.
+//   - opts: Optional SessionOptions (causal consistency, default transaction options).
+//
+// Returns:
+//   - The context carrying the session.
+//   - An error if the session cannot be started.
+func BeginSession(ctx context.Context, client *mongo.Client, opts ...*options.SessionOptions) (context.Context, error) {
+	session, err := client.StartSession(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return mongo.NewSessionContext(ctx, session), nil
+}
+
+// EndSession ends the session stashed in ctx by BeginSession, aborting its transaction if one is still running.
+// It does nothing if ctx carries no session.
+//
+// Parameters:
+//   - ctx: The context returned by BeginSession.
+func EndSession(ctx context.Context) {
+	if session := mongo.SessionFromContext(ctx); session != nil {
+		session.EndSession(ctx)
+	}
+}
@@ -0,0 +1,252 @@
+package mongorepo
+
+import (
+	"errors"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrTreeCycle is returned by MoveSubtree when the new parent is the node itself or one of its descendants.
+var ErrTreeCycle = errors.New("mongorepo: cannot move a node under itself or one of its descendants")
+
+// TreeConfig holds the configuration of a Tree.
+//
+// The materialized path of a node lists the hex ids of its ancestors, root first, wrapped in separators:
+// a root node has the path "/", a child of A has "/A/" and a grandchild "/A/B/".
+type TreeConfig struct {
+	ParentField   string // The field in the entity struct holding the parent id; must be a primitive.ObjectID (zero for roots).
+	PathField     string // The field in the entity struct holding the materialized path; must be a string, default: disabled
+	PathSeparator string // The separator of the materialized path, default: "/"
+}
+
+// Tree provides hierarchical queries over entities stored as parent references and, optionally, materialized paths.
+type Tree[T any] struct {
+	repo   *Repository[T]
+	config TreeConfig
+}
+
+// NewTree creates a Tree on top of a repository.
+//
+// Parameters:
+//   - repo: The repository holding the tree nodes.
+//   - config: The tree configuration.
+//
+// Returns:
+//   - A pointer to a Tree.
+//
+// Panics:
+//   - If config.ParentField is not set.
+func NewTree[T any](repo *Repository[T], config TreeConfig) *Tree[T] {
+	if config.ParentField == "" {
+		panic("Configuration error: The TreeConfig.ParentField is not set.")
+	}
+
+	if config.PathSeparator == "" {
+		config.PathSeparator = "/"
+	}
+
+	return &Tree[T]{repo: repo, config: config}
+}
+
+// CreateChild inserts a node under the given parent, filling the parent reference and the materialized path.
+//
+// Parameters:
+//   - parent: A pointer to the parent entity, or nil to create a root node.
+//   - entity: A pointer to the entity of type `T` to be inserted.
+//
+// Returns:
+//   - An error if the insertion fails.
+func (t *Tree[T]) CreateChild(parent *T, entity *T) error {
+	er := NewEntityReflection(t.repo.config, entity)
+
+	if parent == nil {
+		er.SetField(t.config.ParentField, primitive.NilObjectID)
+		if t.config.PathField != "" {
+			er.SetField(t.config.PathField, t.config.PathSeparator)
+		}
+		return t.repo.Create(entity)
+	}
+
+	parentReflection := NewEntityReflection(t.repo.config, parent)
+	er.SetField(t.config.ParentField, parentReflection.GetObjectID())
+	if t.config.PathField != "" {
+		er.SetField(t.config.PathField, t.DescendantsPrefix(parent))
+	}
+
+	return t.repo.Create(entity)
+}
+
+// DescendantsPrefix returns the materialized path prefix shared by every descendant of a node.
+// It panics if PathField is not configured.
+//
+// Parameters:
+//   - node: A pointer to the node entity.
+//
+// Returns:
+//   - The materialized path prefix of the node descendants.
+func (t *Tree[T]) DescendantsPrefix(node *T) string {
+	t.pathKey()
+
+	er := NewEntityReflection(t.repo.config, node)
+	path := er.GetField(t.config.PathField).(string)
+
+	return path + er.GetObjectID().Hex() + t.config.PathSeparator
+}
+
+// FindChildren retrieves the direct children of a node.
+//
+// Parameters:
+//   - id: The ObjectID of the parent node.
+//   - opts: Optional FindOptions to modify the query behavior.
+//
+// Returns:
+//   - A slice of pointers to the child entities.
+//   - An error if the operation fails.
+func (t *Tree[T]) FindChildren(id primitive.ObjectID, opts ...*options.FindOptions) ([]*T, error) {
+	return t.repo.Find(bson.M{t.repo.fieldKey(t.config.ParentField): id}, opts...)
+}
+
+// FindDescendants retrieves every node whose materialized path starts with the given prefix,
+// see DescendantsPrefix to compute the prefix of a node. Requires PathField.
+//
+// Parameters:
+//   - pathPrefix: The materialized path prefix.
+//   - opts: Optional FindOptions to modify the query behavior.
+//
+// Returns:
+//   - A slice of pointers to the descendant entities.
+//   - An error if the operation fails.
+func (t *Tree[T]) FindDescendants(pathPrefix string, opts ...*options.FindOptions) ([]*T, error) {
+	query := bson.M{t.pathKey(): primitive.Regex{Pattern: "^" + regexp.QuoteMeta(pathPrefix)}}
+
+	return t.repo.Find(query, opts...)
+}
+
+// AncestorsOf retrieves the ancestors of a node following the parent references with $graphLookup,
+// ordered from the root to the direct parent.
+//
+// Parameters:
+//   - id: The ObjectID of the node.
+//
+// Returns:
+//   - A slice of pointers to the ancestor entities.
+//   - An error if the operation fails.
+func (t *Tree[T]) AncestorsOf(id primitive.ObjectID) ([]*T, error) {
+	results, err := t.repo.GraphLookup(GraphLookupOptions{
+		StartWith:        bson.M{"_id": id},
+		ConnectFromField: t.repo.fieldKey(t.config.ParentField),
+	})
+	if err != nil || len(results) == 0 {
+		return nil, err
+	}
+
+	// nodes are ordered by depth, the root is the deepest node
+	nodes := results[0].Nodes
+	ancestors := make([]*T, 0, len(nodes))
+	for i := len(nodes) - 1; i >= 0; i-- {
+		ancestors = append(ancestors, nodes[i].Entity)
+	}
+
+	return ancestors, nil
+}
+
+// MoveSubtree moves a node, and therefore its whole subtree, under a new parent.
+// The parent reference of the node is updated and, when PathField is configured, the materialized
+// paths of the node and all its descendants are rewritten. The updates are not atomic across documents,
+// run MoveSubtree inside a transaction when concurrent readers must not observe a partial move.
+// Every updated document gets UpdatedAt and an incremented VersionField, when configured.
+//
+// Parameters:
+//   - id: The ObjectID of the node to move.
+//   - newParentID: The ObjectID of the new parent, or primitive.NilObjectID to turn the node into a root.
+//
+// Returns:
+//   - ErrTreeCycle if the new parent belongs to the moved subtree.
+//   - An error if a node cannot be loaded or updated.
+func (t *Tree[T]) MoveSubtree(id primitive.ObjectID, newParentID primitive.ObjectID) error {
+	if id == newParentID {
+		return ErrTreeCycle
+	}
+
+	node, err := t.repo.FindOne(bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	newPath := t.config.PathSeparator
+	if !newParentID.IsZero() {
+		ancestors, err := t.AncestorsOf(newParentID)
+		if err != nil {
+			return err
+		}
+		for _, ancestor := range ancestors {
+			if NewEntityReflection(t.repo.config, ancestor).GetID() == id {
+				return ErrTreeCycle
+			}
+		}
+
+		if t.config.PathField != "" {
+			parent, err := t.repo.FindOne(bson.M{"_id": newParentID})
+			if err != nil {
+				return err
+			}
+			newPath = t.DescendantsPrefix(parent)
+		}
+	}
+
+	set := bson.M{t.repo.fieldKey(t.config.ParentField): newParentID}
+	if t.config.PathField != "" {
+		set[t.pathKey()] = newPath
+	}
+
+	if err := t.repo.guardRegion(node); err != nil {
+		return err
+	}
+
+	ctx := t.repo.config.Context
+	nodeFilter := t.repo.entityFilter(NewEntityReflection(t.repo.config, node))
+	start := time.Now()
+	_, err = t.repo.Collection().UpdateOne(ctx, nodeFilter, t.repo.stampUpdate(bson.M{"$set": set}), t.repo.updateComment("MoveSubtree"))
+	t.repo.observe("MoveSubtree", nodeFilter, start, 0, err)
+	if err != nil {
+		return classify(err)
+	}
+
+	if t.config.PathField == "" {
+		return nil
+	}
+
+	// rewrite the descendants paths: replace the old path of the moved node by the new one
+	oldPath := NewEntityReflection(t.repo.config, node).GetField(t.config.PathField).(string)
+	oldPrefix := t.DescendantsPrefix(node)
+	pathRef := "$" + t.pathKey()
+
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		t.pathKey(): bson.M{"$concat": bson.A{
+			newPath,
+			bson.M{"$substrCP": bson.A{pathRef, len([]rune(oldPath)), bson.M{"$strLenCP": pathRef}}},
+		}},
+	}}}}
+
+	// soft-deleted descendants are moved too, so restoring them keeps the tree consistent
+	filter := t.repo.regionScope(bson.M{t.pathKey(): primitive.Regex{Pattern: "^" + regexp.QuoteMeta(oldPrefix)}})
+	start = time.Now()
+	_, err = t.repo.Collection().UpdateMany(ctx, filter, t.repo.stampPipeline(update), t.repo.updateComment("MoveSubtree"))
+	t.repo.observe("MoveSubtree", filter, start, 0, err)
+	return classify(err)
+}
+
+// pathKey returns the BSON key of the materialized path field.
+// It panics if PathField is not configured.
+func (t *Tree[T]) pathKey() string {
+	if t.config.PathField == "" {
+		panic("Configuration error: The TreeConfig.PathField is not set.")
+	}
+
+	return t.repo.fieldKey(t.config.PathField)
+}
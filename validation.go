@@ -0,0 +1,313 @@
+package mongorepo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Validator can be implemented by entities to add custom validation to Validate.
+type Validator interface {
+	Validate() error
+}
+
+// ValidationRule is a custom check applied by Validate to every decoded entity.
+type ValidationRule[T any] func(entity *T) error
+
+// Violation describes why a document is invalid.
+type Violation struct {
+	Field   string // The BSON path of the invalid field, empty for document level violations.
+	Message string
+}
+
+// InvalidDocument is a document that failed validation.
+type InvalidDocument struct {
+	ID         any
+	Violations []Violation
+}
+
+// ValidationReport is the outcome of Validate.
+type ValidationReport struct {
+	Scanned     int64             // The number of documents scanned.
+	Invalid     []InvalidDocument // The documents that failed validation.
+	Quarantined int64             // The number of invalid documents copied to the quarantine collection.
+}
+
+// ValidateOptions holds the optional settings of Validate.
+type ValidateOptions struct {
+	QuarantineCollection string // When set, invalid documents are copied to this collection with their violations, default: disabled
+	BatchSize            int32  // The cursor batch size, default: 500
+}
+
+// Validate streams the documents matching the filter and checks each of them against:
+//   - the `validate` struct tags of `T` (required, min=N, max=N, oneof=a b c),
+//   - the Validate method of `T`, if it implements Validator,
+//   - the given rules.
+//
+// Documents that cannot even be decoded into `T` are reported as invalid. This is meant for auditing
+// legacy data before tightening schemas; soft-deleted documents are included.
+//
+// Parameters:
+//   - filter: A BSON map selecting the documents to scan.
+//   - rules: Custom validation rules.
+//   - opts: Optional ValidateOptions (quarantine collection, batch size).
+//
+// Returns:
+//   - The validation report.
+//   - An error if the scan or the quarantine fails.
+func (r *Repository[T]) Validate(filter bson.M, rules []ValidationRule[T], opts ...*ValidateOptions) (*ValidationReport, error) {
+	settings := ValidateOptions{BatchSize: defaultBatchSize}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if opt.QuarantineCollection != "" {
+			settings.QuarantineCollection = opt.QuarantineCollection
+		}
+		if opt.BatchSize > 0 {
+			settings.BatchSize = opt.BatchSize
+		}
+	}
+
+	ctx := r.config.Context
+	cursor, err := r.Collection().Find(ctx, filter, options.Find().SetBatchSize(settings.BatchSize))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	report := &ValidationReport{}
+	for cursor.Next(ctx) {
+		report.Scanned++
+
+		violations := validateDocument(cursor.Current, rules)
+		if len(violations) == 0 {
+			continue
+		}
+
+		id := decodeRawValue(cursor.Current.Lookup("_id"))
+		report.Invalid = append(report.Invalid, InvalidDocument{ID: id, Violations: violations})
+
+		if settings.QuarantineCollection != "" {
+			quarantined := bson.M{
+				"_id":            id,
+				"document":       cursor.Current,
+				"violations":     violations,
+				"quarantined_at": time.Now(),
+			}
+			_, err := r.Database().Collection(settings.QuarantineCollection).
+				ReplaceOne(ctx, bson.M{"_id": id}, quarantined, options.Replace().SetUpsert(true))
+			if err != nil {
+				return report, err
+			}
+			report.Quarantined++
+		}
+	}
+
+	return report, cursor.Err()
+}
+
+// validateDocument decodes a document and applies the tag, Validator and custom validations.
+func validateDocument[T any](raw bson.Raw, rules []ValidationRule[T]) []Violation {
+	var entity T
+	if err := bson.Unmarshal(raw, &entity); err != nil {
+		return []Violation{{Message: "cannot decode document: " + err.Error()}}
+	}
+
+	violations := ValidateStruct(&entity)
+
+	if validator, ok := any(&entity).(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			violations = append(violations, Violation{Message: err.Error()})
+		}
+	}
+
+	for _, rule := range rules {
+		if err := rule(&entity); err != nil {
+			violations = append(violations, Violation{Message: err.Error()})
+		}
+	}
+
+	return violations
+}
+
+// ValidateStruct checks a struct against its `validate` tags, recursing into nested structs and slices of structs.
+// Supported rules, comma separated:
+//   - required: the value is not the zero value.
+//   - min=N / max=N: bounds of numbers, of the length of strings (in characters), slices and maps.
+//   - oneof=a b c: the value, formatted as string, is one of the space separated options.
+//
+// Parameters:
+//   - entity: A struct or a pointer to a struct.
+//
+// Returns:
+//   - The violations found, with BSON paths as field names.
+func ValidateStruct(entity any) []Violation {
+	return validateValue(reflect.ValueOf(entity), "")
+}
+
+// validateValue recursively validates the fields of a struct value.
+func validateValue(v reflect.Value, prefix string) []Violation {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct || isBSONLeaf(v) {
+		return nil
+	}
+
+	var violations []Violation
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("bson")
+		if tag == "-" {
+			continue
+		}
+
+		value := v.Field(i)
+		if strings.Contains(tag, ",inline") {
+			violations = append(violations, validateNested(value, prefix)...)
+			continue
+		}
+
+		path := bsonFieldName(v.Type(), field.Name)
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+
+		for _, rule := range parseValidateTag(field.Tag.Get("validate")) {
+			if err := checkRule(rule, value); err != nil {
+				violations = append(violations, Violation{Field: path, Message: err.Error()})
+			}
+		}
+
+		violations = append(violations, validateNested(value, path)...)
+	}
+
+	return violations
+}
+
+// validateNested validates nested structs and the struct elements of slices.
+func validateNested(value reflect.Value, path string) []Violation {
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		return validateValue(value, path)
+	case reflect.Slice, reflect.Array:
+		if elem := value.Type().Elem().Kind(); elem != reflect.Struct && elem != reflect.Pointer && elem != reflect.Interface {
+			return nil
+		}
+
+		var violations []Violation
+		for i := 0; i < value.Len(); i++ {
+			violations = append(violations, validateNested(value.Index(i), path+"."+strconv.Itoa(i))...)
+		}
+		return violations
+	}
+
+	return nil
+}
+
+// validateRule is a parsed rule of a `validate` tag.
+type validateRule struct {
+	name string
+	arg  string
+}
+
+// parseValidateTag parses a `validate` tag such as "required,min=3,oneof=a b".
+func parseValidateTag(tag string) []validateRule {
+	var rules []validateRule
+	for _, part := range strings.Split(tag, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, arg, _ := strings.Cut(part, "=")
+		rules = append(rules, validateRule{name: name, arg: arg})
+	}
+
+	return rules
+}
+
+// checkRule applies a single validation rule to a field value.
+func checkRule(rule validateRule, value reflect.Value) error {
+	if rule.name == "required" {
+		if value.IsZero() {
+			return errors.New("is required")
+		}
+		return nil
+	}
+
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil // optional values are only checked when present
+		}
+		value = value.Elem()
+	}
+
+	switch rule.name {
+	case "min", "max":
+		limit, err := strconv.ParseFloat(rule.arg, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s rule %q", rule.name, rule.arg)
+		}
+
+		measure, kind, ok := measureValue(value)
+		if !ok {
+			return nil
+		}
+		if rule.name == "min" && measure < limit {
+			return fmt.Errorf("%s must be at least %s", kind, rule.arg)
+		}
+		if rule.name == "max" && measure > limit {
+			return fmt.Errorf("%s must be at most %s", kind, rule.arg)
+		}
+	case "oneof":
+		actual := fmt.Sprint(value.Interface())
+		for _, option := range strings.Fields(rule.arg) {
+			if actual == option {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of [%s]", rule.arg)
+	}
+
+	return nil
+}
+
+// measureValue returns the number compared by min/max rules: numbers themselves, lengths otherwise.
+func measureValue(value reflect.Value) (float64, string, bool) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), "value", true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), "value", true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), "value", true
+	case reflect.String:
+		return float64(len([]rune(value.String()))), "length", true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(value.Len()), "length", true
+	}
+
+	return 0, "", false
+}
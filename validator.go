@@ -0,0 +1,63 @@
+package mongorepo
+
+import (
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ValidatorOptions holds the options of EnsureValidator.
+type ValidatorOptions struct {
+	Level  string // Which documents are validated: "strict" (every write) or "moderate" (not the already invalid ones), default: "strict"
+	Action string // What happens to an invalid write: "error" (rejected) or "warn" (logged by the server), default: "error"
+}
+
+// EnsureValidator creates the collection, or updates the existing one, with a $jsonSchema validator built from
+// the `bson` and `validate` tags of `T` (see ExportMongoSchema), so the server rejects documents of the wrong
+// BSON type, missing a `required` field or out of their min/max/oneof bounds, whoever writes them. Run it at
+// startup or from a migration; the validator is replaced by the current schema on each call.
+//
+// Parameters:
+//   - opts: Optional ValidatorOptions (validation level and action).
+//
+// Returns:
+//   - An error if the collection cannot be created or modified.
+func (r *Repository[T]) EnsureValidator(opts ...*ValidatorOptions) error {
+	settings := ValidatorOptions{Level: "strict", Action: "error"}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if opt.Level != "" {
+			settings.Level = opt.Level
+		}
+		if opt.Action != "" {
+			settings.Action = opt.Action
+		}
+	}
+
+	ctx := r.config.Context
+	validator := bson.M{"$jsonSchema": ExportMongoSchema[T]()}
+
+	create := options.CreateCollection().
+		SetValidator(validator).
+		SetValidationLevel(settings.Level).
+		SetValidationAction(settings.Action)
+
+	err := r.Database().CreateCollection(ctx, r.config.CollectionName, create)
+	var cmdErr mongo.CommandError
+	if !(errors.As(err, &cmdErr) && cmdErr.Name == "NamespaceExists") {
+		return err
+	}
+
+	command := bson.D{
+		{Key: "collMod", Value: r.config.CollectionName},
+		{Key: "validator", Value: validator},
+		{Key: "validationLevel", Value: settings.Level},
+		{Key: "validationAction", Value: settings.Action},
+	}
+
+	return r.Database().RunCommand(ctx, command).Err()
+}
@@ -0,0 +1,111 @@
+package mongorepo
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ReadinessReport is the outcome of WarmUp.
+type ReadinessReport struct {
+	CollectionExists bool          // Whether the collection exists.
+	Indexes          []string      // The names of the built indexes.
+	MissingIndexes   []string      // The required indexes that do not exist.
+	BuildingIndexes  []string      // The indexes still being built.
+	ReadLatency      time.Duration // The duration of the sample read.
+	Ready            bool          // Whether the collection exists, every required index is built and the sample read succeeded.
+}
+
+// WarmUp checks that the repository is ready to serve traffic, to be run before marking a service healthy after
+// a deploy: the collection must exist, the required indexes must be built (not in progress) and a small read must
+// succeed, which also opens the connections of the pool. In-progress builds are found with $currentOp and are not
+// reported when the user lacks the privilege to run it.
+//
+// Parameters:
+//   - requiredIndexes: The names of the indexes the service needs, e.g. "email_1".
+//
+// Returns:
+//   - The readiness report; Ready is false if any check failed.
+//   - An error if a check could not be performed.
+func (r *Repository[T]) WarmUp(requiredIndexes ...string) (*ReadinessReport, error) {
+	ctx := r.config.Context
+	report := &ReadinessReport{}
+
+	names, err := r.Database().ListCollectionNames(ctx, bson.M{"name": r.config.CollectionName})
+	if err != nil {
+		return report, err
+	}
+	report.CollectionExists = len(names) > 0
+
+	if report.CollectionExists {
+		specs, err := r.Collection().Indexes().ListSpecifications(ctx)
+		if err != nil {
+			return report, err
+		}
+		// depending on the server version, the indexes being built may be listed too
+		report.BuildingIndexes = r.buildingIndexes()
+		for _, spec := range specs {
+			if !slices.Contains(report.BuildingIndexes, spec.Name) {
+				report.Indexes = append(report.Indexes, spec.Name)
+			}
+		}
+	}
+
+	for _, name := range requiredIndexes {
+		if !slices.Contains(report.Indexes, name) && !slices.Contains(report.BuildingIndexes, name) {
+			report.MissingIndexes = append(report.MissingIndexes, name)
+		}
+	}
+
+	start := time.Now()
+	err = r.Collection().FindOne(ctx, bson.M{}).Err()
+	report.ReadLatency = time.Since(start)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return report, err
+	}
+
+	report.Ready = report.CollectionExists && len(report.MissingIndexes) == 0 && len(report.BuildingIndexes) == 0
+	return report, nil
+}
+
+// buildingIndexes returns the names of the indexes of the collection being built, according to $currentOp.
+// Nothing is returned if $currentOp is not available.
+func (r *Repository[T]) buildingIndexes() []string {
+	ctx := r.config.Context
+	pipeline := mongo.Pipeline{
+		{{Key: "$currentOp", Value: bson.M{"allUsers": true}}},
+		{{Key: "$match", Value: bson.M{
+			"command.createIndexes": r.config.CollectionName,
+			"command.$db":           r.config.DbName,
+		}}},
+	}
+
+	cursor, err := r.config.MongoClient.Database("admin").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil
+	}
+	defer cursor.Close(ctx)
+
+	var building []string
+	for cursor.Next(ctx) {
+		var op struct {
+			Command struct {
+				Indexes []struct {
+					Name string `bson:"name"`
+				} `bson:"indexes"`
+			} `bson:"command"`
+		}
+		if err := cursor.Decode(&op); err != nil {
+			continue
+		}
+
+		for _, index := range op.Command.Indexes {
+			building = append(building, index.Name)
+		}
+	}
+
+	return building
+}
@@ -0,0 +1,55 @@
+package mongorepo
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ChangeEvent is a change stream event of the repository collection.
+type ChangeEvent[T any] struct {
+	ResumeToken       bson.Raw            `bson:"_id"`           // The token to resume the stream after this event.
+	OperationType     string              `bson:"operationType"` // insert, update, replace, delete, invalidate...
+	ClusterTime       primitive.Timestamp `bson:"clusterTime"`
+	DocumentKey       bson.M              `bson:"documentKey"`
+	FullDocument      *T                  `bson:"fullDocument,omitempty"` // Set for inserts and replaces, and for updates with a FullDocument option.
+	UpdateDescription *UpdateDescription  `bson:"updateDescription,omitempty"`
+
+	// Set for updates, replaces and deletes with a FullDocumentBeforeChange option, when pre-images are enabled.
+	FullDocumentBeforeChange *T `bson:"fullDocumentBeforeChange,omitempty"`
+}
+
+// UpdateDescription describes the fields changed by an update event.
+type UpdateDescription struct {
+	UpdatedFields bson.M   `bson:"updatedFields"`
+	RemovedFields []string `bson:"removedFields"`
+}
+
+// Watch opens a change stream on the repository collection and returns its events. The stream blocks
+// in Next until an event arrives or the context of the configuration is done.
+//
+// Parameters:
+//   - pipeline: An aggregation pipeline filtering or reshaping the events, may be nil.
+//   - opts: Optional ChangeStreamOptions, e.g. SetFullDocument(options.UpdateLookup) or SetResumeAfter.
+//
+// Returns:
+//   - A Stream of change events.
+//   - ErrUnsupported if the deployment does not support change streams (standalone servers).
+//   - An error if the stream cannot be opened.
+func (r *Repository[T]) Watch(pipeline mongo.Pipeline, opts ...*options.ChangeStreamOptions) (*Stream[ChangeEvent[T]], error) {
+	if err := r.requireCapability("change streams", func(c *Capabilities) bool { return c.ChangeStreams }); err != nil {
+		return nil, err
+	}
+
+	if pipeline == nil {
+		pipeline = mongo.Pipeline{}
+	}
+
+	changeStream, err := r.Collection().Watch(r.config.Context, pipeline, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewStream[ChangeEvent[T]](r.config.Context, changeStream), nil
+}